@@ -0,0 +1,413 @@
+// Package backup backs up a Tumblr blog to a directory: its posts, and optionally its drafts,
+// queue, submissions and likes, each as a JSON file, and the media they reference.
+//
+// A backup directory holds:
+//
+//	manifest.json         the Manifest: how far the backup got and the downloaded media
+//	blog.json             the information of the blog
+//	posts/<id>.json       the published posts, as returned by the API
+//	drafts/<id>.json      the drafts, queue/<id>.json the queued posts, submissions/<id>.json
+//	likes/<id>.json       the posts the blog liked
+//	media/<hash>-<name>   the photos, videos and audio files of the posts
+//
+// Runs are incremental: once a directory holds a backup, only the posts newer than the newest
+// backed up post are fetched, along with the drafts, queue and submissions, which are always
+// fetched anew. Posts deleted from the blog are kept in the backup.
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spbr/gotumblr"
+)
+
+// Kinds of posts, and the directories they are stored in.
+const (
+	Posts       = "posts"
+	Drafts      = "drafts"
+	Queue       = "queue"
+	Submissions = "submissions"
+	Likes       = "likes"
+)
+
+// BlogFile is the name of the file holding the information of the blog in a backup directory.
+const BlogFile = "blog.json"
+
+// Backup backs up a blog. The fields must be set before Run is called.
+type Backup struct {
+	Dir  string
+	Blog gotumblr.BlogIdentifier
+
+	Drafts      bool // back up the drafts
+	Queue       bool // back up the queued posts
+	Submissions bool // back up the submitted posts
+	Likes       bool // back up the posts the blog liked
+	Media       bool // download the media of the posts (set by New)
+	// Full fetches all the posts, instead of only those newer than the last backup.
+	Full bool
+	// Verify checks the checksums of all the media downloaded by previous runs,
+	// and downloads again the files that changed. Otherwise only their size is checked.
+	Verify bool
+	// Concurrency is the number of media downloaded at once, 4 by default.
+	Concurrency int
+	// HTTPClient downloads the media, http.DefaultClient if nil.
+	HTTPClient *http.Client
+	// Logf, if set, is called to report the progress of the backup.
+	Logf func(format string, args ...interface{})
+
+	client gotumblr.TumblrReader
+}
+
+// Summary tells what a run of a Backup did.
+type Summary struct {
+	Posts       int // number of posts of each kind written
+	Drafts      int
+	Queue       int
+	Submissions int
+	Likes       int
+	Media       int   // number of media files downloaded
+	MediaBytes  int64 // total size of the media files downloaded
+	// MediaErrors are the errors of the media that could not be downloaded;
+	// they do not stop the backup, and are downloaded again by the next run (see Manifest.PendingMedia).
+	MediaErrors []error
+}
+
+// pageSize is the number of posts requested at once.
+const pageSize = 20
+
+// New creates a Backup of blog to dir, downloading the media.
+func New(client gotumblr.TumblrReader, blog gotumblr.BlogIdentifier, dir string) *Backup {
+	return &Backup{Dir: dir, Blog: blog, Media: true, client: client}
+}
+
+// Run backs up the blog. The posts written before an error are kept,
+// and the next run starts over from the last complete backup.
+func (b *Backup) Run(ctx context.Context) (*Summary, error) {
+	for _, dir := range []string{Posts, Drafts, Queue, Submissions, Likes, MediaDir} {
+		if err := os.MkdirAll(filepath.Join(b.Dir, dir), 0755); err != nil {
+			return nil, err
+		}
+	}
+	manifest, err := LoadManifest(b.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Blog != "" && manifest.Blog != b.Blog.Hostname() {
+		return nil, fmt.Errorf("backup: %s holds a backup of %s", b.Dir, manifest.Blog)
+	}
+	manifest.Blog = b.Blog.Hostname()
+	summary := new(Summary)
+
+	info, err := b.client.BlogInfo(b.Blog)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeJSON(filepath.Join(b.Dir, BlogFile), info.Blog); err != nil {
+		return nil, err
+	}
+
+	var media []string
+	collect := func(posts []gotumblr.Post) {
+		for _, post := range posts {
+			media = append(media, MediaURLs(post)...)
+		}
+	}
+
+	since := manifest.NewestPostID
+	if b.Full {
+		since = 0
+	}
+	posts, err := b.walk(ctx, Posts, func(offset int, _ gotumblr.PostID) ([]json.RawMessage, error) {
+		response, err := b.client.Posts(b.Blog, "", b.page(offset))
+		if err != nil {
+			return nil, err
+		}
+		return response.Posts, nil
+	}, func(page []gotumblr.Post) bool {
+		return page[len(page)-1].Base().ID <= since
+	})
+	summary.Posts = len(posts)
+	collect(posts)
+	if err != nil {
+		return summary, err
+	}
+	for _, post := range posts {
+		if id := post.Base().ID; id > manifest.NewestPostID {
+			manifest.NewestPostID = id
+		}
+	}
+
+	for _, listing := range []struct {
+		enabled bool
+		kind    string
+		count   *int
+		fetch   func(offset int, last gotumblr.PostID) ([]json.RawMessage, error)
+	}{
+		{b.Drafts, Drafts, &summary.Drafts, func(_ int, last gotumblr.PostID) ([]json.RawMessage, error) {
+			options := map[string]string{}
+			if last != 0 {
+				options["before_id"] = last.String()
+			}
+			response, err := b.client.Drafts(b.Blog, options)
+			if err != nil {
+				return nil, err
+			}
+			return response.Posts, nil
+		}},
+		{b.Queue, Queue, &summary.Queue, func(offset int, _ gotumblr.PostID) ([]json.RawMessage, error) {
+			response, err := b.client.Queue(b.Blog, b.page(offset))
+			if err != nil {
+				return nil, err
+			}
+			return response.Posts, nil
+		}},
+		{b.Submissions, Submissions, &summary.Submissions, func(offset int, _ gotumblr.PostID) ([]json.RawMessage, error) {
+			response, err := b.client.Submission(b.Blog, map[string]string{"offset": strconv.Itoa(offset)})
+			if err != nil {
+				return nil, err
+			}
+			return response.Posts, nil
+		}},
+	} {
+		if !listing.enabled {
+			continue
+		}
+		posts, err := b.walk(ctx, listing.kind, listing.fetch, nil)
+		*listing.count = len(posts)
+		collect(posts)
+		if err != nil {
+			return summary, err
+		}
+		// drafts, queued posts and submissions come and go: only keep the current ones
+		if err := b.prune(listing.kind, posts); err != nil {
+			return summary, err
+		}
+	}
+
+	if b.Likes {
+		newestLike := manifest.NewestLikeID
+		if b.Full {
+			newestLike = 0
+		}
+		likes, err := b.walk(ctx, Likes, func(offset int, _ gotumblr.PostID) ([]json.RawMessage, error) {
+			response, err := b.client.BlogLikes(b.Blog, b.page(offset))
+			if err != nil {
+				return nil, err
+			}
+			return response.LikedPosts, nil
+		}, func(page []gotumblr.Post) bool {
+			for _, post := range page {
+				if newestLike != 0 && post.Base().ID == newestLike {
+					return true
+				}
+			}
+			return false
+		})
+		summary.Likes = len(likes)
+		collect(likes)
+		if err != nil {
+			return summary, err
+		}
+		if len(likes) != 0 {
+			manifest.NewestLikeID = likes[0].Base().ID
+		}
+	}
+
+	if b.Media {
+		// the media of previous runs are checked again, and those that could not be downloaded retried
+		known := make([]string, 0, len(manifest.Media))
+		for u := range manifest.Media {
+			known = append(known, u)
+		}
+		sort.Strings(known)
+		media = append(append(media, manifest.PendingMedia...), known...)
+		manifest.PendingMedia = b.downloadMedia(ctx, manifest, media, summary)
+	}
+	manifest.Updated = time.Now().UTC()
+	if err := manifest.Save(b.Dir); err != nil {
+		return summary, err
+	}
+	if err := ctx.Err(); err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+// walk fetches the pages of a listing, from the newest posts, and writes their posts.
+// fetch is called with the number of posts fetched so far and the id of the last one.
+// The walk ends with an empty page, a page without new posts, or once done returns true for a page.
+// It returns the posts written.
+func (b *Backup) walk(ctx context.Context, kind string, fetch func(offset int, last gotumblr.PostID) ([]json.RawMessage, error), done func([]gotumblr.Post) bool) ([]gotumblr.Post, error) {
+	var posts []gotumblr.Post
+	seen := map[gotumblr.PostID]bool{}
+	offset, last := 0, gotumblr.PostID(0)
+	for {
+		if err := ctx.Err(); err != nil {
+			return posts, err
+		}
+		raw, err := fetch(offset, last)
+		if err != nil {
+			return posts, err
+		}
+		if len(raw) == 0 {
+			return posts, nil
+		}
+		page := make([]gotumblr.Post, 0, len(raw))
+		fresh := 0
+		for _, data := range raw {
+			post, err := gotumblr.DecodePost(data)
+			if err != nil {
+				return posts, err
+			}
+			page = append(page, post)
+			id := post.Base().ID
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			fresh++
+			if err := writePost(filepath.Join(b.Dir, kind, id.String()+".json"), data); err != nil {
+				return posts, err
+			}
+			posts = append(posts, post)
+		}
+		b.logf("%s: %d posts", kind, len(posts))
+		if fresh == 0 || done != nil && done(page) {
+			return posts, nil
+		}
+		offset += len(raw)
+		last = page[len(page)-1].Base().ID
+	}
+}
+
+// prune removes the files of the posts of a kind that are not in posts.
+func (b *Backup) prune(kind string, posts []gotumblr.Post) error {
+	keep := map[string]bool{}
+	for _, post := range posts {
+		keep[post.Base().ID.String()+".json"] = true
+	}
+	dir := filepath.Join(b.Dir, kind)
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".json") && !keep[entry.Name()] {
+			if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// downloadMedia downloads the media that are not already in the backup, Concurrency at a time.
+// It returns the URLs of the media that could not be downloaded.
+func (b *Backup) downloadMedia(ctx context.Context, manifest *Manifest, urls []string, summary *Summary) []string {
+	var missing []string
+	queued := map[string]bool{}
+	for _, u := range urls {
+		if queued[u] {
+			continue
+		}
+		queued[u] = true
+		if media, ok := manifest.Media[u]; ok && checkMedia(b.Dir, media, b.Verify) {
+			continue
+		}
+		missing = append(missing, u)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	b.logf("media: downloading %d files", len(missing))
+	client := b.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	concurrency := b.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	pending := map[string]bool{}
+	for _, u := range missing {
+		pending[u] = true
+	}
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan string)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range jobs {
+				media, err := download(ctx, client, u, b.Dir)
+				mutex.Lock()
+				if err != nil {
+					summary.MediaErrors = append(summary.MediaErrors, err)
+				} else {
+					delete(pending, u)
+					manifest.Media[u] = media
+					summary.Media++
+					summary.MediaBytes += media.Size
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+	for _, u := range missing {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- u
+	}
+	close(jobs)
+	wg.Wait()
+	var failed []string
+	for _, u := range missing {
+		if pending[u] {
+			failed = append(failed, u)
+		}
+	}
+	return failed
+}
+
+// page returns the options requesting a page of posts.
+func (b *Backup) page(offset int) map[string]string {
+	return map[string]string{"offset": strconv.Itoa(offset), "limit": strconv.Itoa(pageSize)}
+}
+
+func (b *Backup) logf(format string, args ...interface{}) {
+	if b.Logf != nil {
+		b.Logf(format, args...)
+	}
+}
+
+// writePost writes the JSON of a post, indented.
+func writePost(path string, data json.RawMessage) error {
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, "", "  "); err != nil {
+		return err
+	}
+	indented.WriteByte('\n')
+	return writeFile(path, indented.Bytes())
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(path, append(data, '\n'))
+}
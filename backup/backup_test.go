@@ -0,0 +1,109 @@
+package backup_test
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/spbr/gotumblr"
+	"github.com/spbr/gotumblr/backup"
+	"github.com/spbr/gotumblr/tumblrtest"
+)
+
+func TestMediaRetriedAndVerified(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	for _, name := range []string{"a.png", "b.png", "c.png"} {
+		server.AddPost("tester", map[string]interface{}{"type": "photo", "photos": []interface{}{
+			map[string]interface{}{"original_size": map[string]interface{}{"url": server.URL + "/media/" + name, "width": 10, "height": 10}},
+		}})
+	}
+	dir := t.TempDir()
+	blog := gotumblr.MustParseBlogIdentifier("tester")
+
+	server.InjectFault(tumblrtest.Fault{Path: "/media/b.png", Status: 500})
+	summary, err := backup.New(server.Client(), blog, dir).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Media != 2 || len(summary.MediaErrors) != 1 {
+		t.Fatalf("first run: %d media, errors %v, want 2 media and 1 error", summary.Media, summary.MediaErrors)
+	}
+	manifest, err := backup.LoadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.PendingMedia) != 1 || manifest.PendingMedia[0] != server.URL+"/media/b.png" {
+		t.Errorf("pending media = %q, want b.png", manifest.PendingMedia)
+	}
+
+	// the incremental run fetches no new post, but retries the media that failed
+	server.ClearFaults()
+	summary, err = backup.New(server.Client(), blog, dir).Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Media != 1 || len(summary.MediaErrors) != 0 {
+		t.Errorf("second run: %d media, errors %v, want the failed media", summary.Media, summary.MediaErrors)
+	}
+	manifest, err = backup.LoadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.PendingMedia) != 0 || len(manifest.Media) != 3 {
+		t.Errorf("manifest has %d media and pending %q, want 3 and none", len(manifest.Media), manifest.PendingMedia)
+	}
+
+	// Verify downloads again the files of previous runs whose content changed
+	path := filepath.Join(dir, manifest.Media[server.URL+"/media/a.png"].Path)
+	if err := ioutil.WriteFile(path, []byte("media:/media/a.pnG"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	b := backup.New(server.Client(), blog, dir)
+	b.Verify = true
+	if summary, err = b.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if summary.Media != 1 {
+		t.Errorf("verified run: %d media downloaded, want the changed one", summary.Media)
+	}
+	if data, _ := ioutil.ReadFile(path); string(data) != "media:/media/a.png" {
+		t.Errorf("the changed file holds %q after the verified run", data)
+	}
+}
+
+func TestRestoreAnswer(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	server.AddPost("tester", map[string]interface{}{
+		"type": "answer", "asking_name": "staff", "question": "How?", "answer": "<p>Like this.</p>",
+		"tags": []string{"ask"}, "timestamp": 1708444800,
+	})
+	dir := t.TempDir()
+	blog := gotumblr.MustParseBlogIdentifier("tester")
+	if _, err := backup.New(server.Client(), blog, dir).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	restore := backup.NewRestore(server.Client(), dir, blog)
+	restore.Kinds = []string{backup.Posts}
+	summary, err := restore.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Created != 1 || len(summary.Unsupported) != 0 {
+		t.Fatalf("summary %+v, want the answer created", summary)
+	}
+	entries, err := backup.ReadJournal(filepath.Join(dir, backup.JournalFile(blog)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var created map[string]interface{}
+	for _, newID := range entries {
+		created, _ = server.Post(newID)
+	}
+	want := "<p>staff asked:</p><blockquote>How?</blockquote><p>Like this.</p>"
+	if created["type"] != "text" || created["body"] != want || created["timestamp"] != int64(1708444800) {
+		t.Errorf("created post %v, want a text post with the body %s", created, want)
+	}
+}
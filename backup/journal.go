@@ -0,0 +1,92 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spbr/gotumblr"
+)
+
+// JournalFile returns the name of the journal of the restores of a backup directory to a blog.
+func JournalFile(blog gotumblr.BlogIdentifier) string {
+	return "restore-" + blog.Hostname() + ".jsonl"
+}
+
+// JournalEntry records a post created by a restore, one JSON object per line of the journal.
+type JournalEntry struct {
+	Kind  string          `json:"kind"`   // kind of the post in the backup, e.g. Posts
+	OldID gotumblr.PostID `json:"old_id"` // id of the post in the backup
+	NewID gotumblr.PostID `json:"new_id"` // id of the post created
+}
+
+// ReadJournal reads a journal and returns the ids of the created posts by the ids of the backed up posts.
+// A missing journal gives an empty map. A last line cut short by a crash is ignored.
+func ReadJournal(path string) (map[gotumblr.PostID]gotumblr.PostID, error) {
+	ids := map[gotumblr.PostID]gotumblr.PostID{}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ids, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			if i == len(lines)-1 {
+				break
+			}
+			return nil, fmt.Errorf("backup: reading %s, line %d: %v", path, i+1, err)
+		}
+		ids[entry.OldID] = entry.NewID
+	}
+	return ids, nil
+}
+
+// journal appends entries to a journal, syncing each one to disk before the next post is created.
+type journal struct {
+	file   *os.File
+	writer *bufio.Writer
+}
+
+// openJournal opens a journal for appending, dropping a last line cut short by a crash.
+func openJournal(path string) (*journal, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(data) != 0 && data[len(data)-1] != '\n' {
+		if err := os.Truncate(path, int64(bytes.LastIndexByte(data, '\n')+1)); err != nil {
+			return nil, err
+		}
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &journal{file: file, writer: bufio.NewWriter(file)}, nil
+}
+
+func (j *journal) record(entry JournalEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	j.writer.Write(append(data, '\n'))
+	if err := j.writer.Flush(); err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+func (j *journal) Close() error {
+	return j.file.Close()
+}
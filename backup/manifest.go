@@ -0,0 +1,88 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spbr/gotumblr"
+)
+
+// ManifestFile is the name of the manifest in a backup directory.
+const ManifestFile = "manifest.json"
+
+// Manifest describes the content of a backup directory.
+type Manifest struct {
+	Blog    string    `json:"blog"`    // hostname of the blog
+	Updated time.Time `json:"updated"` // time of the last run
+	// NewestPostID is the id of the newest published post backed up;
+	// the next incremental run stops at the posts older than it.
+	NewestPostID gotumblr.PostID `json:"newest_post_id,omitempty"`
+	// NewestLikeID is the id of the most recently liked post backed up.
+	NewestLikeID gotumblr.PostID `json:"newest_like_id,omitempty"`
+	// Media maps the URLs of the downloaded media to their files.
+	Media map[string]MediaFile `json:"media,omitempty"`
+	// PendingMedia are the URLs of the media that could not be downloaded; the next run tries them again.
+	PendingMedia []string `json:"pending_media,omitempty"`
+}
+
+// MediaFile is a downloaded media file.
+type MediaFile struct {
+	Path   string `json:"path"`   // path of the file, relative to the backup directory
+	SHA256 string `json:"sha256"` // hex encoded SHA-256 checksum of the content
+	Size   int64  `json:"size"`
+}
+
+// LoadManifest reads the manifest of a backup directory.
+// It returns an empty manifest if the directory has none.
+func LoadManifest(dir string) (*Manifest, error) {
+	manifest := &Manifest{Media: map[string]MediaFile{}}
+	data, err := ioutil.ReadFile(filepath.Join(dir, ManifestFile))
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("backup: reading %s: %v", ManifestFile, err)
+	}
+	if manifest.Media == nil {
+		manifest.Media = map[string]MediaFile{}
+	}
+	return manifest, nil
+}
+
+// Save writes the manifest to a backup directory.
+func (m *Manifest) Save(dir string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, ManifestFile), append(data, '\n'))
+}
+
+// writeFile writes a file atomically, through a temporary file renamed once written.
+func writeFile(path string, data []byte) error {
+	file, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
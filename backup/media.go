@@ -0,0 +1,166 @@
+package backup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spbr/gotumblr"
+)
+
+// MediaDir is the directory of the downloaded media in a backup directory.
+const MediaDir = "media"
+
+// embeddedMedia matches the sources of the images and videos embedded in the HTML of posts.
+var embeddedMedia = regexp.MustCompile(`<(?:img|source|video)\b[^>]*\bsrc="([^"]+)"`)
+
+// MediaURLs returns the URLs of the media hosted by Tumblr that a post references:
+// the original size of its photos, its video or audio file, and the images and videos
+// embedded in its text, caption or answer.
+func MediaURLs(post gotumblr.Post) []string {
+	var urls []string
+	var html []string
+	switch post := post.(type) {
+	case *gotumblr.PhotoPost:
+		for i := range post.Photos {
+			urls = append(urls, post.Photos[i].Original().URL)
+		}
+		html = append(html, post.Caption)
+	case *gotumblr.LinkPost:
+		for i := range post.Photos {
+			urls = append(urls, post.Photos[i].Original().URL)
+		}
+		html = append(html, post.Description)
+	case *gotumblr.VideoPost:
+		if post.VideoType == "tumblr" {
+			urls = append(urls, post.VideoURL)
+		}
+		html = append(html, post.Caption)
+	case *gotumblr.AudioPost:
+		if post.AudioType == "tumblr" {
+			urls = append(urls, post.AudioURL)
+		}
+		html = append(html, post.Caption)
+	case *gotumblr.TextPost:
+		html = append(html, post.Body)
+	case *gotumblr.AnswerPost:
+		html = append(html, post.Answer)
+	case *gotumblr.QuotePost:
+		html = append(html, post.Text, post.Source)
+	}
+	for _, fragment := range html {
+		for _, match := range embeddedMedia.FindAllStringSubmatch(fragment, -1) {
+			if isTumblrMedia(match[1]) {
+				urls = append(urls, match[1])
+			}
+		}
+	}
+	seen := map[string]bool{}
+	unique := urls[:0]
+	for _, u := range urls {
+		if u != "" && !seen[u] {
+			seen[u] = true
+			unique = append(unique, u)
+		}
+	}
+	return unique
+}
+
+// isTumblrMedia reports whether a URL is the URL of a media file hosted by Tumblr.
+func isTumblrMedia(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return host == "media.tumblr.com" || strings.HasSuffix(host, ".media.tumblr.com")
+}
+
+// mediaPath returns the path of the file a media URL is downloaded to, relative to the backup directory:
+// the name of the file in the URL, prefixed by a hash of the URL to tell apart files with the same name.
+func mediaPath(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return path.Join(MediaDir, hex.EncodeToString(sum[:6])+"-"+mediaName(rawURL))
+}
+
+// mediaName returns the name of the file in a media URL.
+func mediaName(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		if base := path.Base(u.Path); base != "/" && base != "." {
+			return base
+		}
+	}
+	return "media"
+}
+
+// download downloads a media file to dir, computing its checksum.
+func download(ctx context.Context, client *http.Client, rawURL, dir string) (MediaFile, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return MediaFile{}, err
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return MediaFile{}, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return MediaFile{}, fmt.Errorf("backup: downloading %s: %s", rawURL, response.Status)
+	}
+	media := MediaFile{Path: mediaPath(rawURL)}
+	target := filepath.Join(dir, filepath.FromSlash(media.Path))
+	file, err := ioutil.TempFile(filepath.Dir(target), ".tmp-")
+	if err != nil {
+		return MediaFile{}, err
+	}
+	hash := sha256.New()
+	media.Size, err = io.Copy(io.MultiWriter(file, hash), response.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), target)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return MediaFile{}, fmt.Errorf("backup: downloading %s: %v", rawURL, err)
+	}
+	media.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return media, nil
+}
+
+// checkMedia reports whether a downloaded media file is still present with its size,
+// and if verify is set, with its checksum.
+func checkMedia(dir string, media MediaFile, verify bool) bool {
+	target := filepath.Join(dir, filepath.FromSlash(media.Path))
+	info, err := os.Stat(target)
+	if err != nil || info.Size() != media.Size {
+		return false
+	}
+	if !verify {
+		return true
+	}
+	file, err := os.Open(target)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return false
+	}
+	return hex.EncodeToString(hash.Sum(nil)) == media.SHA256
+}
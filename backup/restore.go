@@ -0,0 +1,338 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spbr/gotumblr"
+)
+
+// errUnsupported is returned by createOptions for the posts that cannot be created, like
+// photosets whose photos are not all in the backup.
+var errUnsupported = errors.New("backup: the post cannot be created")
+
+// Restore recreates the posts of a backup on a blog. The fields must be set before Run is called.
+//
+// The posts are created oldest first, with their tags, date, state, format and slug.
+// The photos, videos and audio files downloaded by the backup are uploaded again;
+// the media embedded in the text of the posts keep their original URLs.
+// Answers cannot be created as such: they are created as text posts in the Neue Post Format,
+// the question and its asker followed by the answer. The photosets whose photos are not
+// all in the backup are skipped, since only one photo can be created from its URL.
+// Queued posts are queued again, to be published at their scheduled time if it is still
+// in the future, or at the next free slot of the queue.
+//
+// Each post created is recorded in a journal in the backup directory (see JournalFile),
+// so that a restore interrupted by an error, e.g. a rate limit, can be run again
+// without creating the same posts twice.
+type Restore struct {
+	Dir  string
+	Blog gotumblr.BlogIdentifier
+	// Kinds are the kinds of posts restored: Posts, Drafts, Queue and Submissions,
+	// Posts, Drafts and Queue if empty. Submissions are restored as drafts.
+	Kinds []string
+	// Media uploads the media of the backup (set by NewRestore). Otherwise, or if a file
+	// is missing from the backup, photos, videos and audio files are created from their URLs
+	// (but photosets are skipped).
+	Media bool
+	// Logf, if set, is called to report the progress of the restore.
+	Logf func(format string, args ...interface{})
+
+	client gotumblr.TumblrWriter
+}
+
+// RestoreSummary tells what a run of a Restore did.
+type RestoreSummary struct {
+	Created     int               // number of posts created
+	Skipped     int               // number of posts created by previous runs
+	Unsupported []gotumblr.PostID // ids of the posts that could not be created
+}
+
+// NewRestore creates a Restore of the backup in dir to blog, uploading the media.
+func NewRestore(client gotumblr.TumblrWriter, dir string, blog gotumblr.BlogIdentifier) *Restore {
+	return &Restore{Dir: dir, Blog: blog, Media: true, client: client}
+}
+
+// Run restores the posts. It stops at the first post that cannot be created;
+// the posts created before are in the journal and are skipped by the next run.
+func (r *Restore) Run(ctx context.Context) (*RestoreSummary, error) {
+	kinds := r.Kinds
+	if len(kinds) == 0 {
+		kinds = []string{Posts, Drafts, Queue}
+	}
+	for _, kind := range kinds {
+		if kind != Posts && kind != Drafts && kind != Queue && kind != Submissions {
+			return nil, fmt.Errorf("backup: cannot restore the %s of a backup", kind)
+		}
+	}
+	manifest, err := LoadManifest(r.Dir)
+	if err != nil {
+		return nil, err
+	}
+	journalPath := filepath.Join(r.Dir, JournalFile(r.Blog))
+	restored, err := ReadJournal(journalPath)
+	if err != nil {
+		return nil, err
+	}
+	journal, err := openJournal(journalPath)
+	if err != nil {
+		return nil, err
+	}
+	defer journal.Close()
+
+	summary := new(RestoreSummary)
+	for _, kind := range kinds {
+		posts, err := ReadPosts(r.Dir, kind)
+		if err != nil {
+			return summary, err
+		}
+		for i, post := range posts {
+			if err := ctx.Err(); err != nil {
+				return summary, err
+			}
+			id := post.Base().ID
+			if _, ok := restored[id]; ok {
+				summary.Skipped++
+				continue
+			}
+			newID, err := r.create(kind, post, manifest)
+			if err == errUnsupported {
+				r.logf("%s: skipping %s post %s", kind, post.Base().PostType, id)
+				summary.Unsupported = append(summary.Unsupported, id)
+				continue
+			}
+			if err != nil {
+				return summary, fmt.Errorf("backup: restoring post %s: %w", id, err)
+			}
+			if err := journal.record(JournalEntry{Kind: kind, OldID: id, NewID: newID}); err != nil {
+				return summary, err
+			}
+			restored[id] = newID
+			summary.Created++
+			r.logf("%s: %d/%d posts", kind, i+1, len(posts))
+		}
+	}
+	return summary, nil
+}
+
+// create creates a post of the backup, uploading its media.
+func (r *Restore) create(kind string, post gotumblr.Post, manifest *Manifest) (gotumblr.PostID, error) {
+	var files []*os.File
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+	// open returns an upload of the downloaded file of a media URL, if any
+	open := func(param, rawURL string) (gotumblr.Upload, bool) {
+		media, ok := manifest.Media[rawURL]
+		if !r.Media || !ok || !checkMedia(r.Dir, media, false) {
+			return gotumblr.Upload{}, false
+		}
+		file, err := os.Open(filepath.Join(r.Dir, filepath.FromSlash(media.Path)))
+		if err != nil {
+			return gotumblr.Upload{}, false
+		}
+		files = append(files, file)
+		return gotumblr.Upload{Param: param, Filename: mediaName(rawURL), Content: file}, true
+	}
+	if answer, ok := post.(*gotumblr.AnswerPost); ok {
+		return r.client.CreateNPFPost(r.Blog, answerParams(kind, answer, time.Now()))
+	}
+	options, uploads, err := createOptions(kind, post, open, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return r.client.CreatePost(r.Blog, options, uploads...)
+}
+
+// answerParams returns the parameters creating an NPF text post like a backed up answer:
+// the asker and the question, indented, followed by the paragraphs of the answer.
+func answerParams(kind string, post *gotumblr.AnswerPost, now time.Time) gotumblr.NPFPostParams {
+	asker := post.AskingName
+	if asker == "" {
+		asker = "Anonymous"
+	}
+	content := []gotumblr.TextBlock{gotumblr.NewTextBlock("", asker+" asked:")}
+	for _, block := range textBlocks(post.Question) {
+		content = append(content, gotumblr.NewTextBlock("indented", block.Text))
+	}
+	content = append(content, textBlocks(post.Answer)...)
+	base := post.Base()
+	state := createState(kind, base)
+	times := createTimes(state, base, now)
+	return gotumblr.NPFPostParams{
+		Content:   content,
+		State:     state,
+		Tags:      base.Tags,
+		Date:      times.Date,
+		PublishOn: times.PublishOn,
+		Slug:      base.Slug,
+	}
+}
+
+var (
+	blockEnd = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|h[1-6]|li|blockquote)>`)
+	htmlTag  = regexp.MustCompile(`<[^>]*>`)
+)
+
+// textBlocks converts HTML to plain text blocks, one per paragraph, heading, list item or line.
+func textBlocks(source string) []gotumblr.TextBlock {
+	var blocks []gotumblr.TextBlock
+	for _, part := range blockEnd.Split(source, -1) {
+		text := strings.TrimSpace(html.UnescapeString(htmlTag.ReplaceAllString(part, "")))
+		if text != "" {
+			blocks = append(blocks, gotumblr.NewTextBlock("", text))
+		}
+	}
+	return blocks
+}
+
+// createState returns the state a backed up post is created in: submissions become drafts.
+func createState(kind string, base *gotumblr.BasePost) string {
+	switch {
+	case kind == Submissions || base.State == "submission":
+		return "draft"
+	case base.State == "queued":
+		return "queue"
+	}
+	return base.State
+}
+
+// createTimes returns the date of a backed up post, or for a queued post the time it is
+// scheduled to be published, if it is still in the future; the API publishes a queued post
+// given a past time at once.
+func createTimes(state string, base *gotumblr.BasePost, now time.Time) gotumblr.PostTimes {
+	if state != "queue" {
+		if base.Timestamp == 0 {
+			return gotumblr.PostTimes{}
+		}
+		return gotumblr.PostTimes{Date: base.Time()}
+	}
+	if scheduled := time.Unix(base.ScheduledPublishTime, 0); base.ScheduledPublishTime != 0 && scheduled.After(now) {
+		return gotumblr.PostTimes{PublishOn: scheduled}
+	}
+	return gotumblr.PostTimes{}
+}
+
+// createOptions returns the options and the uploads creating a post like a backed up post.
+// open returns the upload of a media URL, if the backup downloaded it. Answers are created by answerParams.
+func createOptions(kind string, post gotumblr.Post, open func(param, rawURL string) (gotumblr.Upload, bool), now time.Time) (map[string]string, []gotumblr.Upload, error) {
+	base := post.Base()
+	options := map[string]string{"type": base.PostType}
+	var uploads []gotumblr.Upload
+	switch post := post.(type) {
+	case *gotumblr.TextPost:
+		options["title"], options["body"] = post.Title, post.Body
+	case *gotumblr.PhotoPost:
+		options["caption"], options["link"] = post.Caption, post.LinkURL
+		for i := range post.Photos {
+			if upload, ok := open("data["+strconv.Itoa(i)+"]", post.Photos[i].Original().URL); ok {
+				uploads = append(uploads, upload)
+			}
+		}
+		// photos cannot be both uploaded and given by URL, and only one photo can be given by URL
+		switch {
+		case len(uploads) == len(post.Photos):
+		case len(uploads) == 0 && len(post.Photos) == 1:
+			options["source"] = post.Photos[0].Original().URL
+		default:
+			return nil, nil, errUnsupported
+		}
+	case *gotumblr.QuotePost:
+		options["quote"], options["source"] = post.Text, post.Source
+	case *gotumblr.LinkPost:
+		options["title"], options["url"], options["description"] = post.Title, post.URL, post.Description
+	case *gotumblr.ChatPost:
+		options["title"], options["conversation"] = post.Title, post.Body
+		if len(post.Dialogue) != 0 {
+			options["conversation"] = gotumblr.FormatConversation(post.Dialogue)
+		}
+	case *gotumblr.AudioPost:
+		options["caption"] = post.Caption
+		if upload, ok := open("data", post.AudioURL); ok {
+			uploads = append(uploads, upload)
+		} else if post.AudioSourceURL != "" {
+			options["external_url"] = post.AudioSourceURL
+		} else {
+			options["external_url"] = post.AudioURL
+		}
+	case *gotumblr.VideoPost:
+		options["caption"] = post.Caption
+		if upload, ok := open("data", post.VideoURL); ok {
+			uploads = append(uploads, upload)
+		} else if len(post.Player) != 0 {
+			options["embed"] = post.Player[len(post.Player)-1].EmbedCode
+		} else {
+			options["embed"] = post.PermalinkURL
+		}
+	default:
+		return nil, nil, errUnsupported
+	}
+	for key, value := range options {
+		if value == "" {
+			delete(options, key)
+		}
+	}
+
+	state := createState(kind, base)
+	if state != "" {
+		options["state"] = state
+	}
+	if len(base.Tags) != 0 {
+		options["tags"] = strings.Join(base.Tags, ",")
+	}
+	createTimes(state, base, now).Apply(options)
+	if base.Format != "" {
+		options["format"] = base.Format
+	}
+	if base.Slug != "" {
+		options["slug"] = base.Slug
+	}
+	return options, uploads, nil
+}
+
+// ReadPosts reads the posts of a kind from a backup directory, oldest first.
+func ReadPosts(dir, kind string) ([]gotumblr.Post, error) {
+	entries, err := ioutil.ReadDir(filepath.Join(dir, kind))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var posts []gotumblr.Post
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, kind, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		post, err := gotumblr.DecodePost(json.RawMessage(data))
+		if err != nil {
+			return nil, fmt.Errorf("backup: reading %s: %v", entry.Name(), err)
+		}
+		posts = append(posts, post)
+	}
+	sort.Slice(posts, func(i, j int) bool { return posts[i].Base().ID < posts[j].Base().ID })
+	return posts, nil
+}
+
+func (r *Restore) logf(format string, args ...interface{}) {
+	if r.Logf != nil {
+		r.Logf(format, args...)
+	}
+}
@@ -0,0 +1,117 @@
+package backup
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spbr/gotumblr"
+)
+
+func photoset(urls ...string) *gotumblr.PhotoPost {
+	post := &gotumblr.PhotoPost{BasePost: gotumblr.BasePost{PostType: "photo", State: "published", Timestamp: 1708444800}}
+	for _, u := range urls {
+		post.Photos = append(post.Photos, gotumblr.PhotoObject{OriginalSize: gotumblr.AltSize{URL: u}})
+	}
+	return post
+}
+
+func TestCreateOptionsPhotos(t *testing.T) {
+	tests := []struct {
+		name       string
+		post       *gotumblr.PhotoPost
+		downloaded []string
+		source     string
+		uploads    []string // params of the uploads
+		err        error
+	}{
+		{"all uploaded", photoset("a", "b"), []string{"a", "b"}, "", []string{"data[0]", "data[1]"}, nil},
+		{"single photo by URL", photoset("a"), nil, "a", nil, nil},
+		{"photoset not downloaded", photoset("a", "b"), nil, "", nil, errUnsupported},
+		{"first photo missing", photoset("a", "b"), []string{"b"}, "", nil, errUnsupported},
+		{"last photo missing", photoset("a", "b"), []string{"a"}, "", nil, errUnsupported},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			open := func(param, rawURL string) (gotumblr.Upload, bool) {
+				for _, u := range test.downloaded {
+					if u == rawURL {
+						return gotumblr.Upload{Param: param, Filename: rawURL, Content: strings.NewReader(rawURL)}, true
+					}
+				}
+				return gotumblr.Upload{}, false
+			}
+			options, uploads, err := createOptions(Posts, test.post, open, time.Now())
+			if err != test.err {
+				t.Fatalf("err = %v, want %v", err, test.err)
+			}
+			if err != nil {
+				return
+			}
+			if options["source"] != test.source {
+				t.Errorf("source = %q, want %q", options["source"], test.source)
+			}
+			if len(uploads) != len(test.uploads) {
+				t.Fatalf("%d uploads, want %d", len(uploads), len(test.uploads))
+			}
+			for i, upload := range uploads {
+				if upload.Param != test.uploads[i] {
+					t.Errorf("upload %d has the param %q, want %q", i, upload.Param, test.uploads[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCreateOptionsDate(t *testing.T) {
+	now := time.Unix(1708444800, 0)
+	post := &gotumblr.TextPost{BasePost: gotumblr.BasePost{PostType: "text", Timestamp: 1708444800}}
+	for _, test := range []struct {
+		kind, state string
+		scheduled   int64
+		key, value  string
+	}{
+		{Posts, "published", 0, "date", "2024-02-20 16:00:00 GMT"},
+		{Drafts, "draft", 0, "date", "2024-02-20 16:00:00 GMT"},
+		{Queue, "queued", 1708448400, "publish_on", "2024-02-20 17:00:00 GMT"},
+		// a past publish_on would publish the post at once
+		{Queue, "queued", 1708441200, "", ""},
+		{Queue, "queued", 0, "", ""},
+	} {
+		post.State, post.ScheduledPublishTime = test.state, test.scheduled
+		options, _, err := createOptions(test.kind, post, nil, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range []string{"date", "publish_on"} {
+			want := ""
+			if key == test.key {
+				want = test.value
+			}
+			if options[key] != want {
+				t.Errorf("%s post scheduled at %d: %s = %q, want %q", test.state, test.scheduled, key, options[key], want)
+			}
+		}
+	}
+}
+
+func TestAnswerParams(t *testing.T) {
+	post := &gotumblr.AnswerPost{
+		BasePost:   gotumblr.BasePost{PostType: "answer", State: "queued", Tags: []string{"ask"}, ScheduledPublishTime: 1708448400},
+		AskingName: "",
+		Question:   "Fish &amp; chips?",
+		Answer:     "<p>Yes.</p><p>With <b>vinegar</b>,<br>always.</p>",
+	}
+	params := answerParams(Posts, post, time.Unix(1708444800, 0))
+	var texts []string
+	for _, block := range params.Content {
+		texts = append(texts, block.Subtype+":"+block.Text)
+	}
+	want := []string{":Anonymous asked:", "indented:Fish & chips?", ":Yes.", ":With vinegar,", ":always."}
+	if strings.Join(texts, "|") != strings.Join(want, "|") {
+		t.Errorf("content %q, want %q", texts, want)
+	}
+	if params.State != "queue" || !params.PublishOn.Equal(time.Unix(1708448400, 0)) || !params.Date.IsZero() || params.Tags[0] != "ask" {
+		t.Errorf("params %+v, want the ask tag, queued to its scheduled time", params)
+	}
+}
@@ -1,21 +1,116 @@
 package gotumblr
 
+import "time"
+
 //BasePost is the basic information common to all Tumblr posts
 type BasePost struct {
-	BlogName    string
-	ID          int64
-	PostURL     string
-	PostType    string `json:"type"`
-	Timestamp   int64
-	Date        string
-	Format      string
-	ReblogKey   string
-	Tags        []string
-	Bookmarklet bool
-	Mobile      bool
-	SourceURL   string
-	SourceTitle string
-	Liked       bool
-	State       string
-	TotalPosts  int64
+	BlogName                string `json:"blog_name"`
+	Blog                    PostBlog
+	ID                      PostID
+	IDString                string `json:"id_string"`
+	PostURL                 string `json:"post_url"`
+	ShortURL                string `json:"short_url"`
+	Slug                    string
+	PostType                string `json:"type"`
+	IsBlocksPostFormat      bool   `json:"is_blocks_post_format"` // whether the post was created in the Neue Post Format
+	ShouldOpenInLegacy      bool   `json:"should_open_in_legacy"`
+	Timestamp               int64
+	FeaturedTimestamp       int64 `json:"featured_timestamp,optional"` // set in tagged results for featured posts
+	Date                    string
+	ScheduledPublishTime    int64 `json:"scheduled_publish_time,optional"` // set on queued posts
+	Format                  string
+	ReblogKey               string `json:"reblog_key"`
+	Tags                    []string
+	Bookmarklet             bool   `json:",optional"`
+	Mobile                  bool   `json:",optional"`
+	SourceURL               string `json:"source_url,optional"`
+	SourceTitle             string `json:"source_title,optional"`
+	RecommendedSource       string `json:"recommended_source,optional"`
+	RecommendedColor        string `json:"recommended_color,optional"`
+	PostAuthor              string `json:"post_author,optional"` // the blog of the member who wrote a post of a group blog
+	Liked                   bool   `json:",optional"`
+	Followed                bool   `json:",optional"`
+	State                   string
+	Summary                 string
+	NoteCount               int64 `json:"note_count"`
+	Reblog                  ReblogInfo
+	Trail                   []TrailItem // the posts of the reblog trail, oldest first
+	ParentPostURL           string      `json:"parent_post_url,optional"` // set on reblogs
+	RebloggedFromID         PostID      `json:"reblogged_from_id,optional"`
+	RebloggedFromURL        string      `json:"reblogged_from_url,optional"`
+	RebloggedFromName       string      `json:"reblogged_from_name,optional"`
+	RebloggedFromTitle      string      `json:"reblogged_from_title,optional"`
+	RebloggedFromUUID       string      `json:"reblogged_from_uuid,optional"`
+	RebloggedFromCanMessage bool        `json:"reblogged_from_can_message,optional"`
+	RebloggedFromFollowing  bool        `json:"reblogged_from_following,optional"`
+	RebloggedRootID         PostID      `json:"reblogged_root_id,optional"`
+	RebloggedRootURL        string      `json:"reblogged_root_url,optional"`
+	RebloggedRootName       string      `json:"reblogged_root_name,optional"`
+	RebloggedRootTitle      string      `json:"reblogged_root_title,optional"`
+	RebloggedRootUUID       string      `json:"reblogged_root_uuid,optional"`
+	RebloggedRootCanMessage bool        `json:"reblogged_root_can_message,optional"`
+	RebloggedRootFollowing  bool        `json:"reblogged_root_following,optional"`
+	CanLike                 bool        `json:"can_like"`
+	CanReblog               bool        `json:"can_reblog"`
+	CanReply                bool        `json:"can_reply"`
+	CanSendInMessage        bool        `json:"can_send_in_message"`
+	CanBlaze                bool        `json:"can_blaze,optional"`
+	CanIgnite               bool        `json:"can_ignite,optional"`
+	IsBlazed                bool        `json:"is_blazed,optional"`
+	IsBlazePending          bool        `json:"is_blaze_pending,optional"`
+	InteractabilityReblog   string      `json:"interactability_reblog"` // who can reblog the post: everyone or noone
+	InteractabilityBlaze    string      `json:"interactability_blaze,optional"`
+	DisplayAvatar           bool        `json:"display_avatar"`
+	TotalPosts              int64       `json:",optional"`
+}
+
+// PostBlog is the information about the blog of a post given with the post.
+type PostBlog struct {
+	Name        string
+	Title       string
+	Description string
+	URL         string
+	UUID        string
+	Updated     int64
+}
+
+// ReblogInfo holds the comment added by a reblog and the HTML of the reblogged posts.
+type ReblogInfo struct {
+	Comment  string
+	TreeHTML string `json:"tree_html"`
+}
+
+// TrailItem is a post of the reblog trail of a post.
+type TrailItem struct {
+	Blog          TrailBlog
+	Post          TrailPost
+	ContentRaw    string `json:"content_raw"`
+	Content       string
+	IsRootItem    bool `json:"is_root_item,optional"`
+	IsCurrentItem bool `json:"is_current_item,optional"`
+}
+
+// TrailBlog is the blog of a post of a reblog trail.
+type TrailBlog struct {
+	Name           string
+	Active         bool
+	Theme          map[string]interface{} // e.g. avatar_shape, background_color, header_image
+	ShareLikes     bool                   `json:"share_likes"`
+	ShareFollowing bool                   `json:"share_following"`
+	CanBeFollowed  bool                   `json:"can_be_followed"`
+}
+
+// TrailPost identifies a post of a reblog trail.
+type TrailPost struct {
+	ID PostID
+}
+
+// Time returns the publishing time of the post in UTC.
+func (p *BasePost) Time() time.Time {
+	return unixTime(p.Timestamp)
+}
+
+// ParsedDate returns the Date of the post parsed in UTC.
+func (p *BasePost) ParsedDate() (time.Time, error) {
+	return ParseDate(p.Date)
 }
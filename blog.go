@@ -1,14 +1,22 @@
 package gotumblr
 
+import "time"
+
 // BlogInfo holds information about a Tumblr blog
 type BlogInfo struct {
 	Title       string
 	Posts       int64
 	Name        string
+	UUID        string
 	URL         string
 	Updated     int64
 	Description string
 	Ask         bool
-	AskAnon     bool
-	Likes       int64
+	AskAnon     bool  `json:"ask_anon,optional"`
+	Likes       int64 `json:",optional"`
+}
+
+// UpdatedTime returns the time of the blog's most recent post in UTC.
+func (b *BlogInfo) UpdatedTime() time.Time {
+	return unixTime(b.Updated)
 }
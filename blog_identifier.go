@@ -0,0 +1,207 @@
+package gotumblr
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// BlogIdentifier identifies a Tumblr blog independently of the form it was given in.
+// The zero value identifies no blog; use ParseBlogIdentifier to create one.
+type BlogIdentifier struct {
+	name string // bare blog name for blogs hosted on tumblr.com
+	host string // custom domain
+	uuid string // blog UUID without the "t:" prefix
+}
+
+// ParseBlogIdentifier parses a blog given as a bare name (mgterzieva),
+// a hostname (mgterzieva.tumblr.com), a custom domain (blog.example.com),
+// a full URL (http://mgterzieva.tumblr.com/, https://www.tumblr.com/mgterzieva, https://www.tumblr.com/blog/view/mgterzieva)
+// or a blog UUID (t:KwxGbqn0_Ti2cs4ZDxIERA).
+func ParseBlogIdentifier(s string) (BlogIdentifier, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return BlogIdentifier{}, fmt.Errorf("gotumblr: empty blog identifier")
+	}
+	if strings.HasPrefix(s, "t:") {
+		uuid := s[2:]
+		if uuid == "" || strings.IndexFunc(uuid, func(r rune) bool { return !isNameRune(r) && r != '_' }) >= 0 {
+			return BlogIdentifier{}, fmt.Errorf("gotumblr: invalid blog UUID %q", s)
+		}
+		return BlogIdentifier{uuid: uuid}, nil
+	}
+	raw := s
+	if !strings.Contains(s, "://") {
+		s = "//" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return BlogIdentifier{}, fmt.Errorf("gotumblr: invalid blog identifier %q: %v", raw, err)
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "tumblr.com" || host == "www.tumblr.com" {
+		blog, _, err := blogFromDashboardPath(raw, u.Path)
+		return blog, err
+	}
+	return blogFromHost(raw, host)
+}
+
+// MustParseBlogIdentifier is like ParseBlogIdentifier but panics if s cannot be parsed.
+// It simplifies the use of constant blog names.
+func MustParseBlogIdentifier(s string) BlogIdentifier {
+	blog, err := ParseBlogIdentifier(s)
+	if err != nil {
+		panic(err)
+	}
+	return blog
+}
+
+// blogFromHost builds a BlogIdentifier from the host part of a blog URL.
+func blogFromHost(raw, host string) (BlogIdentifier, error) {
+	if host == "" {
+		return BlogIdentifier{}, fmt.Errorf("gotumblr: invalid blog identifier %q", raw)
+	}
+	if name := strings.TrimSuffix(host, ".tumblr.com"); name != host || !strings.Contains(host, ".") {
+		if !isBlogName(name) {
+			return BlogIdentifier{}, fmt.Errorf("gotumblr: invalid blog name in %q", raw)
+		}
+		return BlogIdentifier{name: name}, nil
+	}
+	for _, label := range strings.Split(host, ".") {
+		if !isBlogName(label) {
+			return BlogIdentifier{}, fmt.Errorf("gotumblr: invalid blog domain in %q", raw)
+		}
+	}
+	return BlogIdentifier{host: host}, nil
+}
+
+// blogFromDashboardPath extracts the blog name from a www.tumblr.com URL path,
+// either /name[/...], /blog/name[/...] or /blog/view/name[/...], and returns the segments after it.
+func blogFromDashboardPath(raw, path string) (BlogIdentifier, []string, error) {
+	segments := pathSegments(path)
+	if len(segments) > 0 && segments[0] == "blog" {
+		segments = segments[1:]
+		if len(segments) > 0 && segments[0] == "view" {
+			segments = segments[1:]
+		}
+	}
+	if len(segments) == 0 || !isBlogName(segments[0]) {
+		return BlogIdentifier{}, nil, fmt.Errorf("gotumblr: no blog name in %q", raw)
+	}
+	return BlogIdentifier{name: strings.ToLower(segments[0])}, segments[1:], nil
+}
+
+// ParsePostURL extracts the blog and the post id from the URL of a post.
+// Both the blog forms (http://mgterzieva.tumblr.com/post/72078164824/slug,
+// http://blog.example.com/post/72078164824) and the dashboard forms
+// (https://www.tumblr.com/mgterzieva/72078164824,
+// https://www.tumblr.com/blog/view/mgterzieva/72078164824) are recognized.
+func ParsePostURL(rawURL string) (BlogIdentifier, PostID, error) {
+	s := strings.TrimSpace(rawURL)
+	if !strings.Contains(s, "://") {
+		s = "//" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return BlogIdentifier{}, 0, fmt.Errorf("gotumblr: invalid post URL %q: %v", rawURL, err)
+	}
+	host := strings.ToLower(u.Hostname())
+	segments := pathSegments(u.Path)
+	var blog BlogIdentifier
+	if host == "tumblr.com" || host == "www.tumblr.com" {
+		if blog, segments, err = blogFromDashboardPath(rawURL, u.Path); err != nil {
+			return BlogIdentifier{}, 0, err
+		}
+	} else {
+		if blog, err = blogFromHost(rawURL, host); err != nil {
+			return BlogIdentifier{}, 0, err
+		}
+		if len(segments) == 0 || segments[0] != "post" {
+			return BlogIdentifier{}, 0, fmt.Errorf("gotumblr: not a post URL %q", rawURL)
+		}
+		segments = segments[1:]
+	}
+	if len(segments) == 0 {
+		return BlogIdentifier{}, 0, fmt.Errorf("gotumblr: no post id in %q", rawURL)
+	}
+	id, err := ParsePostID(segments[0])
+	if err != nil {
+		return BlogIdentifier{}, 0, err
+	}
+	return blog, id, nil
+}
+
+// IsZero reports whether b identifies no blog.
+func (b BlogIdentifier) IsZero() bool {
+	return b.name == "" && b.host == "" && b.uuid == ""
+}
+
+// Name returns the bare blog name (e.g. mgterzieva), or "" if the blog was
+// identified by a custom domain or a UUID.
+func (b BlogIdentifier) Name() string {
+	return b.name
+}
+
+// UUID returns the blog UUID including its "t:" prefix, or "" if the blog
+// was not identified by its UUID.
+func (b BlogIdentifier) UUID() string {
+	if b.uuid == "" {
+		return ""
+	}
+	return "t:" + b.uuid
+}
+
+// Hostname returns the identifier expected by the /v2/blog/{blog-identifier} endpoints:
+// the standard hostname (mgterzieva.tumblr.com), the custom domain or the UUID.
+func (b BlogIdentifier) Hostname() string {
+	switch {
+	case b.name != "":
+		return b.name + ".tumblr.com"
+	case b.host != "":
+		return b.host
+	case b.uuid != "":
+		return "t:" + b.uuid
+	}
+	return ""
+}
+
+// URL returns the URL of the blog as expected by the follow and unfollow endpoints,
+// or "" if the blog is identified only by its UUID.
+func (b BlogIdentifier) URL() string {
+	if b.name == "" && b.host == "" {
+		return ""
+	}
+	return "https://" + b.Hostname()
+}
+
+// String returns the same value as Hostname.
+func (b BlogIdentifier) String() string {
+	return b.Hostname()
+}
+
+// apiPath returns the path of a blog-scoped API endpoint,
+// e.g. /v2/blog/mgterzieva.tumblr.com/posts/queue for "posts/queue".
+func (b BlogIdentifier) apiPath(endpoint string) (string, error) {
+	if b.IsZero() {
+		return "", fmt.Errorf("gotumblr: missing blog identifier")
+	}
+	return "/v2/blog/" + b.Hostname() + "/" + endpoint, nil
+}
+
+func pathSegments(path string) []string {
+	var segments []string
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+func isBlogName(s string) bool {
+	return s != "" && strings.IndexFunc(s, func(r rune) bool { return !isNameRune(r) }) < 0
+}
+
+func isNameRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-'
+}
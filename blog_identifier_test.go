@@ -0,0 +1,113 @@
+package gotumblr_test
+
+import (
+	"testing"
+
+	"github.com/spbr/gotumblr"
+)
+
+func TestParseBlogIdentifier(t *testing.T) {
+	tests := []struct {
+		input          string
+		hostname, name string
+		uuid, url      string
+	}{
+		{"mgterzieva", "mgterzieva.tumblr.com", "mgterzieva", "", "https://mgterzieva.tumblr.com"},
+		{"  MgTerzieva ", "mgterzieva.tumblr.com", "mgterzieva", "", "https://mgterzieva.tumblr.com"},
+		{"my-blog-2", "my-blog-2.tumblr.com", "my-blog-2", "", "https://my-blog-2.tumblr.com"},
+		{"mgterzieva.tumblr.com", "mgterzieva.tumblr.com", "mgterzieva", "", "https://mgterzieva.tumblr.com"},
+		{"http://mgterzieva.tumblr.com/", "mgterzieva.tumblr.com", "mgterzieva", "", "https://mgterzieva.tumblr.com"},
+		{"https://mgterzieva.tumblr.com/post/72078164824/slug", "mgterzieva.tumblr.com", "mgterzieva", "", "https://mgterzieva.tumblr.com"},
+		{"https://www.tumblr.com/mgterzieva", "mgterzieva.tumblr.com", "mgterzieva", "", "https://mgterzieva.tumblr.com"},
+		{"https://tumblr.com/MgTerzieva/72078164824", "mgterzieva.tumblr.com", "mgterzieva", "", "https://mgterzieva.tumblr.com"},
+		{"https://www.tumblr.com/blog/view/mgterzieva", "mgterzieva.tumblr.com", "mgterzieva", "", "https://mgterzieva.tumblr.com"},
+		{"https://www.tumblr.com/blog/mgterzieva/drafts", "mgterzieva.tumblr.com", "mgterzieva", "", "https://mgterzieva.tumblr.com"},
+		{"blog.example.com", "blog.example.com", "", "", "https://blog.example.com"},
+		{"https://Blog.Example.com:8080/about", "blog.example.com", "", "", "https://blog.example.com"},
+		{"t:KwxGbqn0_Ti2cs4ZDxIERA", "t:KwxGbqn0_Ti2cs4ZDxIERA", "", "t:KwxGbqn0_Ti2cs4ZDxIERA", ""},
+	}
+	for _, test := range tests {
+		blog, err := gotumblr.ParseBlogIdentifier(test.input)
+		if err != nil {
+			t.Errorf("ParseBlogIdentifier(%q): %v", test.input, err)
+			continue
+		}
+		if blog.Hostname() != test.hostname || blog.String() != test.hostname || blog.Name() != test.name ||
+			blog.UUID() != test.uuid || blog.URL() != test.url || blog.IsZero() {
+			t.Errorf("ParseBlogIdentifier(%q) = hostname %q, name %q, UUID %q, URL %q, want %q, %q, %q, %q",
+				test.input, blog.Hostname(), blog.Name(), blog.UUID(), blog.URL(), test.hostname, test.name, test.uuid, test.url)
+		}
+	}
+}
+
+func TestParseBlogIdentifierInvalid(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"   ",
+		"t:",
+		"t:abc/def",
+		"my_blog",
+		"my blog",
+		"bad_label.example.com",
+		"https://www.tumblr.com/",
+		"https://www.tumblr.com/blog/view/",
+		"https://www.tumblr.com/blog",
+		"https://www.tumblr.com/bad_name",
+		"http://%zz",
+	} {
+		if blog, err := gotumblr.ParseBlogIdentifier(input); err == nil {
+			t.Errorf("ParseBlogIdentifier(%q) = %q, want an error", input, blog)
+		}
+	}
+	var zero gotumblr.BlogIdentifier
+	if !zero.IsZero() || zero.Hostname() != "" || zero.URL() != "" {
+		t.Errorf("the zero BlogIdentifier = %q, want no blog", zero)
+	}
+}
+
+func TestParsePostURL(t *testing.T) {
+	tests := []struct {
+		input    string
+		hostname string
+		id       gotumblr.PostID
+	}{
+		{"http://mgterzieva.tumblr.com/post/72078164824/slug", "mgterzieva.tumblr.com", 72078164824},
+		{"https://mgterzieva.tumblr.com/post/72078164824", "mgterzieva.tumblr.com", 72078164824},
+		{"mgterzieva.tumblr.com/post/72078164824/", "mgterzieva.tumblr.com", 72078164824},
+		{"http://blog.example.com/post/72078164824/a-slug?source=share", "blog.example.com", 72078164824},
+		{"https://www.tumblr.com/mgterzieva/72078164824", "mgterzieva.tumblr.com", 72078164824},
+		{"https://www.tumblr.com/mgterzieva/72078164824/slug", "mgterzieva.tumblr.com", 72078164824},
+		{"https://tumblr.com/mgterzieva/721385129357164544", "mgterzieva.tumblr.com", 721385129357164544},
+		{"https://www.tumblr.com/blog/view/mgterzieva/72078164824", "mgterzieva.tumblr.com", 72078164824},
+	}
+	for _, test := range tests {
+		blog, id, err := gotumblr.ParsePostURL(test.input)
+		if err != nil {
+			t.Errorf("ParsePostURL(%q): %v", test.input, err)
+			continue
+		}
+		if blog.Hostname() != test.hostname || id != test.id {
+			t.Errorf("ParsePostURL(%q) = %q, %s, want %q, %s", test.input, blog, id, test.hostname, test.id)
+		}
+	}
+}
+
+func TestParsePostURLInvalid(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"https://mgterzieva.tumblr.com/",
+		"https://mgterzieva.tumblr.com/about/72078164824",
+		"https://mgterzieva.tumblr.com/post/",
+		"https://mgterzieva.tumblr.com/post/slug",
+		"https://mgterzieva.tumblr.com/post/-12",
+		"https://www.tumblr.com/mgterzieva",
+		"https://www.tumblr.com/mgterzieva/not-an-id",
+		"https://www.tumblr.com/blog/view/mgterzieva",
+		"https://www.tumblr.com/",
+		"http://%zz/post/1",
+	} {
+		if blog, id, err := gotumblr.ParsePostURL(input); err == nil {
+			t.Errorf("ParsePostURL(%q) = %q, %s, want an error", input, blog, id)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package bulk
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spbr/gotumblr"
+)
+
+// Action changes a post. Actions are applied by several goroutines at once.
+type Action interface {
+	// Describe describes the change of a post, for dry runs and logs,
+	// or returns an empty string if the action leaves the post unchanged.
+	Describe(post gotumblr.Post) string
+	// Apply changes a post. It returns the options of EditPost undoing the change,
+	// or nil if the change cannot be undone.
+	Apply(client gotumblr.TumblrWriter, blog gotumblr.BlogIdentifier, post gotumblr.Post) (undo map[string]string, err error)
+}
+
+// Delete returns an Action deleting the posts. It cannot be undone.
+func Delete() Action {
+	return deleteAction{}
+}
+
+type deleteAction struct{}
+
+func (deleteAction) Describe(post gotumblr.Post) string {
+	return "delete"
+}
+
+func (deleteAction) Apply(client gotumblr.TumblrWriter, blog gotumblr.BlogIdentifier, post gotumblr.Post) (map[string]string, error) {
+	_, err := client.DeletePost(blog, post.Base().ID)
+	return nil, err
+}
+
+// Retag returns an Action replacing the tags of the posts by the tags returned by retag,
+// which must not modify its argument. The posts whose tags are unchanged are left alone.
+func Retag(retag func(tags []string) []string) Action {
+	return retagAction(retag)
+}
+
+// AddTags returns an Action adding tags to the posts that lack them.
+func AddTags(tags ...string) Action {
+	return Retag(func(current []string) []string {
+		updated := append([]string(nil), current...)
+		for _, tag := range tags {
+			if indexTag(updated, tag) < 0 {
+				updated = append(updated, tag)
+			}
+		}
+		return updated
+	})
+}
+
+// RemoveTags returns an Action removing tags from the posts, ignoring case.
+func RemoveTags(tags ...string) Action {
+	return Retag(func(current []string) []string {
+		var updated []string
+		for _, tag := range current {
+			if indexTag(tags, tag) < 0 {
+				updated = append(updated, tag)
+			}
+		}
+		return updated
+	})
+}
+
+// ReplaceTag returns an Action replacing a tag of the posts by another, ignoring case.
+func ReplaceTag(old, new string) Action {
+	return Retag(func(current []string) []string {
+		var updated []string
+		for _, tag := range current {
+			if strings.EqualFold(tag, old) {
+				tag = new
+			}
+			if indexTag(updated, tag) < 0 {
+				updated = append(updated, tag)
+			}
+		}
+		return updated
+	})
+}
+
+type retagAction func([]string) []string
+
+func (retag retagAction) Describe(post gotumblr.Post) string {
+	current := post.Base().Tags
+	updated := retag(current)
+	if equalTags(current, updated) {
+		return ""
+	}
+	return fmt.Sprintf("tags %q -> %q", current, updated)
+}
+
+func (retag retagAction) Apply(client gotumblr.TumblrWriter, blog gotumblr.BlogIdentifier, post gotumblr.Post) (map[string]string, error) {
+	base := post.Base()
+	options := map[string]string{"id": base.ID.String(), "tags": strings.Join(retag(base.Tags), ",")}
+	if _, err := client.EditPost(blog, options); err != nil {
+		return nil, err
+	}
+	return map[string]string{"id": base.ID.String(), "tags": strings.Join(base.Tags, ",")}, nil
+}
+
+// equalTags reports whether two lists hold the same tags in the same order.
+func equalTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// SetState returns an Action changing the state of the posts:
+// published, draft, queue or private, as the state option of EditPost.
+func SetState(state string) Action {
+	return stateAction(state)
+}
+
+type stateAction string
+
+// editState returns the state option of EditPost setting a post to its state.
+func editState(post gotumblr.Post) string {
+	if state := post.Base().State; state != "queued" {
+		return state
+	}
+	return "queue"
+}
+
+func (state stateAction) Describe(post gotumblr.Post) string {
+	current := editState(post)
+	if current == string(state) {
+		return ""
+	}
+	return "state " + current + " -> " + string(state)
+}
+
+func (state stateAction) Apply(client gotumblr.TumblrWriter, blog gotumblr.BlogIdentifier, post gotumblr.Post) (map[string]string, error) {
+	id := post.Base().ID.String()
+	if _, err := client.EditPost(blog, map[string]string{"id": id, "state": string(state)}); err != nil {
+		return nil, err
+	}
+	return map[string]string{"id": id, "state": editState(post)}, nil
+}
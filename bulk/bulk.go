@@ -0,0 +1,260 @@
+// Package bulk applies an action, like deleting, retagging or changing the state, to the posts
+// of a blog selected by a filter:
+//
+//	op := bulk.New(client, blog, bulk.HasTag("old"), bulk.ReplaceTag("old", "new"))
+//	op.Sources = []string{bulk.Posts, bulk.Drafts}
+//	op.Journal = "retag-undo.jsonl"
+//	summary, err := op.Run(ctx)
+//
+// The posts are changed by several goroutines at once; set a RateLimiter on the client
+// (see gotumblr.TumblrRestClient.SetRateLimiter) to keep within the rate limits of the API.
+package bulk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/spbr/gotumblr"
+)
+
+// Sources of the posts of an operation.
+const (
+	Posts  = "posts"  // the published posts
+	Drafts = "drafts" // the drafts
+	Queue  = "queue"  // the queued posts
+)
+
+// pageSize is the number of posts requested at once.
+const pageSize = 20
+
+// Operation applies an action to the posts of a blog selected by a filter.
+// The fields must be set before Run is called.
+type Operation struct {
+	Blog   gotumblr.BlogIdentifier
+	Filter Filter
+	Action Action
+	// Sources are the listings the posts are selected from, Posts if empty.
+	Sources []string
+	// Concurrency is the number of posts changed at once, 4 by default.
+	Concurrency int
+	// DryRun only reports the changes, through Logf, without applying them.
+	DryRun bool
+	// Journal is the path of the undo journal, see Undo. The entries of a run are appended.
+	// No journal is written if it is empty.
+	Journal string
+	// Logf, if set, is called to report every change.
+	Logf func(format string, args ...interface{})
+
+	client gotumblr.TumblrClient
+}
+
+// Summary tells what a run of an Operation did.
+type Summary struct {
+	Selected  int // number of posts selected by the filter
+	Unchanged int // number of selected posts the action left unchanged
+	Changed   int // number of posts changed, or that would be changed by a dry run
+	Failed    int // number of posts the action failed for
+	// Errors are the errors of the posts the action failed for.
+	Errors []error
+}
+
+// New creates an Operation applying action to the posts of blog selected by filter.
+func New(client gotumblr.TumblrClient, blog gotumblr.BlogIdentifier, filter Filter, action Action) *Operation {
+	return &Operation{Blog: blog, Filter: filter, Action: action, client: client}
+}
+
+// Run selects the posts, then applies the action to them. The posts are all selected
+// before any is changed, so that the changes do not shift the pages of the listings.
+// Failures do not stop the run, except when the rate limit of the API is exceeded:
+// the posts not yet changed are then left alone, and the error is returned.
+func (o *Operation) Run(ctx context.Context) (*Summary, error) {
+	posts, err := o.selectPosts(ctx, withContext(ctx, o.client))
+	if err != nil {
+		return new(Summary), err
+	}
+	return o.apply(ctx, posts)
+}
+
+// apply applies the action to the selected posts, see Run.
+func (o *Operation) apply(ctx context.Context, posts []gotumblr.Post) (*Summary, error) {
+	summary := &Summary{Selected: len(posts)}
+	client := withContext(ctx, o.client)
+
+	type change struct {
+		post        gotumblr.Post
+		description string
+	}
+	var changes []change
+	for _, post := range posts {
+		description := o.Action.Describe(post)
+		if description == "" {
+			summary.Unchanged++
+			continue
+		}
+		changes = append(changes, change{post, description})
+	}
+	if o.DryRun {
+		for _, c := range changes {
+			o.logf("%s: would %s", c.post.Base().ID, c.description)
+		}
+		summary.Changed = len(changes)
+		return summary, nil
+	}
+
+	var undoJournal *journal
+	if o.Journal != "" {
+		var err error
+		if undoJournal, err = openJournal(o.Journal); err != nil {
+			return summary, err
+		}
+		defer undoJournal.Close()
+	}
+	var mutex sync.Mutex
+	var stopErr error
+	stopped := make(chan struct{})
+	var wg sync.WaitGroup
+	jobs := make(chan change)
+	concurrency := o.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range jobs {
+				select {
+				case <-stopped:
+					continue // dispatched before the rate limit was exceeded, but not yet changed
+				default:
+				}
+				id := c.post.Base().ID
+				undo, err := o.Action.Apply(client, o.Blog, c.post)
+				if err == nil && undo != nil && undoJournal != nil {
+					err = undoJournal.record(UndoEntry{ID: id, Change: c.description, Undo: undo})
+				}
+				mutex.Lock()
+				if err != nil {
+					summary.Failed++
+					summary.Errors = append(summary.Errors, fmt.Errorf("bulk: post %s: %w", id, err))
+					if gotumblr.IsRateLimited(err) && stopErr == nil {
+						// the posts being changed are let through, so that their undo entries are recorded
+						stopErr = err
+						close(stopped)
+					}
+				} else {
+					summary.Changed++
+					o.logf("%s: %s (%d/%d)", id, c.description, summary.Changed+summary.Failed, len(changes))
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+dispatch:
+	for _, c := range changes {
+		select {
+		case jobs <- c:
+		case <-stopped:
+			break dispatch
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if stopErr != nil {
+		return summary, stopErr
+	}
+	return summary, ctx.Err()
+}
+
+// selectPosts returns the posts of the sources selected by the filter.
+func (o *Operation) selectPosts(ctx context.Context, client gotumblr.TumblrReader) ([]gotumblr.Post, error) {
+	sources := o.Sources
+	if len(sources) == 0 {
+		sources = []string{Posts}
+	}
+	var selected []gotumblr.Post
+	seen := map[gotumblr.PostID]bool{}
+	for _, source := range sources {
+		offset, last := 0, gotumblr.PostID(0)
+		for {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			page, err := o.page(client, source, offset, last)
+			if err != nil {
+				return nil, err
+			}
+			posts, err := gotumblr.DecodePosts(page)
+			if err != nil {
+				return nil, err
+			}
+			fresh := 0
+			for _, post := range posts {
+				id := post.Base().ID
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+				fresh++
+				if o.Filter == nil || o.Filter(post) {
+					selected = append(selected, post)
+				}
+			}
+			if fresh == 0 {
+				break
+			}
+			offset += len(posts)
+			last = posts[len(posts)-1].Base().ID
+		}
+	}
+	return selected, nil
+}
+
+// page fetches a page of a source, after offset posts or after the post last.
+func (o *Operation) page(client gotumblr.TumblrReader, source string, offset int, last gotumblr.PostID) ([]json.RawMessage, error) {
+	options := map[string]string{"offset": strconv.Itoa(offset), "limit": strconv.Itoa(pageSize)}
+	switch source {
+	case Posts:
+		response, err := client.Posts(o.Blog, "", options)
+		if err != nil {
+			return nil, err
+		}
+		return response.Posts, nil
+	case Drafts:
+		options = map[string]string{}
+		if last != 0 {
+			options["before_id"] = last.String()
+		}
+		response, err := client.Drafts(o.Blog, options)
+		if err != nil {
+			return nil, err
+		}
+		return response.Posts, nil
+	case Queue:
+		response, err := client.Queue(o.Blog, options)
+		if err != nil {
+			return nil, err
+		}
+		return response.Posts, nil
+	}
+	return nil, fmt.Errorf("bulk: unknown source %q", source)
+}
+
+// withContext binds client to ctx if it is a *gotumblr.TumblrRestClient.
+func withContext(ctx context.Context, client gotumblr.TumblrClient) gotumblr.TumblrClient {
+	if restClient, ok := client.(*gotumblr.TumblrRestClient); ok {
+		return restClient.WithContext(ctx)
+	}
+	return client
+}
+
+func (o *Operation) logf(format string, args ...interface{}) {
+	if o.Logf != nil {
+		o.Logf(format, args...)
+	}
+}
@@ -0,0 +1,296 @@
+package bulk_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spbr/gotumblr"
+	"github.com/spbr/gotumblr/bulk"
+	"github.com/spbr/gotumblr/tumblrtest"
+)
+
+var tester = gotumblr.MustParseBlogIdentifier("tester")
+
+// editHook is a client calling a hook around the edits of posts.
+type editHook struct {
+	gotumblr.TumblrClient
+	edit func(options map[string]string, next func() (bool, error)) (bool, error)
+}
+
+func (c *editHook) EditPost(blog gotumblr.BlogIdentifier, options map[string]string) (bool, error) {
+	return c.edit(options, func() (bool, error) { return c.TumblrClient.EditPost(blog, options) })
+}
+
+// addPosts adds n text posts with the given state and tags, oldest first.
+func addPosts(server *tumblrtest.Server, n int, state string, tags ...string) []gotumblr.PostID {
+	start := time.Now().Add(-time.Hour)
+	var ids []gotumblr.PostID
+	for i := 0; i < n; i++ {
+		ids = append(ids, server.AddPost("tester", map[string]interface{}{
+			"type": "text", "body": "x", "state": state, "tags": tags,
+			"timestamp": start.Add(time.Duration(i) * time.Second).Unix(),
+		}))
+	}
+	return ids
+}
+
+func tagsOf(t *testing.T, server *tumblrtest.Server, id gotumblr.PostID) string {
+	post, ok := server.Post(id)
+	if !ok {
+		t.Fatalf("no post %s", id)
+	}
+	return strings.Join(post["tags"].([]string), ",")
+}
+
+func TestRunConcurrency(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	ids := addPosts(server, 30, "published", "a")
+	var mutex sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	client := &editHook{server.Client(), func(options map[string]string, next func() (bool, error)) (bool, error) {
+		mutex.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mutex.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		defer func() {
+			mutex.Lock()
+			inFlight--
+			mutex.Unlock()
+		}()
+		return next()
+	}}
+	op := bulk.New(client, tester, bulk.All(), bulk.AddTags("b"))
+	op.Concurrency = 3
+	summary, err := op.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Selected != 30 || summary.Changed != 30 || summary.Failed != 0 {
+		t.Errorf("summary %+v, want 30 posts changed", summary)
+	}
+	if maxInFlight != 3 {
+		t.Errorf("%d posts changed at once, want 3", maxInFlight)
+	}
+	for _, id := range ids {
+		if tags := tagsOf(t, server, id); tags != "a,b" {
+			t.Errorf("post %s has the tags %q, want a,b", id, tags)
+		}
+	}
+}
+
+func TestRunDryRun(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	ids := addPosts(server, 3, "published", "old")
+	addPosts(server, 2, "published", "other")
+	var logs []string
+	op := bulk.New(server.Client(), tester, bulk.HasTag("old"), bulk.ReplaceTag("old", "new"))
+	op.DryRun = true
+	op.Journal = filepath.Join(t.TempDir(), "undo.jsonl")
+	op.Logf = func(format string, args ...interface{}) {
+		logs = append(logs, strings.TrimSpace(format))
+	}
+	summary, err := op.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Selected != 3 || summary.Changed != 3 {
+		t.Errorf("summary %+v, want 3 posts that would be changed", summary)
+	}
+	if len(logs) != 3 || !strings.Contains(logs[0], "would") {
+		t.Errorf("logs %q, want 3 changes that would be made", logs)
+	}
+	for _, request := range server.Requests() {
+		if !strings.HasPrefix(request, "GET ") {
+			t.Errorf("a dry run sent %s", request)
+		}
+	}
+	for _, id := range ids {
+		if tags := tagsOf(t, server, id); tags != "old" {
+			t.Errorf("post %s has the tags %q after a dry run", id, tags)
+		}
+	}
+	if _, err := bulk.ReadJournal(op.Journal); err == nil {
+		t.Error("a dry run wrote a journal")
+	}
+}
+
+func TestRunStopsOnRateLimit(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	addPosts(server, 8, "published", "a")
+	limited := make(chan struct{})
+	var mutex sync.Mutex
+	calls := 0
+	client := &editHook{server.Client(), func(options map[string]string, next func() (bool, error)) (bool, error) {
+		mutex.Lock()
+		calls++
+		call := calls
+		mutex.Unlock()
+		switch call {
+		case 1:
+			// in flight when the rate limit is exceeded
+			<-limited
+			time.Sleep(10 * time.Millisecond)
+			return next()
+		case 2:
+			defer close(limited)
+			return false, &gotumblr.APIError{StatusCode: 429}
+		}
+		t.Errorf("post %s changed after the rate limit was exceeded", options["id"])
+		return next()
+	}}
+	op := bulk.New(client, tester, bulk.All(), bulk.AddTags("b"))
+	op.Concurrency = 2
+	op.Journal = filepath.Join(t.TempDir(), "undo.jsonl")
+	summary, err := op.Run(context.Background())
+	if !gotumblr.IsRateLimited(err) {
+		t.Fatalf("err = %v, want the rate limit error", err)
+	}
+	if summary.Selected != 8 || summary.Changed != 1 || summary.Failed != 1 || len(summary.Errors) != 1 {
+		t.Errorf("summary %+v, want the post in flight changed and one failure", summary)
+	}
+	entries, err := bulk.ReadJournal(op.Journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || tagsOf(t, server, entries[0].ID) != "a,b" {
+		t.Errorf("journal %+v, want the entry of the post changed in flight", entries)
+	}
+}
+
+func TestJournalAndUndo(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	ids := addPosts(server, 3, "published", "a")
+	journal := filepath.Join(t.TempDir(), "undo.jsonl")
+	// two runs on the same posts, appended to the same journal
+	for _, action := range []bulk.Action{bulk.AddTags("b"), bulk.ReplaceTag("a", "c")} {
+		op := bulk.New(server.Client(), tester, bulk.All(), action)
+		op.Journal = journal
+		if _, err := op.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := bulk.ReadJournal(journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 6 {
+		t.Fatalf("%d journal entries, want 6", len(entries))
+	}
+	for i, entry := range entries {
+		want := map[bool]string{true: "a", false: "a,b"}[i < 3]
+		if entry.Undo["tags"] != want || entry.Change == "" {
+			t.Errorf("entry %d = %+v, want the tags %q restored", i, entry, want)
+		}
+	}
+
+	var edited []string
+	client := &editHook{server.Client(), func(options map[string]string, next func() (bool, error)) (bool, error) {
+		edited = append(edited, options["id"]+":"+options["tags"])
+		return next()
+	}}
+	undone, err := bulk.Undo(context.Background(), client, tester, journal)
+	if err != nil || undone != 6 {
+		t.Fatalf("Undo = %d, %v, want 6 changes undone", undone, err)
+	}
+	for i := range entries {
+		entry := entries[len(entries)-1-i]
+		if want := entry.ID.String() + ":" + entry.Undo["tags"]; edited[i] != want {
+			t.Errorf("edit %d of Undo = %s, want %s", i, edited[i], want)
+		}
+	}
+	for _, id := range ids {
+		if tags := tagsOf(t, server, id); tags != "a" {
+			t.Errorf("post %s has the tags %q after Undo, want a", id, tags)
+		}
+	}
+}
+
+func TestSetStateQueued(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	ids := addPosts(server, 2, "queued")
+	addPosts(server, 1, "published")
+
+	// the queued posts are already in the queue state of EditPost
+	op := bulk.New(server.Client(), tester, bulk.All(), bulk.SetState("queue"))
+	op.Sources = []string{bulk.Queue}
+	summary, err := op.Run(context.Background())
+	if err != nil || summary.Selected != 2 || summary.Unchanged != 2 {
+		t.Fatalf("SetState(queue) = %+v, %v, want 2 posts unchanged", summary, err)
+	}
+
+	var logs []string
+	op = bulk.New(server.Client(), tester, bulk.All(), bulk.SetState("draft"))
+	op.Sources = []string{bulk.Queue}
+	op.Journal = filepath.Join(t.TempDir(), "undo.jsonl")
+	op.Logf = func(format string, args ...interface{}) {
+		logs = append(logs, args[1].(string))
+	}
+	if summary, err = op.Run(context.Background()); err != nil || summary.Changed != 2 {
+		t.Fatalf("SetState(draft) = %+v, %v, want 2 posts changed", summary, err)
+	}
+	if len(logs) != 2 || logs[0] != "state queue -> draft" {
+		t.Errorf("logs %q, want state queue -> draft", logs)
+	}
+	entries, err := bulk.ReadJournal(op.Journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Undo["state"] != "queue" {
+			t.Errorf("entry %+v, want the queue state restored", entry)
+		}
+	}
+	if _, err := bulk.Undo(context.Background(), server.Client(), tester, op.Journal); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range ids {
+		if post, _ := server.Post(id); post["state"] != "queued" {
+			t.Errorf("post %s is %v after Undo, want queued", id, post["state"])
+		}
+	}
+}
+
+func TestSourcesDrafts(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	addPosts(server, 45, "draft")
+	addPosts(server, 3, "published")
+	op := bulk.New(server.Client(), tester, bulk.All(), bulk.SetState("published"))
+	op.Sources = []string{bulk.Drafts}
+	op.DryRun = true
+	summary, err := op.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Selected != 45 {
+		t.Errorf("%d drafts selected, want 45", summary.Selected)
+	}
+	pages := 0
+	for _, request := range server.Requests() {
+		if strings.HasSuffix(request, "/posts/draft") {
+			pages++
+		}
+	}
+	if pages != 4 {
+		t.Errorf("%d pages of drafts fetched, want 3 and an empty one", pages)
+	}
+
+	// the published posts only by default
+	op = bulk.New(server.Client(), tester, bulk.All(), bulk.AddTags("a"))
+	op.DryRun = true
+	if summary, err = op.Run(context.Background()); err != nil || summary.Selected != 3 {
+		t.Errorf("Run = %+v, %v, want the 3 published posts", summary, err)
+	}
+}
@@ -0,0 +1,85 @@
+package bulk
+
+import (
+	"strings"
+	"time"
+
+	"github.com/spbr/gotumblr"
+)
+
+// Filter selects the posts an operation applies to.
+type Filter func(post gotumblr.Post) bool
+
+// All selects every post.
+func All() Filter {
+	return func(gotumblr.Post) bool { return true }
+}
+
+// HasTag selects the posts with a tag, ignoring case.
+func HasTag(tag string) Filter {
+	return func(post gotumblr.Post) bool {
+		return indexTag(post.Base().Tags, tag) >= 0
+	}
+}
+
+// OfType selects the posts of a type, e.g. photo.
+func OfType(postType string) Filter {
+	return func(post gotumblr.Post) bool {
+		return post.Base().PostType == postType
+	}
+}
+
+// Before selects the posts published before t.
+func Before(t time.Time) Filter {
+	return func(post gotumblr.Post) bool {
+		return post.Base().Time().Before(t)
+	}
+}
+
+// After selects the posts published after t.
+func After(t time.Time) Filter {
+	return func(post gotumblr.Post) bool {
+		return post.Base().Time().After(t)
+	}
+}
+
+// And selects the posts selected by all the filters.
+func And(filters ...Filter) Filter {
+	return func(post gotumblr.Post) bool {
+		for _, filter := range filters {
+			if !filter(post) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or selects the posts selected by any of the filters.
+func Or(filters ...Filter) Filter {
+	return func(post gotumblr.Post) bool {
+		for _, filter := range filters {
+			if filter(post) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not selects the posts not selected by filter.
+func Not(filter Filter) Filter {
+	return func(post gotumblr.Post) bool {
+		return !filter(post)
+	}
+}
+
+// indexTag returns the index of a tag in tags, ignoring case, or -1.
+func indexTag(tags []string, tag string) int {
+	for i, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,100 @@
+package bulk
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/spbr/gotumblr"
+)
+
+// UndoEntry records a change of a post, one JSON object per line of an undo journal.
+type UndoEntry struct {
+	ID     gotumblr.PostID   `json:"id"`
+	Change string            `json:"change"` // description of the change
+	Undo   map[string]string `json:"undo"`   // options of EditPost undoing the change
+}
+
+// ReadJournal reads the entries of an undo journal, in the order they were written.
+func ReadJournal(path string) ([]UndoEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var entries []UndoEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry UndoEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("bulk: reading %s, line %d: %v", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// Undo undoes the changes recorded in an undo journal, newest first, by editing the posts back.
+// It stops at the first error and returns the number of changes undone. As undoing a change
+// twice has no effect, an interrupted Undo can be run again.
+func Undo(ctx context.Context, client gotumblr.TumblrWriter, blog gotumblr.BlogIdentifier, path string) (int, error) {
+	entries, err := ReadJournal(path)
+	if err != nil {
+		return 0, err
+	}
+	undone := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			return undone, err
+		}
+		options := map[string]string{}
+		for key, value := range entries[i].Undo {
+			options[key] = value
+		}
+		options["id"] = entries[i].ID.String()
+		if _, err := client.EditPost(blog, options); err != nil {
+			return undone, fmt.Errorf("bulk: undoing the change of post %s: %w", entries[i].ID, err)
+		}
+		undone++
+	}
+	return undone, nil
+}
+
+// journal appends entries to an undo journal, for several goroutines.
+type journal struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+func openJournal(path string) (*journal, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &journal{file: file}, nil
+}
+
+// record appends an entry and syncs it to disk.
+func (j *journal) record(entry UndoEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if _, err := j.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+func (j *journal) Close() error {
+	return j.file.Close()
+}
@@ -0,0 +1,234 @@
+package bulk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spbr/gotumblr"
+)
+
+// MergeTags returns an Action replacing the tags from of the posts, and the case variants
+// of to, by to. The tag takes the place of the first tag replaced; the other tags keep their order.
+func MergeTags(to string, from ...string) Action {
+	merged := append([]string{to}, from...)
+	return Retag(func(current []string) []string {
+		var updated []string
+		for _, tag := range current {
+			if indexTag(merged, tag) >= 0 {
+				tag = to
+			}
+			if indexTag(updated, tag) < 0 {
+				updated = append(updated, tag)
+			}
+		}
+		return updated
+	})
+}
+
+// TagRename renames a tag across the published posts of a blog, or merges several tags into one.
+// The tags are compared ignoring case, so the case variants of a tag, e.g. "Golang" and "GOLANG",
+// are renamed too. The fields must be set before Run is called.
+//
+// A run stopped by the rate limit of the API, or interrupted, can be resumed by running it again.
+// The posts already renamed are not found again, except those whose tags only change case
+// (e.g. "golang" renamed "Golang"), which are found again and left unchanged.
+// The search of the posts, if stopped, is resumed by the next Run of the same TagRename,
+// or with State set, by a TagRename of the same tags, e.g. in another process.
+type TagRename struct {
+	Blog gotumblr.BlogIdentifier
+	// From are the tags renamed.
+	From []string
+	// To is the new tag.
+	To string
+	// Concurrency is the number of posts changed at once, 4 by default.
+	Concurrency int
+	// DryRun only reports the changes, through Logf, without applying them.
+	DryRun bool
+	// Journal is the path of the undo journal, see Undo. No journal is written if it is empty.
+	Journal string
+	// State is the path of the file keeping the progress of a stopped search: the tags searched,
+	// the offset reached in the tag being searched and the posts found. It is removed once the search
+	// completes. No file is written if it is empty.
+	State string
+	// Logf, if set, is called to report the progress of the run.
+	Logf func(format string, args ...interface{})
+
+	client gotumblr.TumblrClient
+	search *search // the search stopped by the last run
+}
+
+// search is the progress of the search of the posts to rename.
+type search struct {
+	found  []gotumblr.Post
+	raw    []json.RawMessage // the found posts, as returned by the API
+	seen   map[gotumblr.PostID]bool
+	done   []string // the tags searched
+	offset int      // offset of the next page of the tag being searched
+	count  int      // number of posts found with the tag being searched
+}
+
+// searchState is the progress of a search saved in the State file of a TagRename.
+type searchState struct {
+	Blog   string            `json:"blog"`
+	From   []string          `json:"from"`
+	To     string            `json:"to"`
+	Done   []string          `json:"done"`
+	Offset int               `json:"offset"`
+	Count  int               `json:"count"`
+	Found  []json.RawMessage `json:"found"`
+}
+
+// NewTagRename creates a TagRename of the tags from of blog to the tag to.
+func NewTagRename(client gotumblr.TumblrClient, blog gotumblr.BlogIdentifier, to string, from ...string) *TagRename {
+	return &TagRename{Blog: blog, From: from, To: to, client: client}
+}
+
+// Run finds the posts carrying the tags with the tag filter of Posts, then rewrites their tags.
+// The posts are all found before any is changed. If the search stops, the summary tells
+// the number of posts found so far. See Operation.Run for the failures.
+func (r *TagRename) Run(ctx context.Context) (*Summary, error) {
+	op := &Operation{
+		Blog:        r.Blog,
+		Action:      MergeTags(r.To, r.From...),
+		Concurrency: r.Concurrency,
+		DryRun:      r.DryRun,
+		Journal:     r.Journal,
+		Logf:        r.Logf,
+		client:      r.client,
+	}
+	posts, err := r.find(ctx, withContext(ctx, r.client))
+	if err != nil {
+		return &Summary{Selected: len(posts)}, err
+	}
+	return op.apply(ctx, posts)
+}
+
+// find returns the posts carrying any of the tags renamed. If it fails, it returns the posts
+// found so far and keeps its progress for the next call.
+func (r *TagRename) find(ctx context.Context, client gotumblr.TumblrReader) (found []gotumblr.Post, err error) {
+	if r.search == nil {
+		if r.search, err = r.loadSearch(); err != nil {
+			return nil, err
+		}
+	}
+	s := r.search
+	defer func() {
+		if err != nil {
+			if saveErr := r.saveSearch(); saveErr != nil {
+				r.logf("saving the progress of the search: %v", saveErr)
+			}
+		}
+	}()
+	for _, tag := range r.From {
+		// the tag filter ignores case: a case variant of a tag searched already finds the same posts
+		if tag = strings.TrimSpace(tag); tag == "" || indexTag(s.done, tag) >= 0 {
+			continue
+		}
+		for {
+			if err := ctx.Err(); err != nil {
+				return s.found, err
+			}
+			options := map[string]string{"tag": tag, "offset": strconv.Itoa(s.offset), "limit": strconv.Itoa(pageSize)}
+			response, err := client.Posts(r.Blog, "", options)
+			if err != nil {
+				return s.found, err
+			}
+			posts, err := gotumblr.DecodePosts(response.Posts)
+			if err != nil {
+				return s.found, err
+			}
+			for i, post := range posts {
+				id := post.Base().ID
+				// the filter is checked again in case the API matches tags more loosely
+				if s.seen[id] || !HasTag(tag)(post) {
+					continue
+				}
+				s.seen[id] = true
+				s.found = append(s.found, post)
+				s.raw = append(s.raw, response.Posts[i])
+				s.count++
+			}
+			s.offset += len(posts)
+			if len(posts) == 0 || response.TotalPosts != 0 && int64(s.offset) >= response.TotalPosts {
+				break
+			}
+			r.logf("searching %q: %d/%d posts", tag, s.offset, response.TotalPosts)
+		}
+		r.logf("found %d posts tagged %q", s.count, tag)
+		s.done = append(s.done, tag)
+		s.offset, s.count = 0, 0
+	}
+	r.search = nil
+	if r.State != "" {
+		if err := os.Remove(r.State); err != nil && !os.IsNotExist(err) {
+			return s.found, err
+		}
+	}
+	return s.found, nil
+}
+
+// loadSearch returns the search saved in the State file, or a new search if there is none,
+// or if it was saved by a TagRename of other tags.
+func (r *TagRename) loadSearch() (*search, error) {
+	s := &search{seen: map[gotumblr.PostID]bool{}}
+	if r.State == "" {
+		return s, nil
+	}
+	data, err := ioutil.ReadFile(r.State)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state searchState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("bulk: reading %s: %v", r.State, err)
+	}
+	if state.Blog != r.Blog.Hostname() || state.To != r.To || strings.Join(state.From, "\n") != strings.Join(r.From, "\n") {
+		r.logf("%s holds the search of another rename, starting over", r.State)
+		return s, nil
+	}
+	found, err := gotumblr.DecodePosts(state.Found)
+	if err != nil {
+		return nil, fmt.Errorf("bulk: reading %s: %v", r.State, err)
+	}
+	for _, post := range found {
+		s.seen[post.Base().ID] = true
+	}
+	s.found, s.raw = found, state.Found
+	s.done, s.offset, s.count = state.Done, state.Offset, state.Count
+	r.logf("resuming the search: %d posts found", len(found))
+	return s, nil
+}
+
+// saveSearch saves the stopped search in the State file, if set.
+func (r *TagRename) saveSearch() error {
+	if r.State == "" || r.search == nil {
+		return nil
+	}
+	s := r.search
+	data, err := json.Marshal(searchState{
+		Blog: r.Blog.Hostname(), From: r.From, To: r.To,
+		Done: s.done, Offset: s.offset, Count: s.count, Found: s.raw,
+	})
+	if err != nil {
+		return err
+	}
+	temp := r.State + ".tmp"
+	if err := ioutil.WriteFile(temp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(temp, r.State)
+}
+
+func (r *TagRename) logf(format string, args ...interface{}) {
+	if r.Logf != nil {
+		r.Logf(format, args...)
+	}
+}
@@ -0,0 +1,123 @@
+package bulk_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spbr/gotumblr"
+	"github.com/spbr/gotumblr/bulk"
+	"github.com/spbr/gotumblr/tumblrtest"
+)
+
+func TestTagRenameResumesSearch(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	for i := 0; i < 45; i++ {
+		server.AddPost("tester", map[string]interface{}{"type": "text", "body": "x", "tags": []string{"golang", "misc"}})
+	}
+	blog := gotumblr.MustParseBlogIdentifier("tester")
+	r := bulk.NewTagRename(server.Client(), blog, "Golang", "golang")
+
+	// the rate limit stops the search after two pages
+	server.SetRateLimit(2, time.Hour)
+	summary, err := r.Run(context.Background())
+	if !gotumblr.IsRateLimited(err) {
+		t.Fatalf("Run over the rate limit: err = %v, want a rate limit error", err)
+	}
+	if summary.Selected != 40 || summary.Changed != 0 {
+		t.Errorf("stopped run: %+v, want 40 posts found and none changed", summary)
+	}
+
+	// the next run fetches the last page only
+	server.SetRateLimit(0, 0)
+	before := len(server.Requests())
+	if summary, err = r.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if summary.Selected != 45 || summary.Changed != 45 {
+		t.Errorf("resumed run: %+v, want 45 posts found and changed", summary)
+	}
+	if searched := len(server.Requests()) - before - 45; searched != 1 {
+		t.Errorf("the resumed run fetched %d pages, want 1", searched)
+	}
+
+	// a case-only rename finds the renamed posts again, and leaves them unchanged
+	if summary, err = r.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if summary.Selected != 45 || summary.Unchanged != 45 || summary.Changed != 0 {
+		t.Errorf("run after the rename: %+v, want 45 posts found and unchanged", summary)
+	}
+	for _, post := range server.BlogPosts("tester") {
+		if tags := post["tags"].([]string); len(tags) != 2 || tags[0] != "Golang" || tags[1] != "misc" {
+			t.Errorf("post %v has the tags %q, want Golang and misc", post["id"], tags)
+		}
+	}
+}
+
+func TestTagRenameResumesSearchFromState(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	for i := 0; i < 45; i++ {
+		server.AddPost("tester", map[string]interface{}{"type": "text", "body": "x", "tags": []string{"golang"}})
+	}
+	blog := gotumblr.MustParseBlogIdentifier("tester")
+	state := filepath.Join(t.TempDir(), "search.json")
+	newRename := func(to string) *bulk.TagRename {
+		r := bulk.NewTagRename(server.Client(), blog, to, "golang")
+		r.State = state
+		return r
+	}
+
+	server.SetRateLimit(2, time.Hour)
+	if _, err := newRename("go").Run(context.Background()); !gotumblr.IsRateLimited(err) {
+		t.Fatalf("Run over the rate limit: err = %v, want a rate limit error", err)
+	}
+	if _, err := os.Stat(state); err != nil {
+		t.Fatalf("no state saved: %v", err)
+	}
+	server.SetRateLimit(0, 0)
+
+	// a rename of other tags ignores the saved search and starts over
+	other := newRename("go-lang")
+	other.DryRun = true
+	before := len(server.Requests())
+	if summary, err := other.Run(context.Background()); err != nil || summary.Selected != 45 {
+		t.Fatalf("other rename: %+v, %v, want 45 posts found", summary, err)
+	}
+	if searched := len(server.Requests()) - before; searched != 3 {
+		t.Errorf("the other rename fetched %d pages, want 3", searched)
+	}
+	if _, err := os.Stat(state); !os.IsNotExist(err) {
+		t.Errorf("the state file is left after a completed search: %v", err)
+	}
+
+	// stopped again, the search is resumed by a new TagRename of the same tags
+	server.SetRateLimit(2, time.Hour)
+	if _, err := newRename("go").Run(context.Background()); !gotumblr.IsRateLimited(err) {
+		t.Fatalf("Run over the rate limit: err = %v, want a rate limit error", err)
+	}
+	server.SetRateLimit(0, 0)
+	before = len(server.Requests())
+	summary, err := newRename("go").Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Selected != 45 || summary.Changed != 45 {
+		t.Errorf("resumed run: %+v, want 45 posts found and changed", summary)
+	}
+	if searched := len(server.Requests()) - before - 45; searched != 1 {
+		t.Errorf("the resumed run fetched %d pages, want 1", searched)
+	}
+	if _, err := os.Stat(state); !os.IsNotExist(err) {
+		t.Errorf("the state file is left after a completed search: %v", err)
+	}
+	for _, post := range server.BlogPosts("tester") {
+		if tags := post["tags"].([]string); len(tags) != 1 || tags[0] != "go" {
+			t.Errorf("post %v has the tags %q, want go", post["id"], tags)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package gotumblr
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedResponse is a response of the API stored in a CacheStore.
+type CachedResponse struct {
+	Key          string      `json:"key"`
+	StatusCode   int         `json:"status_code"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         []byte      `json:"body"`
+	Expires      time.Time   `json:"expires"`                 // the response is fresh until then
+	ETag         string      `json:"etag,omitempty"`          // validator sent in If-None-Match once the response is stale
+	LastModified string      `json:"last_modified,omitempty"` // validator sent in If-Modified-Since once the response is stale
+}
+
+// CacheStore stores the responses cached by a Cache. Implementations must be safe for concurrent use.
+// Stale responses are kept by the store, so that they can be revalidated with a conditional request.
+type CacheStore interface {
+	// Get returns the response stored under key.
+	Get(key string) (*CachedResponse, bool)
+	// Set stores a response under its key, replacing any previous one.
+	Set(response *CachedResponse)
+	// DeletePrefix removes the responses whose key starts with prefix.
+	DeletePrefix(prefix string)
+}
+
+// DefaultCacheTTLs are the TTLs used by NewCache, by endpoint template (see EndpointTemplate).
+var DefaultCacheTTLs = map[string]time.Duration{
+	"/v2/blog/{blog}/info":             10 * time.Minute,
+	"/v2/blog/{blog}/followers":        5 * time.Minute,
+	"/v2/blog/{blog}/likes":            time.Minute,
+	"/v2/blog/{blog}/posts":            time.Minute,
+	"/v2/blog/{blog}/posts/text":       time.Minute,
+	"/v2/blog/{blog}/posts/quote":      time.Minute,
+	"/v2/blog/{blog}/posts/link":       time.Minute,
+	"/v2/blog/{blog}/posts/answer":     time.Minute,
+	"/v2/blog/{blog}/posts/video":      time.Minute,
+	"/v2/blog/{blog}/posts/audio":      time.Minute,
+	"/v2/blog/{blog}/posts/photo":      time.Minute,
+	"/v2/blog/{blog}/posts/chat":       time.Minute,
+	"/v2/blog/{blog}/posts/queue":      time.Minute,
+	"/v2/blog/{blog}/posts/draft":      time.Minute,
+	"/v2/blog/{blog}/posts/submission": time.Minute,
+}
+
+// Cache caches the responses of GET requests to the API, see TumblrRequest.SetCache.
+// Responses are fresh for the TTL of their endpoint; once stale, they are revalidated
+// with a conditional request if the API sent an ETag or Last-Modified header, and fetched again otherwise.
+// Only successful responses are cached.
+//
+// The cached responses of a blog's endpoints are invalidated when a post of the blog is
+// created, edited, reblogged or deleted through a TumblrRequest using the Cache, whichever
+// identifier the blog is known by: its name, hostname, UUID or custom domain. The UUID and
+// custom domain of a blog are learnt from the cached responses that describe the blog.
+// Responses to authenticated requests (e.g. the queue) are cached under the account they were
+// sent for, so a store can be shared by the clients of different users and API hosts.
+type Cache struct {
+	Store CacheStore
+	// TTLs gives the time responses stay fresh, by endpoint template (see EndpointTemplate).
+	// The responses of the endpoints that are not listed are not cached.
+	TTLs map[string]time.Duration
+	// Now returns the current time, time.Now if nil.
+	Now func() time.Time
+
+	mutex       sync.Mutex
+	generations map[string]uint64 // by blog hostname, incremented by every invalidation
+	aliases     map[string]string // the hostnames of the blogs by UUID and custom domain
+}
+
+// NewCache creates a Cache storing the responses in store with the DefaultCacheTTLs.
+func NewCache(store CacheStore) *Cache {
+	ttls := map[string]time.Duration{}
+	for endpoint, ttl := range DefaultCacheTTLs {
+		ttls[endpoint] = ttl
+	}
+	return &Cache{Store: store, TTLs: ttls}
+}
+
+// InvalidateBlog removes the cached responses of the endpoints of a blog, under any of its
+// known identifiers. The responses of the requests in flight are not cached.
+func (c *Cache) InvalidateBlog(blog BlogIdentifier) {
+	if !blog.IsZero() {
+		c.invalidate(blog.Hostname())
+	}
+}
+
+// invalidate removes the cached responses of a blog and of its aliases, given the blog identifier
+// of the endpoint paths, and increments their generations so that the responses in flight are not stored.
+func (c *Cache) invalidate(blog string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.generations == nil {
+		c.generations = map[string]uint64{}
+	}
+	if hostname, ok := c.aliases[blog]; ok {
+		blog = hostname
+	}
+	identifiers := []string{blog}
+	for alias, hostname := range c.aliases {
+		if hostname == blog {
+			identifiers = append(identifiers, alias)
+		}
+	}
+	for _, identifier := range identifiers {
+		c.generations[identifier]++
+		c.Store.DeletePrefix(blogPathPrefix + identifier + "/")
+	}
+}
+
+// generation returns the generation of the cached responses of a blog, see set.
+func (c *Cache) generation(blog string) uint64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.generations[blog]
+}
+
+// set stores a response of the endpoint of a blog, unless the blog was invalidated since its
+// generation was read, before the request was sent. blog is "" for the other endpoints.
+// The aliases of the blog are learnt from the response.
+func (c *Cache) set(response *CachedResponse, blog string, generation uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.generations[blog] != generation {
+		return
+	}
+	c.Store.Set(response)
+	if blog != "" {
+		c.learnAliases(response.Body)
+	}
+}
+
+// learnAliases records the UUID and custom domain of the blog described by a response, if any.
+// The mutex must be held.
+func (c *Cache) learnAliases(body []byte) {
+	var data struct {
+		Response struct {
+			Blog struct {
+				Name, UUID, URL string
+			}
+		}
+	}
+	if json.Unmarshal(body, &data) != nil || data.Response.Blog.Name == "" {
+		return
+	}
+	blog := data.Response.Blog
+	hostname := blog.Name + ".tumblr.com"
+	if c.aliases == nil {
+		c.aliases = map[string]string{}
+	}
+	if blog.UUID != "" {
+		c.aliases[blog.UUID] = hostname
+	}
+	if u, err := url.Parse(blog.URL); err == nil && u.Hostname() != "" {
+		if domain := strings.ToLower(u.Hostname()); domain != hostname {
+			c.aliases[domain] = hostname
+		}
+	}
+}
+
+// EndpointTemplate returns the path of an API request with the blog identifier replaced by {blog}
+// and the avatar size by {size}, e.g. /v2/blog/{blog}/posts/text for /v2/blog/staff.tumblr.com/posts/text.
+// Any query is removed.
+func EndpointTemplate(requestURL string) string {
+	if i := strings.IndexByte(requestURL, '?'); i >= 0 {
+		requestURL = requestURL[:i]
+	}
+	const prefix = "/v2/blog/"
+	if !strings.HasPrefix(requestURL, prefix) {
+		return requestURL
+	}
+	rest := requestURL[len(prefix):]
+	i := strings.IndexByte(rest, '/')
+	if i < 0 {
+		return prefix + "{blog}"
+	}
+	if strings.HasPrefix(rest[i:], "/avatar/") {
+		return prefix + "{blog}/avatar/{size}"
+	}
+	return prefix + "{blog}" + rest[i:]
+}
+
+func (c *Cache) now() time.Time {
+	if c.Now == nil {
+		return time.Now()
+	}
+	return c.Now()
+}
+
+// ttl returns the TTL of the endpoint of a request, 0 if its responses are not cached.
+func (c *Cache) ttl(requestURL string) time.Duration {
+	return c.TTLs[EndpointTemplate(requestURL)]
+}
+
+// blogPathPrefix is the path prefix of the endpoints of a blog.
+const blogPathPrefix = "/v2/blog/"
+
+// blogOf returns the blog identifier of the path of a request to the endpoints of a blog,
+// e.g. staff.tumblr.com for /v2/blog/staff.tumblr.com/posts, or "" for the other endpoints.
+func blogOf(requestURL string) string {
+	if !strings.HasPrefix(requestURL, blogPathPrefix) {
+		return ""
+	}
+	rest := requestURL[len(blogPathPrefix):]
+	i := strings.IndexByte(rest, '/')
+	if i <= 0 {
+		return ""
+	}
+	return rest[:i]
+}
+
+// cacheKey returns the key the response of a GET request is cached under: its path and encoded
+// parameters, then the account the request was sent for,
+// e.g. /v2/blog/staff.tumblr.com/posts?limit=5#api.tumblr.com/3f2a9c1e04d5b6a7.
+func cacheKey(requestURL string, params map[string]string, account string) string {
+	key := requestURL
+	if len(params) != 0 {
+		values := url.Values{}
+		for key, value := range params {
+			values.Set(key, value)
+		}
+		key += "?" + values.Encode()
+	}
+	return key + "#" + account
+}
@@ -0,0 +1,98 @@
+package gotumblr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DiskCache is a CacheStore keeping every response in a JSON file of a directory,
+// so that the cache survives restarts. It never evicts responses; the directory can be
+// emptied at any time the cache is not used.
+type DiskCache struct {
+	dir   string
+	mutex sync.Mutex
+}
+
+// NewDiskCache creates a DiskCache storing the responses in dir, which is created if needed.
+// The files are only readable by the user, as they can contain private posts.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// Get returns the response stored under key. Unreadable files are treated as missing.
+func (c *DiskCache) Get(key string) (*CachedResponse, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	response, err := c.read(c.path(key))
+	if err != nil || response.Key != key {
+		return nil, false
+	}
+	return response, true
+}
+
+// Set stores a response under its key. Write errors are ignored, the response is then not cached.
+func (c *DiskCache) Set(response *CachedResponse) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	file, err := ioutil.TempFile(c.dir, ".tmp-")
+	if err != nil {
+		return
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), c.path(response.Key))
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+}
+
+// DeletePrefix removes the responses whose key starts with prefix.
+func (c *DiskCache) DeletePrefix(prefix string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	paths, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return
+	}
+	for _, path := range paths {
+		response, err := c.read(path)
+		if err != nil || strings.HasPrefix(response.Key, prefix) {
+			os.Remove(path)
+		}
+	}
+}
+
+// path returns the path of the file a response is stored in.
+func (c *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *DiskCache) read(path string) (*CachedResponse, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var response CachedResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
@@ -0,0 +1,69 @@
+package gotumblr
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+)
+
+// LRUCache is an in-memory CacheStore that keeps the most recently used responses.
+type LRUCache struct {
+	maxEntries int
+
+	mutex   sync.Mutex
+	order   *list.List // of *CachedResponse, most recently used first
+	entries map[string]*list.Element
+}
+
+// NewLRUCache creates an LRUCache holding at most maxEntries responses, or an unlimited number if maxEntries <= 0.
+func NewLRUCache(maxEntries int) *LRUCache {
+	return &LRUCache{maxEntries: maxEntries, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+// Get returns the response stored under key.
+func (c *LRUCache) Get(key string) (*CachedResponse, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*CachedResponse), true
+}
+
+// Set stores a response under its key, evicting the least recently used response if the cache is full.
+func (c *LRUCache) Set(response *CachedResponse) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if element, ok := c.entries[response.Key]; ok {
+		element.Value = response
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[response.Key] = c.order.PushFront(response)
+	if c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*CachedResponse).Key)
+	}
+}
+
+// DeletePrefix removes the responses whose key starts with prefix.
+func (c *LRUCache) DeletePrefix(prefix string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for key, element := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.order.Remove(element)
+			delete(c.entries, key)
+		}
+	}
+}
+
+// Len returns the number of responses in the cache.
+func (c *LRUCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.order.Len()
+}
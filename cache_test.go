@@ -0,0 +1,276 @@
+package gotumblr_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spbr/gotumblr"
+	"github.com/spbr/gotumblr/tumblrtest"
+)
+
+type countingLimiter struct {
+	waits int
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	l.waits++
+	return ctx.Err()
+}
+
+func TestCacheWaitsForLimiter(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	now := time.Now()
+	cache := gotumblr.NewCache(gotumblr.NewLRUCache(10))
+	cache.Now = func() time.Time { return now }
+	limiter := new(countingLimiter)
+	client := server.Client()
+	client.SetCache(cache)
+	client.SetRateLimiter(limiter)
+	blog := gotumblr.MustParseBlogIdentifier("tester")
+
+	for i, test := range []struct {
+		name    string
+		advance time.Duration
+		waits   int
+	}{
+		{"cache miss", 0, 1},
+		{"fresh response", time.Minute, 1},
+		{"stale response", time.Hour, 2},
+	} {
+		now = now.Add(test.advance)
+		if _, err := client.BlogInfo(blog); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if limiter.waits != test.waits {
+			t.Errorf("%s: the limiter was waited for %d times, want %d", test.name, limiter.waits, test.waits)
+		}
+		if requests := server.Requests(); len(requests) != test.waits {
+			t.Errorf("request %d (%s): %d requests sent, want %d", i, test.name, len(requests), test.waits)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	now = now.Add(time.Hour)
+	if _, err := client.WithContext(ctx).BlogInfo(blog); err != context.Canceled {
+		t.Errorf("BlogInfo with a done context: err = %v, want the error of the limiter", err)
+	}
+	if requests := server.Requests(); len(requests) != 2 {
+		t.Errorf("%d requests sent, want none once the limiter fails", len(requests)-2)
+	}
+}
+
+// statusRecorder records the HTTP status of the responses, and can hold the responses of a path.
+type statusRecorder struct {
+	mutex    sync.Mutex
+	statuses []int
+	hold     string        // path of the responses held
+	held     chan struct{} // receives once a response is held
+	release  chan struct{} // closed to release the held responses
+}
+
+func (r *statusRecorder) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, err := http.DefaultTransport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+	r.mutex.Lock()
+	r.statuses = append(r.statuses, response.StatusCode)
+	hold := r.hold != "" && request.URL.Path == r.hold
+	r.hold = ""
+	r.mutex.Unlock()
+	if hold {
+		r.held <- struct{}{}
+		<-r.release
+	}
+	return response, nil
+}
+
+func (r *statusRecorder) take() []int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	statuses := r.statuses
+	r.statuses = nil
+	return statuses
+}
+
+func cachingClient(server *tumblrtest.Server, now *time.Time) (*gotumblr.TumblrRestClient, *gotumblr.Cache, *statusRecorder) {
+	cache := gotumblr.NewCache(gotumblr.NewLRUCache(0))
+	cache.Now = func() time.Time { return *now }
+	recorder := new(statusRecorder)
+	client := server.Client()
+	client.SetCache(cache)
+	client.SetHTTPClient(&http.Client{Transport: recorder})
+	return client, cache, recorder
+}
+
+func TestCacheRevalidation(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	server.AddPost("tester", map[string]interface{}{"type": "text", "body": "first"})
+	now := time.Now()
+	client, _, recorder := cachingClient(server, &now)
+	blog := gotumblr.MustParseBlogIdentifier("tester")
+
+	for _, test := range []struct {
+		name     string
+		advance  time.Duration
+		newPost  bool
+		statuses []int
+		posts    int64
+	}{
+		{"cache miss", 0, false, []int{200}, 1},
+		{"fresh", 9 * time.Minute, false, nil, 1},
+		{"expired, not modified", 2 * time.Minute, false, []int{304}, 1},
+		{"fresh again", 9 * time.Minute, false, nil, 1},
+		{"fresh, modified", 30 * time.Second, true, nil, 1},
+		{"expired, modified", 2 * time.Minute, false, []int{200}, 2},
+		{"fresh after the change", time.Minute, false, nil, 2},
+	} {
+		now = now.Add(test.advance)
+		if test.newPost {
+			server.AddPost("tester", map[string]interface{}{"type": "text", "body": "second"})
+		}
+		info, err := client.BlogInfo(blog)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if statuses := recorder.take(); !reflect.DeepEqual(statuses, test.statuses) {
+			t.Errorf("%s: responses %v, want %v", test.name, statuses, test.statuses)
+		}
+		if info.Blog.Posts != test.posts {
+			t.Errorf("%s: %d posts, want %d", test.name, info.Blog.Posts, test.posts)
+		}
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	server.SetCustomDomain("tester", "blog.example.com")
+	now := time.Now()
+	client, cache, recorder := cachingClient(server, &now)
+	identifiers := []gotumblr.BlogIdentifier{
+		gotumblr.MustParseBlogIdentifier("tester"),
+		gotumblr.MustParseBlogIdentifier(server.BlogUUID("tester")),
+		gotumblr.MustParseBlogIdentifier("blog.example.com"),
+	}
+	fill := func() {
+		for _, blog := range identifiers {
+			if _, err := client.Posts(blog, "", map[string]string{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		recorder.take()
+	}
+	// count returns the number of posts listed under every identifier, and the requests sent
+	count := func() ([]int, int) {
+		var counts []int
+		for _, blog := range identifiers {
+			posts, err := client.Posts(blog, "", map[string]string{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			counts = append(counts, len(posts.Posts))
+		}
+		return counts, len(recorder.take())
+	}
+
+	// the first response describing the blog teaches its UUID and custom domain
+	fill()
+	if counts, requests := count(); requests != 0 {
+		t.Errorf("%d requests for cached responses, counts %v", requests, counts)
+	}
+	if _, err := client.CreatePost(identifiers[1], map[string]string{"type": "text", "body": "new"}); err != nil {
+		t.Fatal(err)
+	}
+	recorder.take()
+	if counts, requests := count(); requests != 3 || !reflect.DeepEqual(counts, []int{1, 1, 1}) {
+		t.Errorf("after a post by UUID: %d requests, counts %v, want 3 requests and the new post everywhere", requests, counts)
+	}
+
+	fill()
+	server.AddPost("tester", map[string]interface{}{"type": "text", "body": "added behind the cache"})
+	cache.InvalidateBlog(gotumblr.MustParseBlogIdentifier("blog.example.com"))
+	if counts, requests := count(); requests != 3 || !reflect.DeepEqual(counts, []int{2, 2, 2}) {
+		t.Errorf("after InvalidateBlog: %d requests, counts %v, want 3 requests and 2 posts everywhere", requests, counts)
+	}
+}
+
+func TestCacheInvalidationInFlight(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	server.AddPost("tester", map[string]interface{}{"type": "text", "body": "first"})
+	now := time.Now()
+	client, cache, recorder := cachingClient(server, &now)
+	blog := gotumblr.MustParseBlogIdentifier("tester")
+	recorder.hold = "/v2/blog/tester.tumblr.com/posts"
+	recorder.held = make(chan struct{})
+	recorder.release = make(chan struct{})
+
+	// the response of the first request is held while the blog changes
+	done := make(chan int)
+	go func() {
+		posts, err := client.Posts(blog, "", map[string]string{})
+		if err != nil {
+			t.Error(err)
+		}
+		done <- len(posts.Posts)
+	}()
+	<-recorder.held
+	server.AddPost("tester", map[string]interface{}{"type": "text", "body": "second"})
+	cache.InvalidateBlog(blog)
+
+	// a request made after the invalidation does not wait for the request in flight
+	posts, err := client.Posts(blog, "", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts.Posts) != 2 {
+		t.Errorf("request after the invalidation: %d posts, want 2", len(posts.Posts))
+	}
+	close(recorder.release)
+	if n := <-done; n != 1 {
+		t.Errorf("request in flight: %d posts, want 1", n)
+	}
+	recorder.take()
+
+	// the response of the request in flight was not cached over the newer one
+	if posts, err = client.Posts(blog, "", map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(posts.Posts) != 2 || len(recorder.take()) != 0 {
+		t.Errorf("cached response with %d posts, want the cached response with 2 posts", len(posts.Posts))
+	}
+}
+
+func TestCacheKeyAccount(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	store := gotumblr.NewLRUCache(0)
+	client := server.Client()
+	client.SetCache(gotumblr.NewCache(store))
+	blog := gotumblr.MustParseBlogIdentifier("tester")
+	if _, err := client.Drafts(blog, map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// another account sharing the store does not get the drafts of the first one
+	other := gotumblr.NewTumblrRestClient(server.ConsumerKey, server.ConsumerSecret, "other-token", "other-secret", "", server.URL)
+	other.SetCache(gotumblr.NewCache(store))
+	before := len(server.Requests())
+	if _, err := other.Drafts(blog, map[string]string{}); err == nil {
+		t.Error("the drafts of another account were answered from the cache")
+	}
+	if len(server.Requests()) != before+1 {
+		t.Error("the request of another account was not sent")
+	}
+	if store.Len() != 1 {
+		t.Errorf("%d cached responses, want the response of the first account", store.Len())
+	}
+}
@@ -0,0 +1,91 @@
+package gotumblr
+
+import (
+	"strings"
+	"time"
+)
+
+// ParseConversation parses the text of a chat post, one "Label: phrase" line per dialogue entry,
+// into the structured form returned in ChatPost.Dialogue.
+// Lines without a label become entries with only a phrase, as do the lines starting with ": ",
+// which FormatConversation writes for the phrases without a label that contain a colon.
+// Blank lines are skipped.
+func ParseConversation(conversation string) []DialogueInfo {
+	var dialogue []DialogueInfo
+	for _, line := range strings.Split(conversation, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, ": ") {
+			dialogue = append(dialogue, DialogueInfo{Phrase: strings.TrimSpace(line[1:])})
+			continue
+		}
+		colon := strings.Index(line, ":")
+		if colon <= 0 {
+			dialogue = append(dialogue, DialogueInfo{Phrase: line})
+			continue
+		}
+		name := strings.TrimSpace(line[:colon])
+		dialogue = append(dialogue, DialogueInfo{
+			Name:   name,
+			Label:  name + ":",
+			Phrase: strings.TrimSpace(line[colon+1:]),
+		})
+	}
+	return dialogue
+}
+
+// FormatConversation renders dialogue entries back into the conversation text
+// expected by CreateChatPost, one "Label: phrase" line per entry, so that ParseConversation
+// returns the same entries: a phrase without a label that contains a colon is written after ": ".
+func FormatConversation(dialogue []DialogueInfo) string {
+	lines := make([]string, 0, len(dialogue))
+	for _, entry := range dialogue {
+		label := strings.TrimSpace(entry.Label)
+		if label == "" {
+			label = strings.TrimSpace(entry.Name)
+		}
+		switch {
+		case label != "":
+			lines = append(lines, strings.TrimSuffix(label, ":")+": "+entry.Phrase)
+		case strings.Contains(entry.Phrase, ":"):
+			lines = append(lines, ": "+entry.Phrase)
+		default:
+			lines = append(lines, entry.Phrase)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ChatPostParams holds the parameters for creating a chat post with CreateChatPost.
+// Zero-valued fields are not sent.
+type ChatPostParams struct {
+	Title     string
+	Dialogue  []DialogueInfo
+	State     string // published, draft, queue or private
+	Tags      []string
+	Tweet     string
+	Date      time.Time
+	PublishOn time.Time // for queued posts
+	Format    string    // html or markdown
+	Slug      string
+}
+
+// Options returns the parameters as the options expected by CreateChatPost.
+func (p ChatPostParams) Options() map[string]string {
+	options := map[string]string{"conversation": FormatConversation(p.Dialogue)}
+	for key, value := range map[string]string{
+		"title":  p.Title,
+		"state":  p.State,
+		"tags":   strings.Join(p.Tags, ","),
+		"tweet":  p.Tweet,
+		"format": p.Format,
+		"slug":   p.Slug,
+	} {
+		if value != "" {
+			options[key] = value
+		}
+	}
+	return PostTimes{Date: p.Date, PublishOn: p.PublishOn}.Apply(options)
+}
@@ -0,0 +1,66 @@
+package gotumblr_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spbr/gotumblr"
+)
+
+func TestParseConversation(t *testing.T) {
+	conversation := "Alice: what time is it?\n\n  Bob :  10:30, why?\nsilence\n: note: no label\n:)"
+	want := []gotumblr.DialogueInfo{
+		{Name: "Alice", Label: "Alice:", Phrase: "what time is it?"},
+		{Name: "Bob", Label: "Bob:", Phrase: "10:30, why?"},
+		{Phrase: "silence"},
+		{Phrase: "note: no label"},
+		{Phrase: ":)"},
+	}
+	if got := gotumblr.ParseConversation(conversation); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseConversation = %+v, want %+v", got, want)
+	}
+}
+
+func TestConversationRoundTrip(t *testing.T) {
+	for _, dialogue := range [][]gotumblr.DialogueInfo{
+		{{Name: "Alice", Label: "Alice:", Phrase: "hi"}, {Name: "Bob", Label: "Bob:", Phrase: "it's 10:30: late"}},
+		{{Phrase: "no label"}, {Phrase: "no label: but a colon"}, {Phrase: ":-)"}},
+		{{Name: "Dr. Who", Label: "Dr. Who:", Phrase: ""}, {Phrase: "http://example.com/"}},
+	} {
+		text := gotumblr.FormatConversation(dialogue)
+		if got := gotumblr.ParseConversation(text); !reflect.DeepEqual(got, dialogue) {
+			t.Errorf("ParseConversation(FormatConversation(%+v)) = %+v, via %q", dialogue, got, text)
+		}
+	}
+
+	// the label defaults to the name, and gets its colon
+	dialogue := []gotumblr.DialogueInfo{{Name: "Alice", Phrase: "hi"}, {Label: "Bob", Phrase: "hello"}}
+	if text := gotumblr.FormatConversation(dialogue); text != "Alice: hi\nBob: hello" {
+		t.Errorf("FormatConversation = %q", text)
+	}
+}
+
+func TestChatPostParamsOptions(t *testing.T) {
+	params := gotumblr.ChatPostParams{
+		Title:     "Chat",
+		Dialogue:  []gotumblr.DialogueInfo{{Name: "Alice", Label: "Alice:", Phrase: "at 10:30?"}, {Phrase: "later: maybe"}},
+		State:     "queue",
+		Tags:      []string{"a", "b"},
+		PublishOn: time.Date(2024, 2, 20, 16, 0, 0, 0, time.UTC),
+	}
+	options := params.Options()
+	want := map[string]string{
+		"title":        "Chat",
+		"conversation": "Alice: at 10:30?\n: later: maybe",
+		"state":        "queue",
+		"tags":         "a,b",
+		"publish_on":   "2024-02-20 16:00:00 GMT",
+	}
+	if !reflect.DeepEqual(options, want) {
+		t.Errorf("Options = %v, want %v", options, want)
+	}
+	if got := gotumblr.ParseConversation(options["conversation"]); !reflect.DeepEqual(got, params.Dialogue) {
+		t.Errorf("the conversation parses to %+v, want %+v", got, params.Dialogue)
+	}
+}
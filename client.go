@@ -0,0 +1,53 @@
+package gotumblr
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// TumblrReader is implemented by clients of the read endpoints of the Tumblr API.
+type TumblrReader interface {
+	Info() (*UserInfoResponse, error)
+	Avatar(blog BlogIdentifier, size int) (*AvatarResponse, error)
+	AvatarImage(blog BlogIdentifier, size int, w io.Writer) (int64, error)
+	Likes(options map[string]string) (*LikesResponse, error)
+	Following(options map[string]string) (*FollowingResponse, error)
+	Dashboard(options map[string]string) (*DraftsResponse, error)
+	Tagged(tag string, options map[string]string) ([]json.RawMessage, error)
+	Posts(blog BlogIdentifier, postsType string, options map[string]string) (*PostsResponse, error)
+	BlogInfo(blog BlogIdentifier) (*BlogInfoResponse, error)
+	Followers(blog BlogIdentifier, options map[string]string) (*FollowersResponse, error)
+	BlogLikes(blog BlogIdentifier, options map[string]string) (*LikesResponse, error)
+	Queue(blog BlogIdentifier, options map[string]string) (*DraftsResponse, error)
+	Drafts(blog BlogIdentifier, options map[string]string) (*DraftsResponse, error)
+	Submission(blog BlogIdentifier, options map[string]string) (*DraftsResponse, error)
+}
+
+// TumblrWriter is implemented by clients of the endpoints of the Tumblr API that change data.
+type TumblrWriter interface {
+	Follow(blog BlogIdentifier) (bool, error)
+	Unfollow(blog BlogIdentifier) (bool, error)
+	Like(id PostID, reblogKey string) (bool, error)
+	Unlike(id PostID, reblogKey string) (bool, error)
+	CreatePhoto(blog BlogIdentifier, options map[string]string) (bool, error)
+	CreateText(blog BlogIdentifier, options map[string]string) (bool, error)
+	CreateQuote(blog BlogIdentifier, options map[string]string) (bool, error)
+	CreateLink(blog BlogIdentifier, options map[string]string) (bool, error)
+	CreateChatPost(blog BlogIdentifier, options map[string]string) (bool, error)
+	CreateAudio(blog BlogIdentifier, options map[string]string) (bool, error)
+	CreateVideo(blog BlogIdentifier, options map[string]string) (bool, error)
+	CreatePost(blog BlogIdentifier, options map[string]string, uploads ...Upload) (PostID, error)
+	CreateNPFPost(blog BlogIdentifier, params NPFPostParams) (PostID, error)
+	Reblog(blog BlogIdentifier, options map[string]string) (bool, error)
+	DeletePost(blog BlogIdentifier, id PostID) (bool, error)
+	EditPost(blog BlogIdentifier, options map[string]string) (bool, error)
+}
+
+// TumblrClient is implemented by clients of the whole Tumblr API, such as TumblrRestClient,
+// mocks (see the tumblrmock package) and decorators adding e.g. caching or auditing.
+type TumblrClient interface {
+	TumblrReader
+	TumblrWriter
+}
+
+var _ TumblrClient = (*TumblrRestClient)(nil)
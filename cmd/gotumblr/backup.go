@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/spbr/gotumblr"
+	"github.com/spbr/gotumblr/backup"
+)
+
+// runBackup backs up a blog to a directory, see package backup.
+func runBackup(profile string, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("gotumblr backup", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	drafts := fs.Bool("drafts", false, "back up the drafts")
+	queue := fs.Bool("queue", false, "back up the queued posts")
+	submissions := fs.Bool("submissions", false, "back up the submissions")
+	likes := fs.Bool("likes", false, "back up the posts the blog liked")
+	all := fs.Bool("all", false, "back up the drafts, queue, submissions and likes too")
+	media := fs.Bool("media", true, "download the photos, videos and audio files")
+	full := fs.Bool("full", false, "fetch all the posts, not only those newer than the last backup")
+	verify := fs.Bool("verify", false, "check the checksums of the media already downloaded")
+	concurrency := fs.Int("concurrency", 4, "number of media downloaded at once")
+	quiet := fs.Bool("q", false, "do not report the progress")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gotumblr backup [flags] <blog> <dir>")
+		fmt.Fprintln(stderr, "back up the posts and media of a blog to a directory, only fetching the new posts if it holds a backup")
+		fmt.Fprintln(stderr, "\nflags:")
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return errors.New("backup expects the arguments <blog> <dir>")
+	}
+	blog, err := gotumblr.ParseBlogIdentifier(positional[0])
+	if err != nil {
+		return err
+	}
+	client, err := gotumblr.NewClientFromProfile(profile)
+	if err != nil {
+		return err
+	}
+
+	b := backup.New(client, blog, positional[1])
+	b.Drafts = *drafts || *all
+	b.Queue = *queue || *all
+	b.Submissions = *submissions || *all
+	b.Likes = *likes || *all
+	b.Media, b.Full, b.Verify, b.Concurrency = *media, *full, *verify, *concurrency
+	if !*quiet {
+		b.Logf = func(format string, args ...interface{}) {
+			fmt.Fprintf(stderr, format+"\n", args...)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	summary, err := b.Run(ctx)
+	if summary != nil {
+		fmt.Fprintf(stdout, "posts: %d, drafts: %d, queue: %d, submissions: %d, likes: %d, media: %d (%d bytes)\n",
+			summary.Posts, summary.Drafts, summary.Queue, summary.Submissions, summary.Likes, summary.Media, summary.MediaBytes)
+		for _, err := range summary.MediaErrors {
+			fmt.Fprintln(stderr, err)
+		}
+	}
+	if err == nil && summary != nil && len(summary.MediaErrors) != 0 {
+		err = fmt.Errorf("%d media could not be downloaded, run the backup again to retry", len(summary.MediaErrors))
+	}
+	return err
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/spbr/gotumblr"
+	"github.com/spbr/gotumblr/bulk"
+)
+
+// runBulk applies an action to the posts of a blog selected by flags, see bulk.Operation,
+// or undoes a previous run from its journal.
+func runBulk(profile string, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("gotumblr bulk", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	posts := fs.Bool("posts", false, "select among the published posts (the default without -drafts and -queue)")
+	drafts := fs.Bool("drafts", false, "select among the drafts")
+	queue := fs.Bool("queue", false, "select among the queued posts")
+	tag := fs.String("tag", "", "only select the posts with this tag")
+	postType := fs.String("type", "", "only select the posts of this type")
+	before := fs.String("before", "", "only select the posts published before this `date` (YYYY-MM-DD)")
+	after := fs.String("after", "", "only select the posts published on or after this `date` (YYYY-MM-DD)")
+	remove := fs.Bool("delete", false, "delete the posts")
+	addTags := fs.String("add-tags", "", "add the comma separated `tags` to the posts")
+	removeTags := fs.String("remove-tags", "", "remove the comma separated `tags` from the posts")
+	replaceTag := fs.String("replace-tag", "", "replace a tag of the posts by another, as `old=new`")
+	state := fs.String("state", "", "set the state of the posts: published, draft, queue or private")
+	dryRun := fs.Bool("dry-run", false, "only print the changes")
+	journal := fs.String("journal", "", "append the changes to the undo journal at `path`")
+	undo := fs.String("undo", "", "undo the changes of the journal at `path` instead")
+	concurrency := fs.Int("concurrency", 4, "number of posts changed at once")
+	rate := fs.Float64("rate", 5, "maximum number of requests per second, 0 for no limit")
+	quiet := fs.Bool("q", false, "do not report the changes")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gotumblr bulk [flags] <blog>")
+		fmt.Fprintln(stderr, "delete, retag or change the state of the posts of a blog selected by the flags")
+		fmt.Fprintln(stderr, "\nflags:")
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errors.New("bulk expects the argument <blog>")
+	}
+	blog, err := gotumblr.ParseBlogIdentifier(positional[0])
+	if err != nil {
+		return err
+	}
+
+	var actions []bulk.Action
+	if *remove {
+		actions = append(actions, bulk.Delete())
+	}
+	if *addTags != "" {
+		actions = append(actions, bulk.AddTags(splitTags(*addTags)...))
+	}
+	if *removeTags != "" {
+		actions = append(actions, bulk.RemoveTags(splitTags(*removeTags)...))
+	}
+	if *replaceTag != "" {
+		parts := strings.SplitN(*replaceTag, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return fmt.Errorf("bulk: -replace-tag expects old=new, got %q", *replaceTag)
+		}
+		actions = append(actions, bulk.ReplaceTag(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])))
+	}
+	if *state != "" {
+		actions = append(actions, bulk.SetState(*state))
+	}
+	if *undo == "" && len(actions) != 1 {
+		return errors.New("bulk expects one of -delete, -add-tags, -remove-tags, -replace-tag, -state or -undo")
+	}
+	if *undo != "" && len(actions) != 0 {
+		return errors.New("bulk: -undo cannot be combined with an action")
+	}
+
+	filters := []bulk.Filter{bulk.All()}
+	if *tag != "" {
+		filters = append(filters, bulk.HasTag(*tag))
+	}
+	if *postType != "" {
+		filters = append(filters, bulk.OfType(*postType))
+	}
+	for _, bound := range []struct {
+		value  string
+		filter func(time.Time) bulk.Filter
+	}{
+		{*before, bulk.Before}, {*after, bulk.After},
+	} {
+		if bound.value == "" {
+			continue
+		}
+		t, err := time.ParseInLocation("2006-01-02", bound.value, time.Local)
+		if err != nil {
+			return fmt.Errorf("bulk: invalid date %q", bound.value)
+		}
+		filters = append(filters, bound.filter(t))
+	}
+
+	client, err := gotumblr.NewClientFromProfile(profile)
+	if err != nil {
+		return err
+	}
+	if *rate > 0 {
+		client.SetRateLimiter(gotumblr.NewTokenBucket(*rate, 1))
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *undo != "" {
+		undone, err := bulk.Undo(ctx, client.WithContext(ctx), blog, *undo)
+		fmt.Fprintf(stdout, "undone: %d\n", undone)
+		if err != nil {
+			return fmt.Errorf("%v (run the undo again to resume)", err)
+		}
+		return nil
+	}
+
+	op := bulk.New(client, blog, bulk.And(filters...), actions[0])
+	// only the named sources are selected from, the published posts if none is
+	for _, source := range []struct {
+		name    string
+		enabled bool
+	}{
+		{bulk.Posts, *posts},
+		{bulk.Drafts, *drafts},
+		{bulk.Queue, *queue},
+	} {
+		if source.enabled {
+			op.Sources = append(op.Sources, source.name)
+		}
+	}
+	op.Concurrency = *concurrency
+	op.DryRun = *dryRun
+	op.Journal = *journal
+	if !*quiet || *dryRun {
+		op.Logf = func(format string, args ...interface{}) {
+			fmt.Fprintf(stderr, format+"\n", args...)
+		}
+	}
+
+	summary, err := op.Run(ctx)
+	if summary != nil {
+		verb := "changed"
+		if *dryRun {
+			verb = "would change"
+		}
+		fmt.Fprintf(stdout, "selected: %d, %s: %d, unchanged: %d, failed: %d\n",
+			summary.Selected, verb, summary.Changed, summary.Unchanged, summary.Failed)
+		for _, err := range summary.Errors {
+			fmt.Fprintln(stderr, err)
+		}
+	}
+	return err
+}
+
+// splitTags splits a comma separated list of tags.
+func splitTags(list string) []string {
+	var tags []string
+	for _, tag := range strings.Split(list, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
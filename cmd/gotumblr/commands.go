@@ -0,0 +1,369 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spbr/gotumblr"
+)
+
+// command is a subcommand of gotumblr calling a method of the client.
+type command struct {
+	args    string   // names of the positional arguments, separated by spaces
+	help    string   // one line description
+	options []string // options of the method, given as flags
+	columns []string // columns of the table output, all the scalar fields if empty
+	// run calls the method with the positional arguments and the options set by the flags.
+	run func(client *gotumblr.TumblrRestClient, args []string, options map[string]string) (interface{}, error)
+}
+
+var (
+	postColumns     = []string{"id", "type", "blog_name", "date", "note_count", "state", "post_url"}
+	blogColumns     = []string{"name", "title", "url", "updated"}
+	userColumns     = []string{"name", "following", "url", "updated"}
+	pageOptions     = []string{"limit", "offset"}
+	likesOptions    = []string{"limit", "offset", "before", "after"}
+	postOptions     = []string{"state", "publish_on", "tags", "tweet", "date", "format", "slug"}
+	contentOptions  = []string{"title", "body", "caption", "link", "source", "quote", "url", "description", "conversation", "external_url", "embed"}
+	editPostOptions = append(append([]string{"id"}, postOptions...), contentOptions...)
+)
+
+var commands = map[string]*command{
+	"info": {
+		help:    "show the user's information",
+		columns: []string{"name", "likes", "following", "default_post_format"},
+		run: func(client *gotumblr.TumblrRestClient, args []string, options map[string]string) (interface{}, error) {
+			response, err := client.Info()
+			if err != nil {
+				return nil, err
+			}
+			return listing{response, response.User}, nil
+		},
+	},
+	"likes": {
+		help:    "list the posts the user liked",
+		options: likesOptions,
+		columns: postColumns,
+		run: func(client *gotumblr.TumblrRestClient, args []string, options map[string]string) (interface{}, error) {
+			response, err := client.Likes(options)
+			if err != nil {
+				return nil, err
+			}
+			return listing{response, response.LikedPosts}, nil
+		},
+	},
+	"following": {
+		help:    "list the blogs the user follows",
+		options: pageOptions,
+		columns: blogColumns,
+		run: func(client *gotumblr.TumblrRestClient, args []string, options map[string]string) (interface{}, error) {
+			response, err := client.Following(options)
+			if err != nil {
+				return nil, err
+			}
+			return listing{response, response.Blogs}, nil
+		},
+	},
+	"dashboard": {
+		help:    "list the posts of the user's dashboard",
+		options: []string{"limit", "offset", "type", "since_id", "reblog_info", "notes_info"},
+		columns: postColumns,
+		run: func(client *gotumblr.TumblrRestClient, args []string, options map[string]string) (interface{}, error) {
+			response, err := client.Dashboard(options)
+			if err != nil {
+				return nil, err
+			}
+			return listing{response, response.Posts}, nil
+		},
+	},
+	"tagged": {
+		args:    "<tag>",
+		help:    "list the posts with a tag",
+		options: []string{"before", "limit", "filter"},
+		columns: postColumns,
+		run: func(client *gotumblr.TumblrRestClient, args []string, options map[string]string) (interface{}, error) {
+			posts, err := client.Tagged(args[0], options)
+			if err != nil {
+				return nil, err
+			}
+			return listing{posts, posts}, nil
+		},
+	},
+	"posts": {
+		args:    "<blog>",
+		help:    "list the posts of a blog",
+		options: []string{"type", "id", "tag", "limit", "offset", "reblog_info", "notes_info", "filter"},
+		columns: postColumns,
+		run: func(client *gotumblr.TumblrRestClient, args []string, options map[string]string) (interface{}, error) {
+			blog, err := gotumblr.ParseBlogIdentifier(args[0])
+			if err != nil {
+				return nil, err
+			}
+			postsType := options["type"]
+			delete(options, "type")
+			response, err := client.Posts(blog, postsType, options)
+			if err != nil {
+				return nil, err
+			}
+			return listing{response, response.Posts}, nil
+		},
+	},
+	"blog-info": {
+		args:    "<blog>",
+		help:    "show the information of a blog",
+		columns: []string{"name", "title", "posts", "likes", "url", "updated"},
+		run: func(client *gotumblr.TumblrRestClient, args []string, options map[string]string) (interface{}, error) {
+			blog, err := gotumblr.ParseBlogIdentifier(args[0])
+			if err != nil {
+				return nil, err
+			}
+			response, err := client.BlogInfo(blog)
+			if err != nil {
+				return nil, err
+			}
+			return listing{response, response.Blog}, nil
+		},
+	},
+	"avatar": {
+		args:    "<blog>",
+		help:    "show the avatar URL of a blog",
+		options: []string{"size"},
+		run: func(client *gotumblr.TumblrRestClient, args []string, options map[string]string) (interface{}, error) {
+			blog, err := gotumblr.ParseBlogIdentifier(args[0])
+			if err != nil {
+				return nil, err
+			}
+			size := 64
+			if options["size"] != "" {
+				if size, err = strconv.Atoi(options["size"]); err != nil {
+					return nil, fmt.Errorf("invalid size %q", options["size"])
+				}
+			}
+			return client.Avatar(blog, size)
+		},
+	},
+	"followers": {
+		args:    "<blog>",
+		help:    "list the followers of a blog",
+		options: pageOptions,
+		columns: userColumns,
+		run: func(client *gotumblr.TumblrRestClient, args []string, options map[string]string) (interface{}, error) {
+			blog, err := gotumblr.ParseBlogIdentifier(args[0])
+			if err != nil {
+				return nil, err
+			}
+			response, err := client.Followers(blog, options)
+			if err != nil {
+				return nil, err
+			}
+			return listing{response, response.Users}, nil
+		},
+	},
+	"blog-likes": {
+		args:    "<blog>",
+		help:    "list the posts a blog liked",
+		options: likesOptions,
+		columns: postColumns,
+		run: func(client *gotumblr.TumblrRestClient, args []string, options map[string]string) (interface{}, error) {
+			blog, err := gotumblr.ParseBlogIdentifier(args[0])
+			if err != nil {
+				return nil, err
+			}
+			response, err := client.BlogLikes(blog, options)
+			if err != nil {
+				return nil, err
+			}
+			return listing{response, response.LikedPosts}, nil
+		},
+	},
+	"queue": {
+		args:    "<blog>",
+		help:    "list the queued posts of a blog",
+		options: []string{"limit", "offset", "filter"},
+		columns: postColumns,
+		run:     blogPosts((*gotumblr.TumblrRestClient).Queue),
+	},
+	"drafts": {
+		args:    "<blog>",
+		help:    "list the draft posts of a blog",
+		options: []string{"before_id", "filter"},
+		columns: postColumns,
+		run:     blogPosts((*gotumblr.TumblrRestClient).Drafts),
+	},
+	"submissions": {
+		args:    "<blog>",
+		help:    "list the submitted posts of a blog",
+		options: []string{"offset", "filter"},
+		columns: postColumns,
+		run:     blogPosts((*gotumblr.TumblrRestClient).Submission),
+	},
+	"follow": {
+		args: "<blog>",
+		help: "follow a blog",
+		run:  blogAction((*gotumblr.TumblrRestClient).Follow),
+	},
+	"unfollow": {
+		args: "<blog>",
+		help: "unfollow a blog",
+		run:  blogAction((*gotumblr.TumblrRestClient).Unfollow),
+	},
+	"like": {
+		args: "<id> <reblog_key>",
+		help: "like a post",
+		run:  postAction((*gotumblr.TumblrRestClient).Like),
+	},
+	"unlike": {
+		args: "<id> <reblog_key>",
+		help: "unlike a post",
+		run:  postAction((*gotumblr.TumblrRestClient).Unlike),
+	},
+	"post create": {
+		args:    "<type> <blog>",
+		help:    "create a post of a type: text, photo, quote, link, chat, audio or video",
+		options: append(append([]string(nil), postOptions...), contentOptions...),
+		run: func(client *gotumblr.TumblrRestClient, args []string, options map[string]string) (interface{}, error) {
+			create, ok := map[string]func(*gotumblr.TumblrRestClient, gotumblr.BlogIdentifier, map[string]string) (bool, error){
+				"text":  (*gotumblr.TumblrRestClient).CreateText,
+				"photo": (*gotumblr.TumblrRestClient).CreatePhoto,
+				"quote": (*gotumblr.TumblrRestClient).CreateQuote,
+				"link":  (*gotumblr.TumblrRestClient).CreateLink,
+				"chat":  (*gotumblr.TumblrRestClient).CreateChatPost,
+				"audio": (*gotumblr.TumblrRestClient).CreateAudio,
+				"video": (*gotumblr.TumblrRestClient).CreateVideo,
+			}[args[0]]
+			if !ok {
+				return nil, fmt.Errorf("unknown post type %q", args[0])
+			}
+			return blogOptionsAction(create)(client, args[1:], options)
+		},
+	},
+	"post edit": {
+		args:    "<blog>",
+		help:    "edit a post, given with -id",
+		options: editPostOptions,
+		run:     blogOptionsAction((*gotumblr.TumblrRestClient).EditPost),
+	},
+	"post delete": {
+		args: "<blog> <id>",
+		help: "delete a post",
+		run: func(client *gotumblr.TumblrRestClient, args []string, options map[string]string) (interface{}, error) {
+			blog, err := gotumblr.ParseBlogIdentifier(args[0])
+			if err != nil {
+				return nil, err
+			}
+			id, err := gotumblr.ParsePostID(args[1])
+			if err != nil {
+				return nil, err
+			}
+			return done(client.DeletePost(blog, id))
+		},
+	},
+	"reblog": {
+		args:    "<blog>",
+		help:    "reblog a post, given with -id and -reblog_key, to a blog",
+		options: append([]string{"id", "reblog_key", "comment"}, postOptions...),
+		run:     blogOptionsAction((*gotumblr.TumblrRestClient).Reblog),
+	},
+}
+
+// optionHelp describes the options of the methods.
+var optionHelp = map[string]string{
+	"after":        "only posts liked after this Unix timestamp or RFC 3339 time",
+	"before":       "only posts before this Unix timestamp or RFC 3339 time",
+	"before_id":    "only drafts before this post id",
+	"body":         "body of a text post",
+	"caption":      "caption of a photo, audio or video post",
+	"comment":      "comment added to the reblog",
+	"conversation": "conversation of a chat post, one \"label: line\" per line",
+	"date":         "date of the post, as a GMT date or RFC 3339 time",
+	"description":  "description of a link post",
+	"embed":        "embed code of a video post",
+	"external_url": "URL of the audio file of an audio post",
+	"filter":       "format of the posts: html, text or raw",
+	"format":       "format of the post: html or markdown",
+	"id":           "post id",
+	"limit":        "number of results",
+	"link":         "click-through URL of a photo post",
+	"notes_info":   "include the notes of the posts (true or false)",
+	"offset":       "number of the first result",
+	"publish_on":   "publication date of a queued post, as a GMT date or RFC 3339 time",
+	"quote":        "text of a quote post",
+	"reblog_info":  "include the reblog information of the posts (true or false)",
+	"reblog_key":   "reblog key of the post",
+	"since_id":     "only posts after this post id",
+	"size":         "avatar size: 16, 24, 30, 40, 48, 64, 96, 128 or 512",
+	"slug":         "short text at the end of the post URL",
+	"source":       "source URL of a photo post, or source of a quote",
+	"state":        "state of the post: published, draft, queue or private",
+	"tag":          "only posts with this tag",
+	"tags":         "comma separated tags of the post",
+	"title":        "title of a text, link or chat post",
+	"tweet":        "text of the tweet, or off",
+	"type":         "only posts of this type: text, quote, link, answer, video, audio, photo or chat",
+	"url":          "URL of a link post",
+}
+
+// setOption sets an option, converting the RFC 3339 times of the time options to the format of the API.
+func setOption(options map[string]string, key, value string) {
+	switch key {
+	case "before", "after", "date", "publish_on":
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			gotumblr.SetTimeOption(options, key, t)
+			return
+		}
+	}
+	options[key] = value
+}
+
+// done returns the result of a successful action.
+func done(ok bool, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	return map[string]bool{"ok": ok}, nil
+}
+
+func blogPosts(method func(*gotumblr.TumblrRestClient, gotumblr.BlogIdentifier, map[string]string) (*gotumblr.DraftsResponse, error)) func(*gotumblr.TumblrRestClient, []string, map[string]string) (interface{}, error) {
+	return func(client *gotumblr.TumblrRestClient, args []string, options map[string]string) (interface{}, error) {
+		blog, err := gotumblr.ParseBlogIdentifier(args[0])
+		if err != nil {
+			return nil, err
+		}
+		response, err := method(client, blog, options)
+		if err != nil {
+			return nil, err
+		}
+		return listing{response, response.Posts}, nil
+	}
+}
+
+func blogAction(method func(*gotumblr.TumblrRestClient, gotumblr.BlogIdentifier) (bool, error)) func(*gotumblr.TumblrRestClient, []string, map[string]string) (interface{}, error) {
+	return func(client *gotumblr.TumblrRestClient, args []string, options map[string]string) (interface{}, error) {
+		blog, err := gotumblr.ParseBlogIdentifier(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return done(method(client, blog))
+	}
+}
+
+func blogOptionsAction(method func(*gotumblr.TumblrRestClient, gotumblr.BlogIdentifier, map[string]string) (bool, error)) func(*gotumblr.TumblrRestClient, []string, map[string]string) (interface{}, error) {
+	return func(client *gotumblr.TumblrRestClient, args []string, options map[string]string) (interface{}, error) {
+		blog, err := gotumblr.ParseBlogIdentifier(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return done(method(client, blog, options))
+	}
+}
+
+func postAction(method func(*gotumblr.TumblrRestClient, gotumblr.PostID, string) (bool, error)) func(*gotumblr.TumblrRestClient, []string, map[string]string) (interface{}, error) {
+	return func(client *gotumblr.TumblrRestClient, args []string, options map[string]string) (interface{}, error) {
+		id, err := gotumblr.ParsePostID(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return done(method(client, id, args[1]))
+	}
+}
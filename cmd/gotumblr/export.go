@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/spbr/gotumblr"
+	"github.com/spbr/gotumblr/markdown"
+)
+
+// runExport exports the posts of a blog to Markdown files, see markdown.Exporter.
+func runExport(profile string, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("gotumblr export", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	jekyll := fs.Bool("jekyll", false, "use the directories of Jekyll instead of those of Hugo")
+	postsDir := fs.String("posts-dir", "", "directory of the Markdown files, relative to <dir> (default content/posts, or _posts with -jekyll)")
+	mediaDir := fs.String("media-dir", "", "directory of the media, relative to <dir> (default static/media, or assets/media with -jekyll)")
+	mediaURL := fs.String("media-url", "", "URL the media directory is served at (default /media, or /assets/media with -jekyll)")
+	media := fs.Bool("media", true, "download the media and link to the downloaded files")
+	postType := fs.String("type", "", "only export the posts of this type")
+	tag := fs.String("tag", "", "only export the posts with this tag")
+	quiet := fs.Bool("q", false, "do not report the progress")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gotumblr export [flags] <blog> <dir>")
+		fmt.Fprintln(stderr, "export the posts of a blog to Markdown files with a YAML front matter, for Hugo or Jekyll")
+		fmt.Fprintln(stderr, "\nflags:")
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return errors.New("export expects the arguments <blog> <dir>")
+	}
+	blog, err := gotumblr.ParseBlogIdentifier(positional[0])
+	if err != nil {
+		return err
+	}
+	client, err := gotumblr.NewClientFromProfile(profile)
+	if err != nil {
+		return err
+	}
+
+	e := markdown.New(client, blog, positional[1])
+	if *jekyll {
+		e.PostsDir, e.MediaDir, e.MediaURL = "_posts", "assets/media", "/assets/media"
+	}
+	for _, override := range []struct{ field, value *string }{
+		{&e.PostsDir, postsDir}, {&e.MediaDir, mediaDir}, {&e.MediaURL, mediaURL},
+	} {
+		if *override.value != "" {
+			*override.field = *override.value
+		}
+	}
+	e.Media, e.Type = *media, *postType
+	if *tag != "" {
+		e.Options = map[string]string{"tag": *tag}
+	}
+	if !*quiet {
+		e.Logf = func(format string, args ...interface{}) {
+			fmt.Fprintf(stderr, format+"\n", args...)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	summary, err := e.Run(ctx)
+	if summary != nil {
+		fmt.Fprintf(stdout, "posts: %d, media: %d\n", summary.Posts, summary.Media)
+		for _, err := range summary.MediaErrors {
+			fmt.Fprintln(stderr, err)
+		}
+	}
+	return err
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spbr/gotumblr"
+)
+
+// login runs the OAuth authorization of gotumblr by the user and saves the credentials in a profile
+// of the configuration file, with the consumer key and secret given by the flags or the existing profile.
+func login(name string, args []string, stdout, stderr io.Writer) error {
+	path, err := gotumblr.DefaultConfigPath()
+	if err != nil {
+		return err
+	}
+	config, err := gotumblr.LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		name = os.Getenv(gotumblr.EnvProfile)
+	}
+	if name == "" {
+		name = config.DefaultProfile
+	}
+	if name == "" {
+		name = "default"
+	}
+	profile := config.Profiles[name]
+
+	fs := flag.NewFlagSet("gotumblr login", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&profile.ConsumerKey, "consumer-key", firstNonEmpty(os.Getenv(gotumblr.EnvConsumerKey), profile.ConsumerKey), "consumer `key` of the application")
+	fs.StringVar(&profile.ConsumerSecret, "consumer-secret", firstNonEmpty(os.Getenv(gotumblr.EnvConsumerSecret), profile.ConsumerSecret), "consumer `secret` of the application")
+	fs.StringVar(&profile.Host, "host", profile.Host, "`URL` of the API, "+gotumblr.DefaultHost+" if empty")
+	listen := fs.String("listen", "127.0.0.1:0", "`address` of the local server receiving the authorization")
+	timeout := fs.Duration("timeout", 5*time.Minute, "time to wait for the authorization")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: gotumblr [-profile name] login [flags]\n"+
+			"authorize gotumblr and save the credentials in the profile (%q) of %s\n\nflags:\n", name, path)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errors.New("login takes no arguments")
+	}
+	if profile.ConsumerKey == "" || profile.ConsumerSecret == "" {
+		return errors.New("login needs the consumer key and secret of the application, see -h")
+	}
+
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	callbackURL := "http://" + listener.Addr().String() + "/callback"
+	flow := gotumblr.NewOAuthFlow(profile.ConsumerKey, profile.ConsumerSecret, callbackURL)
+	authorizeURL, err := flow.AuthorizeURL()
+	if err != nil {
+		return err
+	}
+
+	type tokens struct {
+		token, secret string
+		err           error
+	}
+	results := make(chan tokens, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/callback" {
+			http.NotFound(w, r)
+			return
+		}
+		token, secret, err := flow.Callback(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "gotumblr is authorized, you can close this page.")
+		}
+		select {
+		case results <- tokens{token, secret, err}:
+		default:
+		}
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	fmt.Fprintf(stdout, "Open this page to authorize gotumblr:\n\n\t%s\n\n", authorizeURL)
+	var result tokens
+	select {
+	case result = <-results:
+	case <-time.After(*timeout):
+		return errors.New("timed out waiting for the authorization")
+	}
+	if result.err != nil {
+		return result.err
+	}
+	profile.Token, profile.TokenSecret = result.token, result.secret
+
+	config.Profiles[name] = profile
+	if config.DefaultProfile == "" {
+		config.DefaultProfile = name
+	}
+	if err := config.Save(path); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Saved the profile %q in %s\n", name, path)
+	if info, err := profile.NewClient().Info(); err == nil {
+		fmt.Fprintf(stdout, "Logged in as %s\n", info.User.Name)
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
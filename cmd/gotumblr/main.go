@@ -0,0 +1,207 @@
+// Command gotumblr calls the Tumblr API from the command line.
+//
+// Usage:
+//
+//	gotumblr [-profile name] [-output json|jsonl|table] <command> [flags] [arguments]
+//
+// The credentials are read from a profile of the configuration file (see gotumblr.LoadProfile),
+// which "gotumblr login" creates by running the OAuth authorization:
+//
+//	gotumblr -profile work login -consumer-key KEY -consumer-secret SECRET
+//
+// The environment variables TUMBLR_CONSUMER_KEY, TUMBLR_CONSUMER_SECRET, TUMBLR_TOKEN,
+// TUMBLR_TOKEN_SECRET and TUMBLR_HOST override the values of the profile.
+//
+// Every method of gotumblr.TumblrRestClient has a command; their options are given as flags
+// named after them, e.g.
+//
+//	gotumblr -output table posts -type photo -tag cats staff
+//	gotumblr post create text -title Hello -body "Hello world" -tags greeting,test myblog
+//	gotumblr like 72078164824 Fk1Xz2Pq
+//
+// Options without a flag can be given with -opt key=value. The before, after, date and publish_on
+// flags also accept RFC 3339 times. Run "gotumblr help" for the list of commands
+// and "gotumblr <command> -h" for their flags.
+//
+// Results are printed as indented JSON by default. With -output jsonl the posts, blogs or users
+// of a listing are printed as one JSON object per line, and with -output table as aligned columns.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spbr/gotumblr"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		message := err.Error()
+		if !strings.HasPrefix(message, "gotumblr: ") {
+			message = "gotumblr: " + message
+		}
+		fmt.Fprintln(os.Stderr, message)
+		os.Exit(2)
+	}
+}
+
+// run runs the command line args, printing the result to stdout and the usage to stderr.
+func run(args []string, stdout, stderr io.Writer) error {
+	format, profile := "json", ""
+	global := flag.NewFlagSet("gotumblr", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	global.StringVar(&profile, "profile", "", "`name` of the credentials profile, see the login command")
+	global.StringVar(&format, "output", format, "output `format`: json, jsonl or table")
+	global.Usage = func() { usage(global, stderr) }
+	if err := global.Parse(args); err != nil {
+		return err
+	}
+	args = global.Args()
+	if len(args) == 0 {
+		usage(global, stderr)
+		return errors.New("missing command")
+	}
+	if args[0] == "help" {
+		usage(global, stderr)
+		return nil
+	}
+
+	name := args[0]
+	args = args[1:]
+	switch name {
+	case "login":
+		return login(profile, args, stdout, stderr)
+	case "backup":
+		return runBackup(profile, args, stdout, stderr)
+	case "restore":
+		return runRestore(profile, args, stdout, stderr)
+	case "export":
+		return runExport(profile, args, stdout, stderr)
+	case "bulk":
+		return runBulk(profile, args, stdout, stderr)
+	case "rename-tag":
+		return runRenameTag(profile, args, stdout, stderr)
+	}
+	if name == "post" && len(args) > 0 {
+		name, args = "post "+args[0], args[1:]
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q, run \"gotumblr help\" for the list of commands", name)
+	}
+
+	fs := flag.NewFlagSet("gotumblr "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&format, "output", format, "output `format`: json, jsonl or table")
+	values := map[string]*string{}
+	for _, option := range cmd.options {
+		values[option] = fs.String(option, "", optionHelp[option])
+	}
+	var extra optionList
+	fs.Var(&extra, "opt", "additional option as `key=value` (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: gotumblr %s [flags] %s\n%s\n\nflags:\n", name, cmd.args, cmd.help)
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != len(strings.Fields(cmd.args)) {
+		if cmd.args == "" {
+			return fmt.Errorf("%s takes no arguments", name)
+		}
+		return fmt.Errorf("%s expects the arguments %s", name, cmd.args)
+	}
+	output, err := newOutput(format, stdout)
+	if err != nil {
+		return err
+	}
+
+	options := map[string]string{}
+	for option, value := range values {
+		if *value != "" {
+			setOption(options, option, *value)
+		}
+	}
+	for _, kv := range extra {
+		setOption(options, kv[0], kv[1])
+	}
+
+	client, err := gotumblr.NewClientFromProfile(profile)
+	if err != nil {
+		return err
+	}
+	result, err := cmd.run(client, positional, options)
+	if err != nil {
+		return err
+	}
+	return output.write(result, cmd.columns)
+}
+
+func usage(global *flag.FlagSet, w io.Writer) {
+	fmt.Fprintln(w, "usage: gotumblr [-profile name] [-output json|jsonl|table] <command> [flags] [arguments]")
+	fmt.Fprintln(w, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(w, "  %-32s %s\n", "backup <blog> <dir>", "back up the posts and media of a blog to a directory")
+	fmt.Fprintf(w, "  %-32s %s\n", "restore <dir> <blog>", "create the posts of a backup on a blog")
+	fmt.Fprintf(w, "  %-32s %s\n", "export <blog> <dir>", "export the posts of a blog to Markdown files for a static site")
+	fmt.Fprintf(w, "  %-32s %s\n", "bulk <blog>", "delete, retag or change the state of posts selected by tag, type or date")
+	fmt.Fprintf(w, "  %-32s %s\n", "rename-tag <blog> <old>... <new>", "rename or merge tags across the posts of a blog")
+	fmt.Fprintf(w, "  %-32s %s\n", "login", "authorize gotumblr and save the credentials in a profile")
+	for _, name := range names {
+		cmd := commands[name]
+		fmt.Fprintf(w, "  %-32s %s\n", strings.TrimSpace(name+" "+cmd.args), cmd.help)
+	}
+	fmt.Fprintln(w, "\nflags:")
+	global.PrintDefaults()
+}
+
+// parseInterspersed parses the flags of args, which can come before, between or after
+// the positional arguments, and returns the positional arguments. The arguments after "--"
+// are all positional, e.g. a tag starting with a dash.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		if parsed := len(args) - len(rest); parsed > 0 && args[parsed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// optionList collects the -opt flags.
+type optionList [][2]string
+
+func (l *optionList) String() string {
+	return ""
+}
+
+func (l *optionList) Set(s string) error {
+	i := strings.IndexByte(s, '=')
+	if i <= 0 {
+		return fmt.Errorf("option %q is not in the form key=value", s)
+	}
+	*l = append(*l, [2]string{s[:i], s[i+1:]})
+	return nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spbr/gotumblr"
+	"github.com/spbr/gotumblr/tumblrtest"
+)
+
+func TestParseInterspersed(t *testing.T) {
+	for _, test := range []struct {
+		args       []string
+		positional []string
+		tag, limit string
+	}{
+		{[]string{"-tag", "a", "-limit", "2", "staff"}, []string{"staff"}, "a", "2"},
+		{[]string{"staff", "-tag", "a", "other", "-limit=2"}, []string{"staff", "other"}, "a", "2"},
+		{[]string{"staff", "-tag", "a", "--", "-limit", "2"}, []string{"staff", "-limit", "2"}, "a", ""},
+		{[]string{"--", "-tag"}, []string{"-tag"}, "", ""},
+		{nil, nil, "", ""},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		tag, limit := fs.String("tag", "", ""), fs.String("limit", "", "")
+		positional, err := parseInterspersed(fs, test.args)
+		if err != nil {
+			t.Errorf("%q: %v", test.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(positional, test.positional) || *tag != test.tag || *limit != test.limit {
+			t.Errorf("%q: positional %q, -tag %q, -limit %q, want %q, %q, %q",
+				test.args, positional, *tag, *limit, test.positional, test.tag, test.limit)
+		}
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	if _, err := parseInterspersed(fs, []string{"staff", "-unknown"}); err == nil {
+		t.Error("an unknown flag after an argument was accepted")
+	}
+}
+
+func TestOptionList(t *testing.T) {
+	var list optionList
+	for _, s := range []string{"tag=a", "query=a=b", "empty="} {
+		if err := list.Set(s); err != nil {
+			t.Errorf("Set(%q): %v", s, err)
+		}
+	}
+	want := optionList{{"tag", "a"}, {"query", "a=b"}, {"empty", ""}}
+	if !reflect.DeepEqual(list, want) {
+		t.Errorf("options %q, want %q", list, want)
+	}
+	for _, s := range []string{"tag", "=a"} {
+		if err := list.Set(s); err == nil {
+			t.Errorf("Set(%q) succeeded", s)
+		}
+	}
+}
+
+func TestSetOption(t *testing.T) {
+	options := map[string]string{}
+	setOption(options, "before", "2024-02-20T17:00:00+01:00")
+	setOption(options, "date", "2024-02-20T17:00:00+01:00")
+	setOption(options, "after", "1708444800")
+	setOption(options, "tag", "2024-02-20T17:00:00+01:00")
+	want := map[string]string{
+		"before": "1708444800",
+		"date":   "2024-02-20 16:00:00 GMT",
+		"after":  "1708444800",
+		"tag":    "2024-02-20T17:00:00+01:00",
+	}
+	if !reflect.DeepEqual(options, want) {
+		t.Errorf("options %v, want %v", options, want)
+	}
+}
+
+// runServer runs the command line against a fake server with the credentials of the environment.
+func runServer(t *testing.T, server *tumblrtest.Server, args ...string) string {
+	t.Helper()
+	t.Setenv(gotumblr.EnvConfig, filepath.Join(t.TempDir(), "config.json"))
+	t.Setenv(gotumblr.EnvProfile, "")
+	t.Setenv(gotumblr.EnvConsumerKey, server.ConsumerKey)
+	t.Setenv(gotumblr.EnvConsumerSecret, server.ConsumerSecret)
+	t.Setenv(gotumblr.EnvToken, server.Token)
+	t.Setenv(gotumblr.EnvTokenSecret, server.TokenSecret)
+	t.Setenv(gotumblr.EnvHost, server.URL)
+	var stdout, stderr bytes.Buffer
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("gotumblr %s: %v\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return stdout.String()
+}
+
+func TestRunOutput(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	for i, tag := range []string{"a", "b", "a"} {
+		server.AddPost("tester", map[string]interface{}{"type": "text", "title": "post " + tag, "body": "x",
+			"tags": []string{tag}, "timestamp": int64(1708444800 + i)})
+	}
+
+	// the flags and -opt can come before or after the arguments
+	out := runServer(t, server, "-output", "jsonl", "posts", "tester", "-opt", "tag=a", "-limit", "5")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("jsonl output %q, want the 2 posts tagged a", out)
+	}
+	for _, line := range lines {
+		var post map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &post); err != nil || post["title"] != "post a" {
+			t.Errorf("jsonl line %s, want a post tagged a (%v)", line, err)
+		}
+	}
+
+	out = runServer(t, server, "posts", "-output", "table", "-limit", "1", "tester")
+	lines = strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "ID") || !strings.Contains(lines[0], "BLOG_NAME") ||
+		!strings.Contains(lines[1], "text") || !strings.Contains(lines[1], "tester") {
+		t.Errorf("table output %q, want a header and a post", out)
+	}
+
+	out = runServer(t, server, "blog-info", "tester")
+	var info struct {
+		Blog struct{ Name string }
+	}
+	if err := json.Unmarshal([]byte(out), &info); err != nil || info.Blog.Name != "tester" || !strings.Contains(out, "\n  ") {
+		t.Errorf("json output %q, want the indented blog information (%v)", out, err)
+	}
+
+	runServer(t, server, "post", "create", "text", "-title", "new", "-body", "hello", "-tags", "c,d", "tester")
+	var created map[string]interface{}
+	for _, post := range server.BlogPosts("tester") {
+		if post["title"] == "new" {
+			created = post
+		}
+	}
+	if created == nil || created["body"] != "hello" || !reflect.DeepEqual(created["tags"], []string{"c", "d"}) {
+		t.Errorf("created post %v, want the title, body and tags of the flags", created)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	for _, args := range [][]string{
+		{},
+		{"unknown"},
+		{"posts"},
+		{"posts", "a", "b"},
+		{"-output", "xml", "info"},
+		{"posts", "-opt", "novalue", "staff"},
+	} {
+		if err := run(args, &stdout, &stderr); err == nil {
+			t.Errorf("gotumblr %q succeeded", args)
+		}
+	}
+}
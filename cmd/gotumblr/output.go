@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// listing is the result of a command with the items (posts, blogs, users, ...)
+// printed by the jsonl and table formats, or the single item they print.
+type listing struct {
+	result interface{}
+	items  interface{}
+}
+
+// output prints the results of the commands in a format.
+type output struct {
+	format string
+	w      io.Writer
+}
+
+func newOutput(format string, w io.Writer) (*output, error) {
+	switch format {
+	case "json", "jsonl", "table":
+		return &output{format, w}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q, use json, jsonl or table", format)
+}
+
+// write prints a result. columns are the columns of the table format, all scalar fields if empty.
+func (o *output) write(result interface{}, columns []string) error {
+	items := []interface{}{result}
+	if l, ok := result.(listing); ok {
+		result = l.result
+		items = toSlice(l.items)
+	}
+	switch o.format {
+	case "jsonl":
+		encoder := json.NewEncoder(o.w)
+		for _, item := range items {
+			if err := encoder.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "table":
+		return o.writeTable(items, columns)
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(o.w, "%s\n", data)
+	return err
+}
+
+// writeTable prints items as aligned columns, with a header line.
+func (o *output) writeTable(items []interface{}, columns []string) error {
+	rows := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		row, err := toMap(item)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, row)
+	}
+	if len(columns) == 0 {
+		columns = scalarKeys(rows)
+	}
+	tw := tabwriter.NewWriter(o.w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(columns, "\t")))
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			cells[i] = cell(lookup(row, column))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// toSlice returns the elements of a slice, or v alone if it is not a slice.
+func toSlice(v interface{}) []interface{} {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Slice {
+		return []interface{}{v}
+	}
+	items := make([]interface{}, value.Len())
+	for i := range items {
+		items[i] = value.Index(i).Interface()
+	}
+	return items
+}
+
+// toMap returns the fields of the JSON encoding of v, keeping numbers exact.
+func toMap(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var m map[string]interface{}
+	if err := decoder.Decode(&m); err != nil {
+		return nil, fmt.Errorf("cannot print %s as a table", data)
+	}
+	return m, nil
+}
+
+// lookup returns the field of a row named column, ignoring case and underscores,
+// as the fields of the responses are named either like blog_name or like BlogName.
+func lookup(row map[string]interface{}, column string) interface{} {
+	if value, ok := row[column]; ok {
+		return value
+	}
+	want := normalizeKey(column)
+	for key, value := range row {
+		if normalizeKey(key) == want {
+			return value
+		}
+	}
+	return nil
+}
+
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.Replace(key, "_", "", -1))
+}
+
+// scalarKeys returns the sorted names of the scalar fields of the rows.
+func scalarKeys(rows []map[string]interface{}) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, row := range rows {
+		for key, value := range row {
+			switch value.(type) {
+			case map[string]interface{}, []interface{}:
+				continue
+			}
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// cell formats a value for a table: scalars as is, lists of scalars joined by commas.
+func cell(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.Join(strings.Fields(value), " ")
+	case []interface{}:
+		parts := make([]string, 0, len(value))
+		for _, element := range value {
+			parts = append(parts, cell(element))
+		}
+		return strings.Join(parts, ",")
+	case map[string]interface{}:
+		return "{...}"
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/spbr/gotumblr"
+	"github.com/spbr/gotumblr/bulk"
+)
+
+// runRenameTag renames or merges tags across the posts of a blog, see bulk.TagRename.
+func runRenameTag(profile string, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("gotumblr rename-tag", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dryRun := fs.Bool("dry-run", false, "only print the changes")
+	journal := fs.String("journal", "", "append the changes to the undo journal at `path`, see bulk -undo")
+	concurrency := fs.Int("concurrency", 4, "number of posts changed at once")
+	rate := fs.Float64("rate", 5, "maximum number of requests per second, 0 for no limit")
+	quiet := fs.Bool("q", false, "do not report the progress")
+	state := fs.String("state", "", "keep the progress of a stopped search in the file at `path`, next to the journal\n"+
+		"or in the user's cache directory by default")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gotumblr rename-tag [flags] <blog> <old>... <new>")
+		fmt.Fprintln(stderr, "rename a tag, or merge several tags into one, across the posts of a blog, ignoring case")
+		fmt.Fprintln(stderr, "\nflags:")
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 3 {
+		return errors.New("rename-tag expects the arguments <blog> <old>... <new>")
+	}
+	blog, err := gotumblr.ParseBlogIdentifier(positional[0])
+	if err != nil {
+		return err
+	}
+	client, err := gotumblr.NewClientFromProfile(profile)
+	if err != nil {
+		return err
+	}
+	if *rate > 0 {
+		client.SetRateLimiter(gotumblr.NewTokenBucket(*rate, 1))
+	}
+
+	r := bulk.NewTagRename(client, blog, positional[len(positional)-1], positional[1:len(positional)-1]...)
+	r.Concurrency = *concurrency
+	r.DryRun = *dryRun
+	r.Journal = *journal
+	if r.State, err = statePath(*state, *journal, blog); err != nil {
+		return err
+	}
+	if !*quiet || *dryRun {
+		r.Logf = func(format string, args ...interface{}) {
+			fmt.Fprintf(stderr, format+"\n", args...)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	summary, err := r.Run(ctx)
+	if summary != nil {
+		verb := "renamed"
+		if *dryRun {
+			verb = "would rename"
+		}
+		fmt.Fprintf(stdout, "found: %d, %s: %d, unchanged: %d, failed: %d\n",
+			summary.Selected, verb, summary.Changed, summary.Unchanged, summary.Failed)
+		for _, err := range summary.Errors {
+			fmt.Fprintln(stderr, err)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%v (run rename-tag again to resume)", err)
+	}
+	return nil
+}
+
+// statePath returns the path of the file keeping the progress of a stopped search of rename-tag:
+// the path given with -state, else the path of the journal with a .search suffix,
+// else a file named after the blog in the user's cache directory.
+func statePath(state, journal string, blog gotumblr.BlogIdentifier) (string, error) {
+	switch {
+	case state != "":
+		return state, nil
+	case journal != "":
+		return journal + ".search", nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "gotumblr")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "rename-tag-"+blog.Hostname()+".json"), nil
+}
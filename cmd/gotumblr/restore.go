@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/spbr/gotumblr"
+	"github.com/spbr/gotumblr/backup"
+)
+
+// runRestore creates the posts of a backup on a blog, see backup.Restore.
+func runRestore(profile string, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("gotumblr restore", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	posts := fs.Bool("posts", true, "restore the published posts")
+	drafts := fs.Bool("drafts", true, "restore the drafts")
+	queue := fs.Bool("queue", true, "restore the queued posts")
+	submissions := fs.Bool("submissions", false, "restore the submissions, as drafts")
+	media := fs.Bool("media", true, "upload the photos, videos and audio files of the backup")
+	quiet := fs.Bool("q", false, "do not report the progress")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gotumblr restore [flags] <dir> <blog>")
+		fmt.Fprintln(stderr, "create the posts of a backup on a blog, skipping those created by a previous run")
+		fmt.Fprintln(stderr, "\nflags:")
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return errors.New("restore expects the arguments <dir> <blog>")
+	}
+	blog, err := gotumblr.ParseBlogIdentifier(positional[1])
+	if err != nil {
+		return err
+	}
+	client, err := gotumblr.NewClientFromProfile(profile)
+	if err != nil {
+		return err
+	}
+
+	r := backup.NewRestore(client, positional[0], blog)
+	for _, kind := range []struct {
+		name    string
+		enabled bool
+	}{
+		{backup.Posts, *posts},
+		{backup.Drafts, *drafts},
+		{backup.Queue, *queue},
+		{backup.Submissions, *submissions},
+	} {
+		if kind.enabled {
+			r.Kinds = append(r.Kinds, kind.name)
+		}
+	}
+	if len(r.Kinds) == 0 {
+		return errors.New("restore has nothing to restore")
+	}
+	r.Media = *media
+	if !*quiet {
+		r.Logf = func(format string, args ...interface{}) {
+			fmt.Fprintf(stderr, format+"\n", args...)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	summary, err := r.Run(ctx)
+	if summary != nil {
+		fmt.Fprintf(stdout, "created: %d, already restored: %d, skipped: %d\n",
+			summary.Created, summary.Skipped, len(summary.Unsupported))
+	}
+	if err != nil {
+		return fmt.Errorf("%v (run the restore again to resume)", err)
+	}
+	return nil
+}
@@ -1,9 +1,17 @@
 package gotumblr
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"net/http"
+)
 
 // CompleteResponse holds the entire response from the Tumblr API
 type CompleteResponse struct {
 	Meta     MetaInfo
 	Response json.RawMessage
+
+	// StatusCode and Header are the HTTP status code and headers of the response.
+	// They are not set for responses parsed with JSONParse.
+	StatusCode int         `json:"-"`
+	Header     http.Header `json:"-"`
 }
@@ -0,0 +1,83 @@
+package gotumblr
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Post is implemented by all post types (*TextPost, *PhotoPost, ...),
+// giving access to the information common to all of them.
+type Post interface {
+	Base() *BasePost
+}
+
+// Base returns the information common to all posts.
+func (p *BasePost) Base() *BasePost {
+	return p
+}
+
+// DecodePost decodes a post returned by the API (e.g. an element of PostsResponse.Posts)
+// into the post type matching its type field: *TextPost, *PhotoPost, *QuotePost, *LinkPost,
+// *ChatPost, *AudioPost, *VideoPost or *AnswerPost. Posts of unknown types are decoded into a *BasePost.
+// The fields missing from the post are not reported, even in strict mode: use TumblrRestClient.DecodePost.
+func DecodePost(data json.RawMessage) (Post, error) {
+	return decodePost(data, false)
+}
+
+// decodePost decodes a post like DecodePost. In strict mode, the fields of the post type that are
+// missing from a post of a known type are reported as a *DecodeError (see checkFields). Posts of
+// unknown types are decoded into a *BasePost without checking their fields.
+func decodePost(data json.RawMessage, strict bool) (Post, error) {
+	var base BasePost
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, &DecodeError{data, err}
+	}
+	var post Post
+	switch base.PostType {
+	case "text":
+		post = new(TextPost)
+	case "photo":
+		post = new(PhotoPost)
+	case "quote":
+		post = new(QuotePost)
+	case "link":
+		post = new(LinkPost)
+	case "chat":
+		post = new(ChatPost)
+	case "audio":
+		post = new(AudioPost)
+	case "video":
+		post = new(VideoPost)
+	case "answer":
+		post = new(AnswerPost)
+	default:
+		return &base, nil
+	}
+	if err := json.Unmarshal(data, post); err != nil {
+		return nil, &DecodeError{data, fmt.Errorf("%s post: %w", base.PostType, err)}
+	}
+	if strict {
+		if err := checkFields(data, reflect.TypeOf(post), ""); err != nil {
+			return nil, &DecodeError{data, fmt.Errorf("%s post: %w", base.PostType, err)}
+		}
+	}
+	return post, nil
+}
+
+// DecodePosts decodes a list of posts with DecodePost.
+func DecodePosts(data []json.RawMessage) ([]Post, error) {
+	return decodePosts(data, false)
+}
+
+func decodePosts(data []json.RawMessage, strict bool) ([]Post, error) {
+	posts := make([]Post, 0, len(data))
+	for _, raw := range data {
+		post, err := decodePost(raw, strict)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+	return posts, nil
+}
@@ -0,0 +1,805 @@
+package gotumblr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The fixtures of testdata/posts are posts in the legacy format of /v2/blog/{blog}/posts, one per type,
+// with all the fields of the responses. Run the tests with GOTUMBLR_CAPTURE_BLOG set to a blog to replace
+// them with the latest posts of that blog, fetched with the default profile (see TestCaptureFixtures).
+
+func readFixture(t *testing.T, name string) json.RawMessage {
+	t.Helper()
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "posts", name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+var trailTheme = map[string]interface{}{
+	"header_full_width":    3000.0,
+	"header_full_height":   1055.0,
+	"header_focus_width":   3000.0,
+	"header_focus_height":  1055.0,
+	"avatar_shape":         "square",
+	"background_color":     "#001935",
+	"body_font":            "Favorit",
+	"header_bounds":        "0,3000,1055,0",
+	"header_image":         "https://static.tumblr.com/3f0e0f1c4b2d1d9e/lmmgbvvy8/Yi4s6nnh2/tumblr_static_header.png",
+	"header_image_focused": "https://static.tumblr.com/3f0e0f1c4b2d1d9e/lmmgbvvy8/Yi4s6nnh2/tumblr_static_header_focused_v3.png",
+	"header_image_poster":  "",
+	"header_image_scaled":  "https://static.tumblr.com/3f0e0f1c4b2d1d9e/lmmgbvvy8/Yi4s6nnh2/tumblr_static_header_2048_v2.png",
+	"header_stretch":       true,
+	"link_color":           "#529ECC",
+	"show_avatar":          true,
+	"show_description":     true,
+	"show_header_image":    true,
+	"show_title":           true,
+	"title_color":          "#FFFFFF",
+	"title_font":           "Gibson",
+	"title_font_weight":    "bold",
+}
+
+var fixturePosts = []struct {
+	name string
+	want Post
+}{
+	{"text", &TextPost{
+		BasePost: BasePost{
+			PostType: "text",
+			BlogName: "staff",
+			Blog: PostBlog{
+				Name:        "staff",
+				Title:       "Tumblr Staff",
+				Description: "<p>Official news from Tumblr HQ.</p>",
+				URL:         "https://staff.tumblr.com/",
+				UUID:        "t:0aY0xL4Fi1OFJg_0HZOLVA",
+				Updated:     1709312456,
+			},
+			ID:                      740962346784243712,
+			IDString:                "740962346784243712",
+			PostURL:                 "https://staff.tumblr.com/post/740962346784243712/reply-controls-are-here",
+			Slug:                    "reply-controls-are-here",
+			Date:                    "2024-01-30 19:03:12 GMT",
+			Timestamp:               1706641392,
+			State:                   "published",
+			Format:                  "html",
+			ReblogKey:               "u6xTmJkO",
+			Tags:                    []string{"changes", "replies", "tumblr"},
+			ShortURL:                "https://tmblr.co/ZE5Fbye9Y8Ai0",
+			Summary:                 "Reply controls are here",
+			ShouldOpenInLegacy:      true,
+			ParentPostURL:           "https://changes.tumblr.com/post/740956893476061184/reply-controls",
+			RebloggedFromID:         740956893476061184,
+			RebloggedFromURL:        "https://changes.tumblr.com/post/740956893476061184/reply-controls",
+			RebloggedFromName:       "changes",
+			RebloggedFromTitle:      "Changes",
+			RebloggedFromUUID:       "t:Y8X3m3QkG1rGvFh1Qm2RQw",
+			RebloggedFromCanMessage: true,
+			RebloggedFromFollowing:  true,
+			RebloggedRootID:         740956893476061184,
+			RebloggedRootURL:        "https://changes.tumblr.com/post/740956893476061184/reply-controls",
+			RebloggedRootName:       "changes",
+			RebloggedRootTitle:      "Changes",
+			RebloggedRootUUID:       "t:Y8X3m3QkG1rGvFh1Qm2RQw",
+			RebloggedRootCanMessage: true,
+			RebloggedRootFollowing:  true,
+			Followed:                true,
+			NoteCount:               2917,
+			Reblog: ReblogInfo{
+				Comment:  "<p>We heard you. Reply controls are live for everyone.</p>",
+				TreeHTML: `<p><a href="https://changes.tumblr.com/post/740956893476061184/reply-controls" class="tumblr_blog">changes</a>:</p><blockquote><p>Starting today you can choose who replies to your posts: everyone, the blogs you follow, or the blogs that follow you for a week.</p></blockquote>`,
+			},
+			Trail: []TrailItem{
+				{
+					Blog: TrailBlog{
+						Name:          "changes",
+						Active:        true,
+						Theme:         trailTheme,
+						CanBeFollowed: true,
+					},
+					Post: TrailPost{
+						ID: 740956893476061184,
+					},
+					ContentRaw: "<p>Starting today you can choose who replies to your posts: everyone, the blogs you follow, or the blogs that follow you for a week.</p>",
+					Content:    "<p>Starting today you can choose who replies to your posts: everyone, the blogs you follow, or the blogs that follow you for a week.</p>",
+					IsRootItem: true,
+				},
+				{
+					Blog: TrailBlog{
+						Name:          "staff",
+						Active:        true,
+						Theme:         trailTheme,
+						CanBeFollowed: true,
+					},
+					Post: TrailPost{
+						ID: 740962346784243712,
+					},
+					ContentRaw:    "<p>We heard you. Reply controls are live for everyone.</p>",
+					Content:       "<p>We heard you. Reply controls are live for everyone.</p>",
+					IsCurrentItem: true,
+				},
+			},
+			CanLike:               true,
+			InteractabilityReblog: "everyone",
+			InteractabilityBlaze:  "everyone",
+			CanReblog:             true,
+			CanSendInMessage:      true,
+			CanReply:              true,
+			DisplayAvatar:         true,
+		},
+		Title: "Reply controls are here",
+		Body:  `<p><a href="https://changes.tumblr.com/post/740956893476061184/reply-controls" class="tumblr_blog">changes</a>:</p><blockquote><p>Starting today you can choose who replies to your posts: everyone, the blogs you follow, or the blogs that follow you for a week.</p></blockquote><p>We heard you. Reply controls are live for everyone.</p>`,
+	}},
+	{"photo", &PhotoPost{
+		BasePost: BasePost{
+			PostType: "photo",
+			BlogName: "staff",
+			Blog: PostBlog{
+				Name:        "staff",
+				Title:       "Tumblr Staff",
+				Description: "<p>Official news from Tumblr HQ.</p>",
+				URL:         "https://staff.tumblr.com/",
+				UUID:        "t:0aY0xL4Fi1OFJg_0HZOLVA",
+				Updated:     1709312456,
+			},
+			ID:                    741598322147098624,
+			IDString:              "741598322147098624",
+			PostURL:               "https://staff.tumblr.com/post/741598322147098624/desk-setups",
+			Slug:                  "desk-setups",
+			Date:                  "2024-02-06 19:31:05 GMT",
+			Timestamp:             1707247865,
+			State:                 "published",
+			Format:                "html",
+			ReblogKey:             "9QnwWbPt",
+			Tags:                  []string{"desks", "workspaces", "community"},
+			ShortURL:              "https://tmblr.co/ZE5FbyeAeCLm0",
+			Summary:               "Show us your desks! We’ll reblog our favorites.",
+			ShouldOpenInLegacy:    true,
+			SourceURL:             "https://www.flickr.com/photos/tumblr/53512345678/",
+			SourceTitle:           "flickr.com",
+			Followed:              true,
+			NoteCount:             864,
+			Reblog:                ReblogInfo{},
+			Trail:                 []TrailItem{},
+			CanLike:               true,
+			InteractabilityReblog: "everyone",
+			InteractabilityBlaze:  "everyone",
+			CanReblog:             true,
+			CanSendInMessage:      true,
+			DisplayAvatar:         true,
+		},
+		Caption:        "<p>Show us your desks! We’ll reblog our favorites.</p>",
+		ImagePermalink: "https://staff.tumblr.com/image/741598322147098624",
+		PhotosetLayout: "11",
+		Photos: []PhotoObject{
+			{
+				Caption: "Standing desk, plants optional",
+				OriginalSize: AltSize{
+					URL:    "https://64.media.tumblr.com/8d1a9c2e5b3f4a7c06e1d2b9f4c8a3e7/2c0f7f3b16a84d1e-5a/s2048x3072/a4d1b6c2e9f0873512c4e6d8b0a2f4c6e8d0b2a4.jpg",
+					Width:  2048,
+					Height: 1536,
+				},
+				AltSizes: []AltSize{
+					{
+						URL:    "https://64.media.tumblr.com/8d1a9c2e5b3f4a7c06e1d2b9f4c8a3e7/2c0f7f3b16a84d1e-5a/s2048x3072/a4d1b6c2e9f0873512c4e6d8b0a2f4c6e8d0b2a4.jpg",
+						Width:  2048,
+						Height: 1536,
+					},
+					{
+						URL:    "https://64.media.tumblr.com/8d1a9c2e5b3f4a7c06e1d2b9f4c8a3e7/2c0f7f3b16a84d1e-5a/s1280x1920/a4d1b6c2e9f0873512c4e6d8b0a2f4c6e8d0b2a4.jpg",
+						Width:  1280,
+						Height: 960,
+					},
+					{
+						URL:    "https://64.media.tumblr.com/8d1a9c2e5b3f4a7c06e1d2b9f4c8a3e7/2c0f7f3b16a84d1e-5a/s640x960/a4d1b6c2e9f0873512c4e6d8b0a2f4c6e8d0b2a4.jpg",
+						Width:  640,
+						Height: 480,
+					},
+					{
+						URL:    "https://64.media.tumblr.com/8d1a9c2e5b3f4a7c06e1d2b9f4c8a3e7/2c0f7f3b16a84d1e-5a/s540x810/a4d1b6c2e9f0873512c4e6d8b0a2f4c6e8d0b2a4.jpg",
+						Width:  540,
+						Height: 405,
+					},
+					{
+						URL:    "https://64.media.tumblr.com/8d1a9c2e5b3f4a7c06e1d2b9f4c8a3e7/2c0f7f3b16a84d1e-5a/s500x750/a4d1b6c2e9f0873512c4e6d8b0a2f4c6e8d0b2a4.jpg",
+						Width:  500,
+						Height: 375,
+					},
+					{
+						URL:    "https://64.media.tumblr.com/8d1a9c2e5b3f4a7c06e1d2b9f4c8a3e7/2c0f7f3b16a84d1e-5a/s400x600/a4d1b6c2e9f0873512c4e6d8b0a2f4c6e8d0b2a4.jpg",
+						Width:  400,
+						Height: 300,
+					},
+					{
+						URL:    "https://64.media.tumblr.com/8d1a9c2e5b3f4a7c06e1d2b9f4c8a3e7/2c0f7f3b16a84d1e-5a/s250x375/a4d1b6c2e9f0873512c4e6d8b0a2f4c6e8d0b2a4.jpg",
+						Width:  250,
+						Height: 187,
+					},
+					{
+						URL:    "https://64.media.tumblr.com/8d1a9c2e5b3f4a7c06e1d2b9f4c8a3e7/2c0f7f3b16a84d1e-5a/s100x150/a4d1b6c2e9f0873512c4e6d8b0a2f4c6e8d0b2a4.jpg",
+						Width:  100,
+						Height: 75,
+					},
+					{
+						URL:    "https://64.media.tumblr.com/8d1a9c2e5b3f4a7c06e1d2b9f4c8a3e7/2c0f7f3b16a84d1e-5a/s75x75_c1/a4d1b6c2e9f0873512c4e6d8b0a2f4c6e8d0b2a4.jpg",
+						Width:  75,
+						Height: 75,
+					},
+				},
+				Exif: map[string]interface{}{
+					"Camera":      "FUJIFILM X-T4",
+					"ISO":         640.0,
+					"Aperture":    "f/2.8",
+					"Exposure":    "1/60th",
+					"FocalLength": "23mm",
+				},
+			},
+			{
+				OriginalSize: AltSize{
+					URL:    "https://64.media.tumblr.com/f03b7e9a12c4d6e8a0b2c4d6e8f0a2b4/2c0f7f3b16a84d1e-7e/s2048x3072/0c9e1b3d5f7a9c1e3b5d7f9a1c3e5b7d9f1a3c5e.jpg",
+					Width:  1536,
+					Height: 2048,
+				},
+				AltSizes: []AltSize{
+					{
+						URL:    "https://64.media.tumblr.com/f03b7e9a12c4d6e8a0b2c4d6e8f0a2b4/2c0f7f3b16a84d1e-7e/s2048x3072/0c9e1b3d5f7a9c1e3b5d7f9a1c3e5b7d9f1a3c5e.jpg",
+						Width:  1536,
+						Height: 2048,
+					},
+					{
+						URL:    "https://64.media.tumblr.com/f03b7e9a12c4d6e8a0b2c4d6e8f0a2b4/2c0f7f3b16a84d1e-7e/s1280x1920/0c9e1b3d5f7a9c1e3b5d7f9a1c3e5b7d9f1a3c5e.jpg",
+						Width:  1280,
+						Height: 1706,
+					},
+					{
+						URL:    "https://64.media.tumblr.com/f03b7e9a12c4d6e8a0b2c4d6e8f0a2b4/2c0f7f3b16a84d1e-7e/s640x960/0c9e1b3d5f7a9c1e3b5d7f9a1c3e5b7d9f1a3c5e.jpg",
+						Width:  640,
+						Height: 853,
+					},
+					{
+						URL:    "https://64.media.tumblr.com/f03b7e9a12c4d6e8a0b2c4d6e8f0a2b4/2c0f7f3b16a84d1e-7e/s540x810/0c9e1b3d5f7a9c1e3b5d7f9a1c3e5b7d9f1a3c5e.jpg",
+						Width:  540,
+						Height: 720,
+					},
+					{
+						URL:    "https://64.media.tumblr.com/f03b7e9a12c4d6e8a0b2c4d6e8f0a2b4/2c0f7f3b16a84d1e-7e/s500x750/0c9e1b3d5f7a9c1e3b5d7f9a1c3e5b7d9f1a3c5e.jpg",
+						Width:  500,
+						Height: 666,
+					},
+					{
+						URL:    "https://64.media.tumblr.com/f03b7e9a12c4d6e8a0b2c4d6e8f0a2b4/2c0f7f3b16a84d1e-7e/s400x600/0c9e1b3d5f7a9c1e3b5d7f9a1c3e5b7d9f1a3c5e.jpg",
+						Width:  400,
+						Height: 533,
+					},
+					{
+						URL:    "https://64.media.tumblr.com/f03b7e9a12c4d6e8a0b2c4d6e8f0a2b4/2c0f7f3b16a84d1e-7e/s250x375/0c9e1b3d5f7a9c1e3b5d7f9a1c3e5b7d9f1a3c5e.jpg",
+						Width:  250,
+						Height: 333,
+					},
+					{
+						URL:    "https://64.media.tumblr.com/f03b7e9a12c4d6e8a0b2c4d6e8f0a2b4/2c0f7f3b16a84d1e-7e/s100x150/0c9e1b3d5f7a9c1e3b5d7f9a1c3e5b7d9f1a3c5e.jpg",
+						Width:  100,
+						Height: 133,
+					},
+					{
+						URL:    "https://64.media.tumblr.com/f03b7e9a12c4d6e8a0b2c4d6e8f0a2b4/2c0f7f3b16a84d1e-7e/s75x75_c1/0c9e1b3d5f7a9c1e3b5d7f9a1c3e5b7d9f1a3c5e.jpg",
+						Width:  75,
+						Height: 75,
+					},
+				},
+			},
+		},
+	}},
+	{"quote", &QuotePost{
+		BasePost: BasePost{
+			PostType: "quote",
+			BlogName: "staff",
+			Blog: PostBlog{
+				Name:        "staff",
+				Title:       "Tumblr Staff",
+				Description: "<p>Official news from Tumblr HQ.</p>",
+				URL:         "https://staff.tumblr.com/",
+				UUID:        "t:0aY0xL4Fi1OFJg_0HZOLVA",
+				Updated:     1709312456,
+			},
+			ID:                    739201145520029696,
+			IDString:              "739201145520029696",
+			PostURL:               "https://staff.tumblr.com/post/739201145520029696",
+			Date:                  "2024-01-11 08:12:44 GMT",
+			Timestamp:             1704960764,
+			State:                 "published",
+			Format:                "markdown",
+			ReblogKey:             "Z3pLxR2c",
+			Tags:                  []string{"quotes"},
+			ShortURL:              "https://tmblr.co/ZE5Fbye7bSq-0",
+			Summary:               "The best way to predict the future is to invent it.",
+			ShouldOpenInLegacy:    true,
+			Liked:                 true,
+			NoteCount:             153,
+			Reblog:                ReblogInfo{},
+			Trail:                 []TrailItem{},
+			CanLike:               true,
+			InteractabilityReblog: "everyone",
+			InteractabilityBlaze:  "everyone",
+			CanReblog:             true,
+			CanSendInMessage:      true,
+			CanReply:              true,
+			DisplayAvatar:         true,
+		},
+		Text:   "The best way to predict the future is to invent it.",
+		Source: `<a href="https://en.wikipedia.org/wiki/Alan_Kay">Alan Kay</a>`,
+	}},
+	{"link", &LinkPost{
+		BasePost: BasePost{
+			PostType: "link",
+			BlogName: "staff",
+			Blog: PostBlog{
+				Name:        "staff",
+				Title:       "Tumblr Staff",
+				Description: "<p>Official news from Tumblr HQ.</p>",
+				URL:         "https://staff.tumblr.com/",
+				UUID:        "t:0aY0xL4Fi1OFJg_0HZOLVA",
+				Updated:     1709312456,
+			},
+			ID:                    742103657823305728,
+			IDString:              "742103657823305728",
+			PostURL:               "https://staff.tumblr.com/post/742103657823305728/whats-new-on-the-dashboard",
+			Slug:                  "whats-new-on-the-dashboard",
+			Date:                  "2024-02-12 09:23:18 GMT",
+			Timestamp:             1707729798,
+			State:                 "published",
+			Format:                "html",
+			ReblogKey:             "fM7sK0qa",
+			Tags:                  []string{"dashboard", "changelog"},
+			ShortURL:              "https://tmblr.co/ZE5FbyeBPzCq0",
+			Summary:               "What’s new on the dashboard",
+			ShouldOpenInLegacy:    true,
+			Followed:              true,
+			NoteCount:             427,
+			Reblog:                ReblogInfo{},
+			Trail:                 []TrailItem{},
+			CanLike:               true,
+			InteractabilityReblog: "everyone",
+			InteractabilityBlaze:  "everyone",
+			CanReblog:             true,
+			CanSendInMessage:      true,
+			CanReply:              true,
+			DisplayAvatar:         true,
+		},
+		Title:     "What’s new on the dashboard",
+		URL:       "https://changes.tumblr.com/post/742101998234566656/whats-new",
+		LinkImage: "https://64.media.tumblr.com/5e8f0a1b2c3d4e5f60718293a4b5c6d7/9a8b7c6d5e4f3a2b-01/s540x810/1f2e3d4c5b6a79880716253443526170.png",
+		LinkImageDimensions: LinkImageDimensions{
+			Width:  1200,
+			Height: 630,
+		},
+		Author:    "Tumblr Staff",
+		Excerpt:   "Tabs, filters and a new activity view.",
+		Publisher: "changes.tumblr.com",
+		Photos: []PhotoObject{
+			{
+				OriginalSize: AltSize{
+					URL:    "https://64.media.tumblr.com/5e8f0a1b2c3d4e5f60718293a4b5c6d7/9a8b7c6d5e4f3a2b-01/s540x810/1f2e3d4c5b6a79880716253443526170.png",
+					Width:  1200,
+					Height: 630,
+				},
+				AltSizes: []AltSize{
+					{
+						URL:    "https://64.media.tumblr.com/5e8f0a1b2c3d4e5f60718293a4b5c6d7/9a8b7c6d5e4f3a2b-01/s540x810/1f2e3d4c5b6a79880716253443526170.png",
+						Width:  1200,
+						Height: 630,
+					},
+					{
+						URL:    "https://64.media.tumblr.com/5e8f0a1b2c3d4e5f60718293a4b5c6d7/9a8b7c6d5e4f3a2b-01/s400x600/1f2e3d4c5b6a79880716253443526170.png",
+						Width:  400,
+						Height: 210,
+					},
+				},
+			},
+		},
+		Description: "<p>Everything that changed this week, in one place.</p>",
+	}},
+	{"chat", &ChatPost{
+		BasePost: BasePost{
+			PostType: "chat",
+			BlogName: "staff",
+			Blog: PostBlog{
+				Name:        "staff",
+				Title:       "Tumblr Staff",
+				Description: "<p>Official news from Tumblr HQ.</p>",
+				URL:         "https://staff.tumblr.com/",
+				UUID:        "t:0aY0xL4Fi1OFJg_0HZOLVA",
+				Updated:     1709312456,
+			},
+			ID:                    736415082295738368,
+			IDString:              "736415082295738368",
+			PostURL:               "https://staff.tumblr.com/post/736415082295738368/a-conversation-with-support",
+			Slug:                  "a-conversation-with-support",
+			Date:                  "2023-12-11 17:41:29 GMT",
+			Timestamp:             1702316489,
+			State:                 "published",
+			Format:                "html",
+			ReblogKey:             "cQ1vR8nT",
+			Tags:                  []string{"support", "conversations"},
+			ShortURL:              "https://tmblr.co/ZE5Fbye4gbF20",
+			Summary:               "A conversation with support",
+			ShouldOpenInLegacy:    true,
+			Followed:              true,
+			NoteCount:             96,
+			Reblog:                ReblogInfo{},
+			Trail:                 []TrailItem{},
+			CanLike:               true,
+			InteractabilityReblog: "everyone",
+			InteractabilityBlaze:  "everyone",
+			CanReblog:             true,
+			CanSendInMessage:      true,
+			CanReply:              true,
+			DisplayAvatar:         true,
+		},
+		Title: "A conversation with support",
+		Body:  "me: is the dashboard down?\r\nsupport: not anymore!\r\n(sound of relief)\r\nme: time: 3:14 am",
+		Dialogue: []DialogueInfo{
+			{
+				Name:   "me",
+				Label:  "me:",
+				Phrase: "is the dashboard down?",
+			},
+			{
+				Name:   "support",
+				Label:  "support:",
+				Phrase: "not anymore!",
+			},
+			{
+				Phrase: "(sound of relief)",
+			},
+			{
+				Name:   "me",
+				Label:  "me:",
+				Phrase: "time: 3:14 am",
+			},
+		},
+	}},
+	{"audio", &AudioPost{
+		BasePost: BasePost{
+			PostType: "audio",
+			BlogName: "staff",
+			Blog: PostBlog{
+				Name:        "staff",
+				Title:       "Tumblr Staff",
+				Description: "<p>Official news from Tumblr HQ.</p>",
+				URL:         "https://staff.tumblr.com/",
+				UUID:        "t:0aY0xL4Fi1OFJg_0HZOLVA",
+				Updated:     1709312456,
+			},
+			ID:                    737843011215466496,
+			IDString:              "737843011215466496",
+			PostURL:               "https://staff.tumblr.com/post/737843011215466496/lofi-beats-to-scroll-to",
+			Slug:                  "lofi-beats-to-scroll-to",
+			Date:                  "2023-12-27 11:58:04 GMT",
+			Timestamp:             1703678284,
+			State:                 "published",
+			Format:                "html",
+			ReblogKey:             "oV4eN9yD",
+			Tags:                  []string{"music", "lofi"},
+			ShortURL:              "https://tmblr.co/ZE5Fbye5mE3e0",
+			Summary:               "Lofi beats to scroll to",
+			ShouldOpenInLegacy:    true,
+			Followed:              true,
+			NoteCount:             2045,
+			SourceURL:             "https://soundcloud.com/night-desk/infinite-scroll",
+			SourceTitle:           "soundcloud.com",
+			Reblog:                ReblogInfo{},
+			Trail:                 []TrailItem{},
+			CanLike:               true,
+			InteractabilityReblog: "everyone",
+			InteractabilityBlaze:  "everyone",
+			CanReblog:             true,
+			CanSendInMessage:      true,
+			CanReply:              true,
+			DisplayAvatar:         true,
+		},
+		ID3Title:       "Infinite Scroll",
+		Caption:        "<p>Lofi beats to scroll to</p>",
+		Player:         `<iframe class="tumblr_audio_player tumblr_audio_player_737843011215466496" src="https://staff.tumblr.com/post/737843011215466496/audio_player_iframe/staff/tumblr_s5w2qz8Xk91qzfq8a?audio_file=https%3A%2F%2Fa.tumblr.com%2Ftumblr_s5w2qz8Xk91qzfq8ao1.mp3" frameborder="0" allowtransparency="true" scrolling="no" width="540" height="169"></iframe>`,
+		Embed:          `<iframe class="tumblr_audio_player tumblr_audio_player_737843011215466496" src="https://staff.tumblr.com/post/737843011215466496/audio_player_iframe/staff/tumblr_s5w2qz8Xk91qzfq8a?audio_file=https%3A%2F%2Fa.tumblr.com%2Ftumblr_s5w2qz8Xk91qzfq8ao1.mp3" frameborder="0" allowtransparency="true" scrolling="no" width="540" height="169"></iframe>`,
+		Plays:          20571,
+		AlbumArt:       "https://64.media.tumblr.com/tumblr_s5w2qz8Xk91qzfq8a_1703678284_cover.jpg",
+		Artist:         "Night Desk",
+		Album:          "Dashboard Sessions",
+		TrackName:      "Infinite Scroll",
+		TrackNumber:    3,
+		Year:           2023,
+		AudioURL:       "https://a.tumblr.com/tumblr_s5w2qz8Xk91qzfq8ao1.mp3",
+		AudioSourceURL: "https://www.tumblr.com/audio_file/staff/737843011215466496/tumblr_s5w2qz8Xk91qzfq8a",
+		AudioType:      "tumblr",
+	}},
+	{"video", &VideoPost{
+		BasePost: BasePost{
+			PostType: "video",
+			BlogName: "staff",
+			Blog: PostBlog{
+				Name:        "staff",
+				Title:       "Tumblr Staff",
+				Description: "<p>Official news from Tumblr HQ.</p>",
+				URL:         "https://staff.tumblr.com/",
+				UUID:        "t:0aY0xL4Fi1OFJg_0HZOLVA",
+				Updated:     1709312456,
+			},
+			ID:                    738977302511910912,
+			IDString:              "738977302511910912",
+			PostURL:               "https://staff.tumblr.com/post/738977302511910912/behind-the-scenes",
+			Slug:                  "behind-the-scenes",
+			Date:                  "2024-01-08 20:55:31 GMT",
+			Timestamp:             1704747331,
+			State:                 "published",
+			Format:                "html",
+			ReblogKey:             "L2hYd7wE",
+			Tags:                  []string{"video", "office"},
+			ShortURL:              "https://tmblr.co/ZE5Fbye79pKW0",
+			Summary:               "Behind the scenes at the office",
+			ShouldOpenInLegacy:    true,
+			Followed:              true,
+			NoteCount:             388,
+			Reblog:                ReblogInfo{},
+			Trail:                 []TrailItem{},
+			CanLike:               true,
+			InteractabilityReblog: "everyone",
+			InteractabilityBlaze:  "everyone",
+			CanReblog:             true,
+			CanSendInMessage:      true,
+			CanReply:              true,
+			DisplayAvatar:         true,
+		},
+		Caption:         "<p>Behind the scenes at the office.</p>",
+		VideoURL:        "https://va.media.tumblr.com/tumblr_s8k2jq0bX91qzfq8a.mp4",
+		HTML5Capable:    true,
+		ThumbnailURL:    "https://64.media.tumblr.com/tumblr_s8k2jq0bX91qzfq8a_frame1.jpg",
+		ThumbnailWidth:  1280,
+		ThumbnailHeight: 720,
+		Duration:        43,
+		Player: []PlayerInfo{
+			{
+				Width:     250,
+				EmbedCode: "<video  id='embed-65b9c2a1d4e7f090512345' class='crt-video crt-skin-default' width='250' height='141' poster='https://64.media.tumblr.com/tumblr_s8k2jq0bX91qzfq8a_frame1.jpg' preload='none' muted data-crt-video data-crt-options='{\"autoheight\":null,\"duration\":43,\"hdUrl\":false,\"filmstrip\":false}' >\n    <source src=\"https://va.media.tumblr.com/tumblr_s8k2jq0bX91qzfq8a.mp4\" type=\"video/mp4\">\n</video>\n",
+			},
+			{
+				Width:     400,
+				EmbedCode: "<video  id='embed-65b9c2a1d4e7f090512345' class='crt-video crt-skin-default' width='400' height='225' poster='https://64.media.tumblr.com/tumblr_s8k2jq0bX91qzfq8a_frame1.jpg' preload='none' muted data-crt-video data-crt-options='{\"autoheight\":null,\"duration\":43,\"hdUrl\":false,\"filmstrip\":false}' >\n    <source src=\"https://va.media.tumblr.com/tumblr_s8k2jq0bX91qzfq8a.mp4\" type=\"video/mp4\">\n</video>\n",
+			},
+			{
+				Width:     500,
+				EmbedCode: "<video  id='embed-65b9c2a1d4e7f090512345' class='crt-video crt-skin-default' width='500' height='281' poster='https://64.media.tumblr.com/tumblr_s8k2jq0bX91qzfq8a_frame1.jpg' preload='none' muted data-crt-video data-crt-options='{\"autoheight\":null,\"duration\":43,\"hdUrl\":false,\"filmstrip\":false}' >\n    <source src=\"https://va.media.tumblr.com/tumblr_s8k2jq0bX91qzfq8a.mp4\" type=\"video/mp4\">\n</video>\n",
+			},
+		},
+		VideoType: "tumblr",
+	}},
+	{"answer", &AnswerPost{
+		BasePost: BasePost{
+			PostType: "answer",
+			BlogName: "tumblr-help",
+			Blog: PostBlog{
+				Name:        "tumblr-help",
+				Title:       "Tumblr Help",
+				Description: "Answers to your questions.",
+				URL:         "https://tumblr-help.tumblr.com/",
+				UUID:        "t:P1kq4mLz0rT6bS2eW9xYcA",
+				Updated:     1708011234,
+			},
+			ID:                 743512877190823936,
+			IDString:           "743512877190823936",
+			PostURL:            "https://tumblr-help.tumblr.com/post/743512877190823936/can-i-edit-old-posts",
+			Slug:               "can-i-edit-old-posts",
+			Date:               "2024-02-27 22:33:51 GMT",
+			Timestamp:          1709073231,
+			State:              "published",
+			Format:             "html",
+			ReblogKey:          "sE0kPq3m",
+			Tags:               []string{"asks", "faq"},
+			ShortURL:           "https://tmblr.co/ZZqk7ueD8Wq00",
+			Summary:            "Can I edit old posts?",
+			ShouldOpenInLegacy: true,
+			PostAuthor:         "staff",
+			Followed:           true,
+			NoteCount:          41,
+			Reblog: ReblogInfo{
+				Comment: "<p>Yes, from the <b>post menu</b>.</p>",
+			},
+			Trail: []TrailItem{
+				{
+					Blog: TrailBlog{
+						Name:          "tumblr-help",
+						Active:        true,
+						Theme:         trailTheme,
+						CanBeFollowed: true,
+					},
+					Post: TrailPost{
+						ID: 743512877190823936,
+					},
+					ContentRaw:    "<p>Yes, from the <b>post menu</b>.</p>",
+					Content:       "<p>Yes, from the <b>post menu</b>.</p>",
+					IsRootItem:    true,
+					IsCurrentItem: true,
+				},
+			},
+			CanLike:               true,
+			InteractabilityReblog: "everyone",
+			InteractabilityBlaze:  "everyone",
+			CanReblog:             true,
+			CanSendInMessage:      true,
+			CanReply:              true,
+			DisplayAvatar:         true,
+		},
+		AskingName: "curious-cat",
+		AskingURL:  "https://curious-cat.tumblr.com/",
+		Question:   "Can I edit old posts?",
+		Answer:     "<p>Yes, from the <b>post menu</b>.</p>",
+	}},
+}
+
+func TestDecodePostFixtures(t *testing.T) {
+	for _, test := range fixturePosts {
+		t.Run(test.name, func(t *testing.T) {
+			data := readFixture(t, test.name)
+			got, err := DecodePost(data)
+			if err != nil {
+				t.Fatalf("DecodePost: %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				gotJSON, _ := json.MarshalIndent(got, "", "  ")
+				wantJSON, _ := json.MarshalIndent(test.want, "", "  ")
+				t.Errorf("DecodePost = %s\nwant %s", gotJSON, wantJSON)
+			}
+			if got.Base().Time().Unix() != got.Base().Timestamp {
+				t.Errorf("Time() = %v, want the timestamp %d", got.Base().Time(), got.Base().Timestamp)
+			}
+			// every field of the response has a field in the post, except the recommendations, always null
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(data, &fields); err != nil {
+				t.Fatal(err)
+			}
+			delete(fields, "recommended_source")
+			delete(fields, "recommended_color")
+			trimmed, _ := json.Marshal(fields)
+			decoder := json.NewDecoder(bytes.NewReader(trimmed))
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(reflect.New(reflect.TypeOf(test.want).Elem()).Interface()); err != nil {
+				t.Errorf("a field of the response is not decoded: %v", err)
+			}
+		})
+	}
+}
+
+func TestDecodePosts(t *testing.T) {
+	var data []json.RawMessage
+	for _, test := range fixturePosts {
+		data = append(data, readFixture(t, test.name))
+	}
+	posts, err := DecodePosts(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != len(fixturePosts) {
+		t.Fatalf("DecodePosts returned %d posts, want %d", len(posts), len(fixturePosts))
+	}
+	for i, post := range posts {
+		if !reflect.DeepEqual(post, fixturePosts[i].want) {
+			t.Errorf("post %d (%s) differs from DecodePost", i, fixturePosts[i].name)
+		}
+	}
+
+	data = append(data, json.RawMessage(`{"type": "text", "id": "oops"}`))
+	if _, err := DecodePosts(data); !errors.As(err, new(*DecodeError)) {
+		t.Errorf("DecodePosts with an invalid post: err = %v, want a *DecodeError", err)
+	}
+}
+
+func TestDecodePostUnknownType(t *testing.T) {
+	data := json.RawMessage(`{"type": "blocks", "id": 721385129357164552, "blog_name": "staff", "content": []}`)
+	for _, strict := range []bool{false, true} {
+		post, err := decodePost(data, strict)
+		if err != nil {
+			t.Fatalf("strict %v: %v", strict, err)
+		}
+		base, ok := post.(*BasePost)
+		if !ok || base.ID != 721385129357164552 || base.BlogName != "staff" {
+			t.Errorf("strict %v: decodePost = %#v, want a *BasePost", strict, post)
+		}
+	}
+}
+
+func TestDecodePostStrict(t *testing.T) {
+	client := NewTumblrRestClient("key", "secret", "token", "token secret", "", "https://api.tumblr.com")
+	client.SetStrict(true)
+	edit := func(name string, change func(fields map[string]interface{})) json.RawMessage {
+		var fields map[string]interface{}
+		if err := json.Unmarshal(readFixture(t, name), &fields); err != nil {
+			t.Fatal(err)
+		}
+		change(fields)
+		data, _ := json.Marshal(fields)
+		return data
+	}
+
+	// the fields the API adds are not reported, nor are the optional fields it leaves out
+	for _, test := range fixturePosts {
+		data := edit(test.name, func(fields map[string]interface{}) {
+			fields["is_paywalled"] = false
+			delete(fields, "source_url")
+			delete(fields, "parent_post_url")
+		})
+		if _, err := client.DecodePost(data); err != nil {
+			t.Errorf("strict DecodePost of the %s fixture: %v", test.name, err)
+		}
+	}
+
+	for _, test := range []struct {
+		name, fixture, missing string
+		change                 func(fields map[string]interface{})
+	}{
+		{"renamed field", "text", "trail", func(fields map[string]interface{}) {
+			fields["reblog_trail"] = fields["trail"]
+			delete(fields, "trail")
+		}},
+		{"field of the post type", "photo", "photos", func(fields map[string]interface{}) { delete(fields, "photos") }},
+		{"nested field", "text", "blog.uuid", func(fields map[string]interface{}) {
+			delete(fields["blog"].(map[string]interface{}), "uuid")
+		}},
+		{"field of a list", "photo", "photos[1].original_size", func(fields map[string]interface{}) {
+			delete(fields["photos"].([]interface{})[1].(map[string]interface{}), "original_size")
+		}},
+	} {
+		data := edit(test.fixture, test.change)
+		if _, err := DecodePost(data); err != nil {
+			t.Errorf("%s: DecodePost: %v", test.name, err)
+		}
+		_, err := client.DecodePost(data)
+		if !errors.As(err, new(*DecodeError)) || !strings.Contains(err.Error(), `"`+test.missing+`"`) {
+			t.Errorf("%s: strict DecodePost: err = %v, want a *DecodeError for the missing field %s", test.name, err, test.missing)
+		}
+	}
+}
+
+// TestCaptureFixtures replaces the fixtures by the latest post of each type of the blog named by
+// GOTUMBLR_CAPTURE_BLOG, fetched with the default profile. The expected posts must then be updated.
+func TestCaptureFixtures(t *testing.T) {
+	name := os.Getenv("GOTUMBLR_CAPTURE_BLOG")
+	if name == "" {
+		t.Skip("GOTUMBLR_CAPTURE_BLOG is not set")
+	}
+	client, err := NewClientFromProfile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	blog, err := ParseBlogIdentifier(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range fixturePosts {
+		response, err := client.Posts(blog, test.name, map[string]string{"limit": "1", "reblog_info": "true"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(response.Posts) == 0 {
+			t.Errorf("%s has no %s post", name, test.name)
+			continue
+		}
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, response.Posts[0], "", "  "); err != nil {
+			t.Fatal(err)
+		}
+		indented.WriteString("\n")
+		if err := ioutil.WriteFile(filepath.Join("testdata", "posts", test.name+".json"), indented.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
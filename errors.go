@@ -0,0 +1,49 @@
+package gotumblr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// APIError is returned when the Tumblr API answers with an unexpected HTTP or meta status.
+type APIError struct {
+	StatusCode int      // the HTTP status code, 0 if unknown
+	Meta       MetaInfo // the meta information of the response, empty if the response was not JSON
+}
+
+func (e *APIError) Error() string {
+	msg := e.Meta.Msg
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	status := e.Meta.Status
+	if status == 0 {
+		status = int64(e.StatusCode)
+	}
+	return fmt.Sprintf("gotumblr: %d %s", status, msg)
+}
+
+// IsRateLimited reports whether err is, or wraps, an *APIError telling that the rate limit
+// of the API was exceeded. The requests can be sent again once the limit is reset.
+func IsRateLimited(err error) bool {
+	var apiError *APIError
+	return errors.As(err, &apiError) && (apiError.StatusCode == http.StatusTooManyRequests || apiError.Meta.Status == http.StatusTooManyRequests)
+}
+
+// DecodeError is returned when a response from the Tumblr API cannot be decoded,
+// either because it is not valid JSON, because it does not match the expected
+// structure or, in strict mode, because it lacks fields modelled by gotumblr.
+type DecodeError struct {
+	Data []byte // the JSON that could not be decoded
+	Err  error  // the error returned by the JSON decoder
+}
+
+func (e *DecodeError) Error() string {
+	return "gotumblr: decoding response: " + e.Err.Error()
+}
+
+// Unwrap returns the error returned by the JSON decoder.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,214 @@
+// Package feed builds RSS 2.0 and Atom feeds of Tumblr posts, e.g. of a tag across several blogs
+// or of the posts a blog liked, and serves them over HTTP:
+//
+//	source := feed.Posts(client, blogs, map[string]string{"tag": "golang"})
+//	handler := feed.NewHandler(feed.Feed{Title: "Go on Tumblr", Link: "https://example.com/"}, source)
+//	http.Handle("/golang.xml", handler)
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/spbr/gotumblr"
+)
+
+// Feed is a feed of posts, built from decoded posts with AddPosts.
+type Feed struct {
+	Title       string
+	Link        string // URL of the site of the feed
+	Description string
+	// ID is the Atom id of the feed, Link if empty.
+	ID string
+	// Updated is the time the feed last changed, the time of its newest item if zero.
+	Updated time.Time
+	Items   []Item
+}
+
+// Item is an entry of a feed.
+type Item struct {
+	ID         string // Atom id and RSS guid: the URL of the post
+	Title      string
+	Link       string
+	Content    string // HTML
+	Author     string // name of the blog of the post
+	Categories []string
+	Published  time.Time
+	Enclosure  *Enclosure // the audio or video file of the post, if any
+}
+
+// Enclosure is a media file attached to an item.
+type Enclosure struct {
+	URL    string
+	Type   string // MIME type
+	Length int64  // size in bytes, 0 if unknown
+}
+
+// AddPosts adds items for posts to the feed, in order.
+func (f *Feed) AddPosts(posts []gotumblr.Post) {
+	for _, post := range posts {
+		f.Items = append(f.Items, FromPost(post))
+	}
+}
+
+// updated returns the time the feed last changed.
+func (f *Feed) updated() time.Time {
+	updated := f.Updated
+	if updated.IsZero() {
+		for _, item := range f.Items {
+			if item.Published.After(updated) {
+				updated = item.Published
+			}
+		}
+	}
+	if updated.IsZero() {
+		updated = time.Unix(0, 0)
+	}
+	return updated.UTC()
+}
+
+// RSS returns the feed as an RSS 2.0 document.
+func (f *Feed) RSS() ([]byte, error) {
+	type guid struct {
+		IsPermaLink bool   `xml:"isPermaLink,attr"`
+		Value       string `xml:",chardata"`
+	}
+	type enclosure struct {
+		URL    string `xml:"url,attr"`
+		Type   string `xml:"type,attr"`
+		Length int64  `xml:"length,attr"`
+	}
+	type item struct {
+		Title       string     `xml:"title,omitempty"`
+		Link        string     `xml:"link,omitempty"`
+		Description string     `xml:"description"`
+		Author      string     `xml:"dc:creator,omitempty"`
+		Categories  []string   `xml:"category"`
+		GUID        guid       `xml:"guid"`
+		PubDate     string     `xml:"pubDate"`
+		Enclosure   *enclosure `xml:"enclosure"`
+	}
+	type channel struct {
+		Title         string `xml:"title"`
+		Link          string `xml:"link"`
+		Description   string `xml:"description"`
+		LastBuildDate string `xml:"lastBuildDate"`
+		Items         []item `xml:"item"`
+	}
+	type rss struct {
+		XMLName xml.Name `xml:"rss"`
+		Version string   `xml:"version,attr"`
+		DC      string   `xml:"xmlns:dc,attr"`
+		Channel channel  `xml:"channel"`
+	}
+	document := rss{
+		Version: "2.0",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: channel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   f.Description,
+			LastBuildDate: f.updated().Format(time.RFC1123Z),
+		},
+	}
+	for _, it := range f.Items {
+		rendered := item{
+			Title:       it.Title,
+			Link:        it.Link,
+			Description: it.Content,
+			Author:      it.Author,
+			Categories:  it.Categories,
+			GUID:        guid{IsPermaLink: it.ID == it.Link, Value: it.ID},
+			PubDate:     it.Published.UTC().Format(time.RFC1123Z),
+		}
+		if it.Enclosure != nil {
+			rendered.Enclosure = &enclosure{URL: it.Enclosure.URL, Type: it.Enclosure.Type, Length: it.Enclosure.Length}
+		}
+		document.Channel.Items = append(document.Channel.Items, rendered)
+	}
+	return marshal(document)
+}
+
+// Atom returns the feed as an Atom document.
+func (f *Feed) Atom() ([]byte, error) {
+	type link struct {
+		Href   string `xml:"href,attr"`
+		Rel    string `xml:"rel,attr,omitempty"`
+		Type   string `xml:"type,attr,omitempty"`
+		Length int64  `xml:"length,attr,omitempty"`
+	}
+	type text struct {
+		Type  string `xml:"type,attr"`
+		Value string `xml:",chardata"`
+	}
+	type person struct {
+		Name string `xml:"name"`
+	}
+	type category struct {
+		Term string `xml:"term,attr"`
+	}
+	type entry struct {
+		ID         string     `xml:"id"`
+		Title      string     `xml:"title"`
+		Links      []link     `xml:"link"`
+		Published  string     `xml:"published"`
+		Updated    string     `xml:"updated"`
+		Author     *person    `xml:"author"`
+		Categories []category `xml:"category"`
+		Content    text       `xml:"content"`
+	}
+	type feed struct {
+		XMLName  xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		ID       string   `xml:"id"`
+		Title    string   `xml:"title"`
+		Subtitle string   `xml:"subtitle,omitempty"`
+		Links    []link   `xml:"link"`
+		Updated  string   `xml:"updated"`
+		Entries  []entry  `xml:"entry"`
+	}
+	document := feed{
+		ID:       f.ID,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Updated:  f.updated().Format(time.RFC3339),
+	}
+	if document.ID == "" {
+		document.ID = f.Link
+	}
+	if f.Link != "" {
+		document.Links = append(document.Links, link{Href: f.Link, Rel: "alternate"})
+	}
+	for _, it := range f.Items {
+		published := it.Published.UTC().Format(time.RFC3339)
+		rendered := entry{
+			ID:        it.ID,
+			Title:     it.Title,
+			Published: published,
+			Updated:   published,
+			Content:   text{Type: "html", Value: it.Content},
+		}
+		if it.Link != "" {
+			rendered.Links = append(rendered.Links, link{Href: it.Link, Rel: "alternate"})
+		}
+		if it.Enclosure != nil {
+			rendered.Links = append(rendered.Links, link{Href: it.Enclosure.URL, Rel: "enclosure", Type: it.Enclosure.Type, Length: it.Enclosure.Length})
+		}
+		if it.Author != "" {
+			rendered.Author = &person{Name: it.Author}
+		}
+		for _, term := range it.Categories {
+			rendered.Categories = append(rendered.Categories, category{Term: term})
+		}
+		document.Entries = append(document.Entries, rendered)
+	}
+	return marshal(document)
+}
+
+// marshal encodes an XML document, with its header.
+func marshal(document interface{}) ([]byte, error) {
+	data, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
@@ -0,0 +1,159 @@
+package feed_test
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spbr/gotumblr/feed"
+)
+
+func testFeed() *feed.Feed {
+	published := time.Date(2024, 2, 20, 16, 0, 0, 0, time.UTC)
+	return &feed.Feed{
+		Title: "Go on Tumblr", Link: "https://example.com/", Description: "posts about Go",
+		Items: []feed.Item{{
+			ID: "https://staff.tumblr.com/post/7", Title: "Hello", Link: "https://staff.tumblr.com/post/7",
+			Content: "<p>a & b</p>", Author: "staff", Categories: []string{"go", "news"}, Published: published,
+			Enclosure: &feed.Enclosure{URL: "https://a.tumblr.com/x.mp3", Type: "audio/mpeg", Length: 42},
+		}, {
+			ID: "8", Title: "Older", Content: "old", Published: published.Add(-time.Hour),
+		}},
+	}
+}
+
+func TestRSS(t *testing.T) {
+	data, err := testFeed().RSS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), xml.Header) {
+		t.Errorf("no XML header in %s", data)
+	}
+	var document struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Title         string `xml:"title"`
+			Link          string `xml:"link"`
+			Description   string `xml:"description"`
+			LastBuildDate string `xml:"lastBuildDate"`
+			Items         []struct {
+				Title       string   `xml:"title"`
+				Link        string   `xml:"link"`
+				Description string   `xml:"description"`
+				PubDate     string   `xml:"pubDate"`
+				Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+				Categories  []string `xml:"category"`
+				GUID        struct {
+					IsPermaLink bool   `xml:"isPermaLink,attr"`
+					Value       string `xml:",chardata"`
+				} `xml:"guid"`
+				Enclosure *struct {
+					URL    string `xml:"url,attr"`
+					Type   string `xml:"type,attr"`
+					Length int64  `xml:"length,attr"`
+				} `xml:"enclosure"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(data, &document); err != nil {
+		t.Fatalf("invalid RSS: %v\n%s", err, data)
+	}
+	channel := document.Channel
+	if document.Version != "2.0" || channel.Title != "Go on Tumblr" || channel.Link != "https://example.com/" ||
+		channel.Description != "posts about Go" || channel.LastBuildDate != "Tue, 20 Feb 2024 16:00:00 +0000" {
+		t.Errorf("channel %+v, want the feed metadata, built at the newest item", channel)
+	}
+	if len(channel.Items) != 2 {
+		t.Fatalf("%d items, want 2", len(channel.Items))
+	}
+	item := channel.Items[0]
+	if item.Title != "Hello" || item.Description != "<p>a & b</p>" || item.Creator != "staff" ||
+		strings.Join(item.Categories, ",") != "go,news" || item.PubDate != "Tue, 20 Feb 2024 16:00:00 +0000" {
+		t.Errorf("item %+v", item)
+	}
+	if !item.GUID.IsPermaLink || item.GUID.Value != "https://staff.tumblr.com/post/7" {
+		t.Errorf("guid %+v, want the permalink of the post", item.GUID)
+	}
+	if item.Enclosure == nil || item.Enclosure.URL != "https://a.tumblr.com/x.mp3" || item.Enclosure.Type != "audio/mpeg" || item.Enclosure.Length != 42 {
+		t.Errorf("enclosure %+v", item.Enclosure)
+	}
+	if older := channel.Items[1]; older.GUID.IsPermaLink || older.GUID.Value != "8" || older.Enclosure != nil {
+		t.Errorf("item %+v, want a guid that is not a permalink and no enclosure", older)
+	}
+}
+
+func TestAtom(t *testing.T) {
+	data, err := testFeed().Atom()
+	if err != nil {
+		t.Fatal(err)
+	}
+	type link struct {
+		Href   string `xml:"href,attr"`
+		Rel    string `xml:"rel,attr"`
+		Type   string `xml:"type,attr"`
+		Length int64  `xml:"length,attr"`
+	}
+	var document struct {
+		XMLName  xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		ID       string   `xml:"id"`
+		Title    string   `xml:"title"`
+		Subtitle string   `xml:"subtitle"`
+		Updated  string   `xml:"updated"`
+		Links    []link   `xml:"link"`
+		Entries  []struct {
+			ID        string `xml:"id"`
+			Title     string `xml:"title"`
+			Published string `xml:"published"`
+			Updated   string `xml:"updated"`
+			Links     []link `xml:"link"`
+			Author    *struct {
+				Name string `xml:"name"`
+			} `xml:"author"`
+			Categories []struct {
+				Term string `xml:"term,attr"`
+			} `xml:"category"`
+			Content struct {
+				Type  string `xml:"type,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"content"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(data, &document); err != nil {
+		t.Fatalf("invalid Atom: %v\n%s", err, data)
+	}
+	if document.ID != "https://example.com/" || document.Title != "Go on Tumblr" || document.Subtitle != "posts about Go" ||
+		document.Updated != "2024-02-20T16:00:00Z" || len(document.Links) != 1 || document.Links[0].Rel != "alternate" {
+		t.Errorf("feed %+v, want the feed metadata, its id defaulting to its link", document)
+	}
+	if len(document.Entries) != 2 {
+		t.Fatalf("%d entries, want 2", len(document.Entries))
+	}
+	entry := document.Entries[0]
+	if entry.ID != "https://staff.tumblr.com/post/7" || entry.Title != "Hello" || entry.Published != "2024-02-20T16:00:00Z" ||
+		entry.Author == nil || entry.Author.Name != "staff" || len(entry.Categories) != 2 || entry.Categories[1].Term != "news" {
+		t.Errorf("entry %+v", entry)
+	}
+	if entry.Content.Type != "html" || entry.Content.Value != "<p>a & b</p>" {
+		t.Errorf("content %+v, want the escaped HTML", entry.Content)
+	}
+	want := []link{{Href: "https://staff.tumblr.com/post/7", Rel: "alternate"},
+		{Href: "https://a.tumblr.com/x.mp3", Rel: "enclosure", Type: "audio/mpeg", Length: 42}}
+	if len(entry.Links) != 2 || entry.Links[0] != want[0] || entry.Links[1] != want[1] {
+		t.Errorf("links %+v, want %+v", entry.Links, want)
+	}
+	if older := document.Entries[1]; len(older.Links) != 0 || older.Author != nil {
+		t.Errorf("entry %+v, want no link and no author", older)
+	}
+}
+
+func TestEmptyFeedUpdated(t *testing.T) {
+	data, err := (&feed.Feed{Title: "empty"}).Atom()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "<updated>1970-01-01T00:00:00Z</updated>") {
+		t.Errorf("feed without items %s, want the epoch as its update time", data)
+	}
+}
@@ -0,0 +1,210 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Formats of the documents served by a Handler.
+const (
+	RSS  = "rss"
+	Atom = "atom"
+)
+
+// Handler serves a feed of the posts of a Source. The posts are fetched again once the feed
+// is older than TTL; if that fails, the previous feed is served until the source recovers.
+// The posts are fetched once for all the requests waiting for them, with a context of their own:
+// a request that goes away does not cancel the fetch. After an error, the source is not called
+// again for Backoff, doubled at each consecutive error up to TTL.
+// Requests can ask for a format with the format query parameter, e.g. ?format=atom.
+// The fields must not be changed once the handler serves requests.
+type Handler struct {
+	// Feed holds the title, link, description and id of the feed; its items are replaced.
+	Feed    Feed
+	Source  Source
+	Format  string        // RSS or Atom, RSS by default
+	TTL     time.Duration // 15 minutes by default
+	Limit   int           // maximum number of items, 20 by default
+	Timeout time.Duration // of a fetch of the posts, 30 seconds by default
+	Backoff time.Duration // after an error, 1 minute by default
+	// ErrorLog logs the errors of the source, the standard logger if nil.
+	ErrorLog *log.Logger
+
+	mutex    sync.Mutex
+	items    []Item
+	fetched  time.Time
+	err      error         // error of the last fetch, nil once one succeeds
+	failures int           // number of consecutive errors
+	retryAt  time.Time     // the source is not called before, after an error
+	done     chan struct{} // closed at the end of the fetch in progress, nil if none
+}
+
+// NewHandler creates a Handler serving an RSS feed of the posts of source,
+// with the title, link and description of meta.
+func NewHandler(meta Feed, source Source) *Handler {
+	return &Handler{Feed: meta, Source: source, Format: RSS, TTL: 15 * time.Minute, Limit: 20,
+		Timeout: 30 * time.Second, Backoff: time.Minute}
+}
+
+// ServeHTTP serves the feed, answering conditional requests with its ETag.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = h.Format
+	}
+	if format == "" {
+		format = RSS
+	}
+	if format != RSS && format != Atom {
+		http.Error(w, "unknown feed format "+strconv.Quote(format), http.StatusBadRequest)
+		return
+	}
+
+	items, expires, err := h.fetch(r.Context())
+	if err != nil {
+		http.Error(w, "the feed is not available", http.StatusBadGateway)
+		return
+	}
+	feed := h.Feed
+	feed.Items = items
+	var data []byte
+	contentType := "application/rss+xml; charset=utf-8"
+	if format == Atom {
+		data, err = feed.Atom()
+		contentType = "application/atom+xml; charset=utf-8"
+	} else {
+		data, err = feed.RSS()
+	}
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", etag)
+	if maxAge := int(time.Until(expires).Seconds()); maxAge > 0 {
+		w.Header().Set("Cache-Control", "max-age="+strconv.Itoa(maxAge))
+	}
+	http.ServeContent(w, r, "", feed.updated(), bytes.NewReader(data))
+}
+
+// fetch returns the items of the feed and the time they expire, zero if they already expired.
+// If they expired, it starts a fetch of the posts, unless one is in progress or the handler
+// backs off after an error, and waits for it or for the end of ctx.
+func (h *Handler) fetch(ctx context.Context) ([]Item, time.Time, error) {
+	h.mutex.Lock()
+	if h.fresh() || time.Now().Before(h.retryAt) {
+		defer h.mutex.Unlock()
+		return h.result()
+	}
+	if h.done == nil {
+		h.done = make(chan struct{})
+		go h.refresh(h.done)
+	}
+	done := h.done
+	h.mutex.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, time.Time{}, ctx.Err()
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.result()
+}
+
+// refresh fetches the posts and closes done.
+func (h *Handler) refresh(done chan struct{}) {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	posts, err := h.Source(ctx)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	defer close(done)
+	h.done = nil
+	if err != nil {
+		h.logf("feed: fetching the posts: %v", err)
+		h.err = err
+		h.failures++
+		h.retryAt = time.Now().Add(h.backoff())
+		return
+	}
+	limit := h.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	if len(posts) > limit {
+		posts = posts[:limit]
+	}
+	var feed Feed
+	feed.AddPosts(posts)
+	h.items, h.fetched = feed.Items, time.Now()
+	h.err, h.failures, h.retryAt = nil, 0, time.Time{}
+}
+
+// result returns the items of the feed and the time they expire, or the error of the last fetch
+// if the posts were never fetched. The mutex must be held.
+func (h *Handler) result() ([]Item, time.Time, error) {
+	if h.fetched.IsZero() {
+		return nil, time.Time{}, h.err
+	}
+	if !h.fresh() {
+		return h.items, time.Time{}, nil
+	}
+	return h.items, h.fetched.Add(h.ttl()), nil
+}
+
+// fresh reports whether the items were fetched less than TTL ago. The mutex must be held.
+func (h *Handler) fresh() bool {
+	return !h.fetched.IsZero() && time.Since(h.fetched) < h.ttl()
+}
+
+func (h *Handler) ttl() time.Duration {
+	if h.TTL <= 0 {
+		return 15 * time.Minute
+	}
+	return h.TTL
+}
+
+// backoff returns the time to wait after the consecutive errors: Backoff, doubled
+// at each error, up to TTL. The mutex must be held.
+func (h *Handler) backoff() time.Duration {
+	backoff := h.Backoff
+	if backoff <= 0 {
+		backoff = time.Minute
+	}
+	for i := 1; i < h.failures && backoff < h.ttl(); i++ {
+		backoff *= 2
+	}
+	if backoff > h.ttl() {
+		backoff = h.ttl()
+	}
+	return backoff
+}
+
+func (h *Handler) logf(format string, args ...interface{}) {
+	if h.ErrorLog != nil {
+		h.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
@@ -0,0 +1,172 @@
+package feed_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spbr/gotumblr"
+	"github.com/spbr/gotumblr/feed"
+)
+
+// countingSource is a Source returning a text post, or err, and counting its calls.
+type countingSource struct {
+	mutex sync.Mutex
+	calls int
+	err   error
+	wait  chan struct{} // if set, the source blocks until it is closed or its context ends
+	ctxs  []context.Context
+}
+
+func (s *countingSource) source(ctx context.Context) ([]gotumblr.Post, error) {
+	s.mutex.Lock()
+	s.calls++
+	s.ctxs = append(s.ctxs, ctx)
+	err, wait := s.err, s.wait
+	s.mutex.Unlock()
+	if wait != nil {
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []gotumblr.Post{&gotumblr.TextPost{BasePost: base("text"), Title: "Hello", Body: "<p>body</p>"}}, nil
+}
+
+func (s *countingSource) set(err error) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.err = err
+	return s.calls
+}
+
+func newTestHandler(source *countingSource) *feed.Handler {
+	handler := feed.NewHandler(feed.Feed{Title: "test", Link: "https://example.com/"}, source.source)
+	handler.ErrorLog = log.New(io.Discard, "", 0)
+	return handler
+}
+
+func serve(handler http.Handler, target string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", target, nil)
+	for key, values := range header {
+		r.Header[key] = values
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandlerFormats(t *testing.T) {
+	handler := newTestHandler(&countingSource{})
+	for _, test := range []struct {
+		target, contentType, root string
+	}{
+		{"/feed", "application/rss+xml; charset=utf-8", "<rss"},
+		{"/feed?format=atom", "application/atom+xml; charset=utf-8", "<feed"},
+	} {
+		w := serve(handler, test.target, nil)
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != test.contentType || !strings.Contains(w.Body.String(), test.root) {
+			t.Errorf("%s: %d %s\n%s", test.target, w.Code, w.Header().Get("Content-Type"), w.Body)
+		}
+	}
+	if w := serve(handler, "/feed?format=json", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown format: status %d, want 400", w.Code)
+	}
+}
+
+func TestHandlerETag(t *testing.T) {
+	source := &countingSource{}
+	handler := newTestHandler(source)
+	w := serve(handler, "/feed", nil)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" || !strings.HasPrefix(w.Header().Get("Cache-Control"), "max-age=") {
+		t.Fatalf("status %d, headers %v, want an ETag and a max-age", w.Code, w.Header())
+	}
+	w = serve(handler, "/feed", http.Header{"If-None-Match": {etag}})
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("conditional request: status %d, body %q, want 304", w.Code, w.Body)
+	}
+	if w = serve(handler, "/feed?format=atom", http.Header{"If-None-Match": {etag}}); w.Code != http.StatusOK {
+		t.Errorf("conditional request of another format: status %d, want 200", w.Code)
+	}
+	if calls := source.set(nil); calls != 1 {
+		t.Errorf("%d fetches, want 1 within the TTL", calls)
+	}
+}
+
+func TestHandlerBackoff(t *testing.T) {
+	source := &countingSource{err: errors.New("down")}
+	handler := newTestHandler(source)
+	handler.TTL = time.Hour
+	handler.Backoff = 50 * time.Millisecond
+
+	if w := serve(handler, "/feed", nil); w.Code != http.StatusBadGateway {
+		t.Fatalf("status %d, want 502 before the first fetch succeeds", w.Code)
+	}
+	// the source is not called again during the backoff
+	if w := serve(handler, "/feed", nil); w.Code != http.StatusBadGateway || source.set(nil) != 1 {
+		t.Fatalf("status %d after %d fetches, want 502 after a single fetch", w.Code, source.calls)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if w := serve(handler, "/feed", nil); w.Code != http.StatusOK || source.set(nil) != 2 {
+		t.Fatalf("status %d after %d fetches, want the feed fetched again after the backoff", w.Code, source.calls)
+	}
+
+	// once fetched, the feed is served while the source fails
+	handler.TTL = time.Millisecond
+	source.set(errors.New("down"))
+	time.Sleep(2 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if w := serve(handler, "/feed", nil); w.Code != http.StatusOK || w.Header().Get("Cache-Control") != "" {
+			t.Errorf("status %d, headers %v, want the stale feed without a max-age", w.Code, w.Header())
+		}
+	}
+	if calls := source.set(nil); calls != 3 {
+		t.Errorf("%d fetches, want one more failed fetch, then the backoff", calls)
+	}
+}
+
+func TestHandlerDetachedFetch(t *testing.T) {
+	source := &countingSource{wait: make(chan struct{})}
+	handler := newTestHandler(source)
+
+	// a request that goes away does not cancel the fetch shared with the others
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest("GET", "/feed", nil).WithContext(ctx)
+	gone := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		gone <- w.Code
+	}()
+	waiting := make(chan *httptest.ResponseRecorder)
+	for source.set(nil) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	go func() { waiting <- serve(handler, "/feed", nil) }()
+	cancel()
+	if code := <-gone; code != http.StatusBadGateway {
+		t.Errorf("canceled request: status %d, want 502", code)
+	}
+	close(source.wait)
+	if w := <-waiting; w.Code != http.StatusOK {
+		t.Errorf("waiting request: status %d, want 200", w.Code)
+	}
+	if calls := source.set(nil); calls != 1 {
+		t.Errorf("%d fetches, want 1 shared by the requests", calls)
+	}
+	if _, ok := source.ctxs[0].Deadline(); !ok {
+		t.Error("the source was called without a timeout")
+	}
+}
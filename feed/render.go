@@ -0,0 +1,150 @@
+package feed
+
+import (
+	"html"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spbr/gotumblr"
+)
+
+// titleLength is the maximum length of the titles made from the summary of a post.
+const titleLength = 80
+
+// FromPost returns the item of a post. Its content is the HTML of the post, rendered by type:
+// the photos of a photo post, the quote and its source, the link and its description,
+// the dialogue of a chat, the question and answer, the player of an audio or video post.
+// The audio or video file of a post, if hosted by Tumblr, is its enclosure.
+// Posts without a title are titled with their summary.
+func FromPost(post gotumblr.Post) Item {
+	base := post.Base()
+	item := Item{
+		ID:         base.PostURL,
+		Link:       base.PostURL,
+		Author:     base.BlogName,
+		Categories: base.Tags,
+		Published:  base.Time(),
+	}
+	if item.ID == "" {
+		item.ID = base.ID.String()
+	}
+	var content strings.Builder
+	write := func(parts ...string) {
+		for _, part := range parts {
+			content.WriteString(part)
+		}
+	}
+	switch post := post.(type) {
+	case *gotumblr.TextPost:
+		item.Title = post.Title
+		write(post.Body)
+	case *gotumblr.PhotoPost:
+		for i := range post.Photos {
+			photo := &post.Photos[i]
+			image := `<img src="` + html.EscapeString(photo.Original().URL) + `" alt="` + html.EscapeString(photo.Caption) + `">`
+			if post.LinkURL != "" {
+				image = `<a href="` + html.EscapeString(post.LinkURL) + `">` + image + `</a>`
+			}
+			write("<p>", image, "</p>")
+		}
+		write(post.Caption)
+	case *gotumblr.QuotePost:
+		write("<blockquote>", post.Text, "</blockquote>")
+		if post.Source != "" {
+			write("<p>— ", post.Source, "</p>")
+		}
+	case *gotumblr.LinkPost:
+		item.Title = post.Title
+		label := post.Title
+		if label == "" {
+			label = post.URL
+		}
+		write(`<p><a href="`, html.EscapeString(post.URL), `">`, html.EscapeString(label), "</a></p>")
+		if post.Excerpt != "" {
+			write("<blockquote>", html.EscapeString(post.Excerpt), "</blockquote>")
+		}
+		write(post.Description)
+	case *gotumblr.ChatPost:
+		item.Title = post.Title
+		dialogue := post.Dialogue
+		if len(dialogue) == 0 {
+			dialogue = gotumblr.ParseConversation(post.Body)
+		}
+		for _, entry := range dialogue {
+			write("<p>")
+			if entry.Label != "" {
+				write("<strong>", html.EscapeString(entry.Label), "</strong> ")
+			}
+			write(html.EscapeString(entry.Phrase), "</p>")
+		}
+	case *gotumblr.AnswerPost:
+		asker := html.EscapeString(post.AskingName)
+		if asker == "" {
+			asker = "Anonymous"
+		} else if post.AskingURL != "" {
+			asker = `<a href="` + html.EscapeString(post.AskingURL) + `">` + asker + "</a>"
+		}
+		write("<blockquote><p>", asker, " asked: ", post.Question, "</p></blockquote>", post.Answer)
+	case *gotumblr.AudioPost:
+		item.Title = strings.Join(nonEmpty(post.Artist, post.TrackName), " — ")
+		if post.AlbumArt != "" {
+			write(`<p><img src="`, html.EscapeString(post.AlbumArt), `" alt="`, html.EscapeString(post.Album), `"></p>`)
+		}
+		write(post.Player, post.Caption)
+		if post.AudioType == "tumblr" && post.AudioURL != "" {
+			item.Enclosure = &Enclosure{URL: post.AudioURL, Type: mediaType(post.AudioURL, "audio/mpeg")}
+		}
+	case *gotumblr.VideoPost:
+		if len(post.Player) != 0 {
+			write(post.Player[len(post.Player)-1].EmbedCode)
+		} else if post.ThumbnailURL != "" {
+			write(`<p><a href="`, html.EscapeString(base.PostURL), `"><img src="`, html.EscapeString(post.ThumbnailURL), `" alt="video"></a></p>`)
+		}
+		write(post.Caption)
+		if post.VideoType == "tumblr" && post.VideoURL != "" {
+			item.Enclosure = &Enclosure{URL: post.VideoURL, Type: mediaType(post.VideoURL, "video/mp4")}
+		}
+	}
+	item.Content = content.String()
+	if item.Title == "" {
+		item.Title = summaryTitle(base.Summary)
+	}
+	return item
+}
+
+// summaryTitle shortens the summary of a post to a title, on a word boundary.
+func summaryTitle(summary string) string {
+	summary = strings.Join(strings.Fields(summary), " ")
+	if utf8.RuneCountInString(summary) <= titleLength {
+		return summary
+	}
+	runes := []rune(summary)[:titleLength]
+	title := string(runes)
+	if space := strings.LastIndex(title, " "); space > titleLength/2 {
+		title = title[:space]
+	}
+	return title + "…"
+}
+
+// mediaType returns the MIME type of a media file from the extension in its URL, or def.
+func mediaType(rawURL, def string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		if t := mime.TypeByExtension(path.Ext(u.Path)); t != "" {
+			return t
+		}
+	}
+	return def
+}
+
+func nonEmpty(values ...string) []string {
+	var kept []string
+	for _, value := range values {
+		if value != "" {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
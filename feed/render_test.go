@@ -0,0 +1,101 @@
+package feed_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spbr/gotumblr"
+	"github.com/spbr/gotumblr/feed"
+)
+
+func base(postType string) gotumblr.BasePost {
+	return gotumblr.BasePost{
+		ID: 7, PostType: postType, BlogName: "staff", PostURL: "https://staff.tumblr.com/post/7",
+		Tags: []string{"a", "b"}, Timestamp: 1708444800, Summary: "the summary",
+	}
+}
+
+func TestFromPost(t *testing.T) {
+	tests := []struct {
+		name    string
+		post    gotumblr.Post
+		title   string
+		content string
+	}{
+		{"text", &gotumblr.TextPost{BasePost: base("text"), Title: "Hello", Body: "<p>body</p>"},
+			"Hello", "<p>body</p>"},
+		{"photo", &gotumblr.PhotoPost{BasePost: base("photo"), Caption: "<p>caption</p>", LinkURL: "https://example.com/",
+			Photos: []gotumblr.PhotoObject{{Caption: `a "cat"`, OriginalSize: gotumblr.AltSize{URL: "https://64.media.tumblr.com/a.jpg"}}}},
+			"the summary", `<p><a href="https://example.com/"><img src="https://64.media.tumblr.com/a.jpg" alt="a &#34;cat&#34;"></a></p><p>caption</p>`},
+		{"quote", &gotumblr.QuotePost{BasePost: base("quote"), Text: "To be", Source: "Hamlet"},
+			"the summary", "<blockquote>To be</blockquote><p>— Hamlet</p>"},
+		{"link", &gotumblr.LinkPost{BasePost: base("link"), URL: "https://example.com/?a=1&b=2", Excerpt: "x < y", Description: "<p>d</p>"},
+			"the summary", `<p><a href="https://example.com/?a=1&amp;b=2">https://example.com/?a=1&amp;b=2</a></p><blockquote>x &lt; y</blockquote><p>d</p>`},
+		{"chat", &gotumblr.ChatPost{BasePost: base("chat"), Title: "Talk", Body: "A: hi\nno label"},
+			"Talk", "<p><strong>A:</strong> hi</p><p>no label</p>"},
+		{"answer", &gotumblr.AnswerPost{BasePost: base("answer"), AskingName: "bob", AskingURL: "https://bob.tumblr.com/", Question: "Why?", Answer: "<p>Because.</p>"},
+			"the summary", `<blockquote><p><a href="https://bob.tumblr.com/">bob</a> asked: Why?</p></blockquote><p>Because.</p>`},
+		{"anonymous answer", &gotumblr.AnswerPost{BasePost: base("answer"), Question: "Who?", Answer: "Me."},
+			"the summary", "<blockquote><p>Anonymous asked: Who?</p></blockquote>Me."},
+		{"audio", &gotumblr.AudioPost{BasePost: base("audio"), Artist: "Artist", TrackName: "Track", Player: "<audio></audio>", Caption: "<p>c</p>"},
+			"Artist — Track", "<audio></audio><p>c</p>"},
+		{"video", &gotumblr.VideoPost{BasePost: base("video"), Caption: "<p>c</p>",
+			Player: []gotumblr.PlayerInfo{{Width: 250, EmbedCode: "<small>"}, {Width: 500, EmbedCode: "<large>"}}},
+			"the summary", "<large><p>c</p>"},
+		{"video without player", &gotumblr.VideoPost{BasePost: base("video"), ThumbnailURL: "https://example.com/t.jpg"},
+			"the summary", `<p><a href="https://staff.tumblr.com/post/7"><img src="https://example.com/t.jpg" alt="video"></a></p>`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item := feed.FromPost(test.post)
+			if item.Title != test.title {
+				t.Errorf("title %q, want %q", item.Title, test.title)
+			}
+			if item.Content != test.content {
+				t.Errorf("content %s, want %s", item.Content, test.content)
+			}
+			if item.ID != "https://staff.tumblr.com/post/7" || item.Link != item.ID || item.Author != "staff" ||
+				strings.Join(item.Categories, ",") != "a,b" || item.Published.Unix() != 1708444800 {
+				t.Errorf("item %+v, want the URL, blog, tags and time of the post", item)
+			}
+			if item.Enclosure != nil {
+				t.Errorf("enclosure %+v, want none", item.Enclosure)
+			}
+		})
+	}
+}
+
+func TestFromPostEnclosure(t *testing.T) {
+	tests := []struct {
+		post gotumblr.Post
+		want *feed.Enclosure
+	}{
+		{&gotumblr.AudioPost{BasePost: base("audio"), AudioType: "tumblr", AudioURL: "https://a.tumblr.com/tumblr_x.mp3"},
+			&feed.Enclosure{URL: "https://a.tumblr.com/tumblr_x.mp3", Type: "audio/mpeg"}},
+		{&gotumblr.AudioPost{BasePost: base("audio"), AudioType: "tumblr", AudioURL: "https://a.tumblr.com/tumblr_x"},
+			&feed.Enclosure{URL: "https://a.tumblr.com/tumblr_x", Type: "audio/mpeg"}},
+		{&gotumblr.AudioPost{BasePost: base("audio"), AudioType: "soundcloud", AudioURL: "https://soundcloud.com/x"}, nil},
+		{&gotumblr.VideoPost{BasePost: base("video"), VideoType: "tumblr", VideoURL: "https://va.media.tumblr.com/tumblr_x.mp4"},
+			&feed.Enclosure{URL: "https://va.media.tumblr.com/tumblr_x.mp4", Type: "video/mp4"}},
+		{&gotumblr.VideoPost{BasePost: base("video"), VideoType: "youtube", VideoURL: "https://youtube.com/x"}, nil},
+	}
+	for _, test := range tests {
+		item := feed.FromPost(test.post)
+		if (item.Enclosure == nil) != (test.want == nil) || item.Enclosure != nil && *item.Enclosure != *test.want {
+			t.Errorf("%T: enclosure %+v, want %+v", test.post, item.Enclosure, test.want)
+		}
+	}
+}
+
+func TestFromPostSummaryTitle(t *testing.T) {
+	post := &gotumblr.TextPost{BasePost: base("text")}
+	post.Summary = strings.Repeat("word ", 30)
+	item := feed.FromPost(post)
+	if !strings.HasSuffix(item.Title, "word…") || len([]rune(item.Title)) > 81 {
+		t.Errorf("title %q, want the summary cut on a word boundary", item.Title)
+	}
+	post.PostURL = ""
+	if item := feed.FromPost(post); item.ID != "7" {
+		t.Errorf("id %q, want the post id without a URL", item.ID)
+	}
+}
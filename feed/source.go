@@ -0,0 +1,76 @@
+package feed
+
+import (
+	"context"
+	"sort"
+
+	"github.com/spbr/gotumblr"
+)
+
+// Source returns the posts of a feed, newest first.
+type Source func(ctx context.Context) ([]gotumblr.Post, error)
+
+// Posts returns a Source of the posts of several blogs, merged newest first.
+// options are the options of Posts, e.g. tag to follow a tag across the blogs,
+// and limit for the number of posts fetched from each blog.
+func Posts(client gotumblr.TumblrReader, blogs []gotumblr.BlogIdentifier, options map[string]string) Source {
+	return func(ctx context.Context) ([]gotumblr.Post, error) {
+		var posts []gotumblr.Post
+		for _, blog := range blogs {
+			response, err := withContext(ctx, client).Posts(blog, "", copyOptions(options))
+			if err != nil {
+				return nil, err
+			}
+			decoded, err := gotumblr.DecodePosts(response.Posts)
+			if err != nil {
+				return nil, err
+			}
+			posts = append(posts, decoded...)
+		}
+		sort.SliceStable(posts, func(i, j int) bool {
+			return posts[i].Base().Timestamp > posts[j].Base().Timestamp
+		})
+		return posts, nil
+	}
+}
+
+// Likes returns a Source of the posts a blog liked, most recently liked first.
+// options are the options of BlogLikes.
+func Likes(client gotumblr.TumblrReader, blog gotumblr.BlogIdentifier, options map[string]string) Source {
+	return func(ctx context.Context) ([]gotumblr.Post, error) {
+		response, err := withContext(ctx, client).BlogLikes(blog, copyOptions(options))
+		if err != nil {
+			return nil, err
+		}
+		return gotumblr.DecodePosts(response.LikedPosts)
+	}
+}
+
+// Tagged returns a Source of the posts with a tag, from all the blogs.
+// options are the options of Tagged.
+func Tagged(client gotumblr.TumblrReader, tag string, options map[string]string) Source {
+	return func(ctx context.Context) ([]gotumblr.Post, error) {
+		posts, err := withContext(ctx, client).Tagged(tag, copyOptions(options))
+		if err != nil {
+			return nil, err
+		}
+		return gotumblr.DecodePosts(posts)
+	}
+}
+
+// withContext returns the client bound to ctx, if it is a *gotumblr.TumblrRestClient.
+func withContext(ctx context.Context, client gotumblr.TumblrReader) gotumblr.TumblrReader {
+	if restClient, ok := client.(*gotumblr.TumblrRestClient); ok {
+		return restClient.WithContext(ctx)
+	}
+	return client
+}
+
+// copyOptions copies options, as the client methods add to them.
+func copyOptions(options map[string]string) map[string]string {
+	copied := make(map[string]string, len(options))
+	for key, value := range options {
+		copied[key] = value
+	}
+	return copied
+}
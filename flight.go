@@ -0,0 +1,69 @@
+package gotumblr
+
+import (
+	"context"
+	"sync"
+)
+
+// flight is a GET request in progress, shared by the callers making the same request.
+type flight struct {
+	done    chan struct{} // closed once data and err are set
+	data    *CompleteResponse
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// flightGroup coalesces identical concurrent requests, so that a single request answers all the callers.
+// The zero value is ready to use.
+type flightGroup struct {
+	mutex   sync.Mutex
+	flights map[string]*flight
+}
+
+// do calls fn once for all the concurrent calls with the same key and returns its result.
+// A caller whose ctx is done returns ctx.Err() without waiting; the context passed to fn
+// is cancelled once every caller waiting for the result has returned this way.
+// It carries the values of the ctx of the first caller, e.g. its tracing span, but not its deadline.
+func (g *flightGroup) do(ctx context.Context, key string, fn func(context.Context) (*CompleteResponse, error)) (*CompleteResponse, error) {
+	g.mutex.Lock()
+	if g.flights == nil {
+		g.flights = map[string]*flight{}
+	}
+	f, ok := g.flights[key]
+	if !ok {
+		flightCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		g.flights[key] = f
+		go g.run(flightCtx, key, f, fn)
+	}
+	f.waiters++
+	g.mutex.Unlock()
+
+	select {
+	case <-f.done:
+		return f.data, f.err
+	case <-ctx.Done():
+		g.mutex.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			f.cancel()
+			if g.flights[key] == f {
+				delete(g.flights, key)
+			}
+		}
+		g.mutex.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (g *flightGroup) run(ctx context.Context, key string, f *flight, fn func(context.Context) (*CompleteResponse, error)) {
+	f.data, f.err = fn(ctx)
+	g.mutex.Lock()
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+	g.mutex.Unlock()
+	f.cancel()
+	close(f.done)
+}
@@ -1,5 +1,7 @@
 package gotumblr
 
+import "time"
+
 // FollowedBlog holds information about a blog that the user follows
 type FollowedBlog struct {
 	Name        string
@@ -8,3 +10,8 @@ type FollowedBlog struct {
 	Title       string
 	Description string
 }
+
+// UpdatedTime returns the time the blog was last updated in UTC.
+func (b *FollowedBlog) UpdatedTime() time.Time {
+	return unixTime(b.Updated)
+}
@@ -2,6 +2,6 @@ package gotumblr
 
 // FollowersResponse holds information about the users that follow a Tumblr blog
 type FollowersResponse struct {
-	TotalUsers int64
+	TotalUsers int64 `json:"total_users"`
 	Users      []User
 }
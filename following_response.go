@@ -2,6 +2,6 @@ package gotumblr
 
 // FollowingResponse holds information about the blogs a user follows
 type FollowingResponse struct {
-	TotalBlogs int64
+	TotalBlogs int64 `json:"total_blogs"`
 	Blogs      []FollowedBlog
 }
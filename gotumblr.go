@@ -3,16 +3,18 @@
 package gotumblr
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net/http"
 )
 
 //TumblrRestClient defines a Go Client for the Tumblr API.
 type TumblrRestClient struct {
 	request *TumblrRequest
+	ctx     context.Context
 }
 
 //NewTumblrRestClient initializes the TumblrRestClient, creating TumblrRequest that deals with all request formatting.
@@ -22,61 +24,160 @@ type TumblrRestClient struct {
 //oauthSecret is the user specific secret, received from the /access_token endpoint.
 //host is the host that you are tryng to send information to (e.g. http://api.tumblr.com).
 func NewTumblrRestClient(consumerKey, consumerSecret, oauthToken, oauthSecret, callbackURL, host string) *TumblrRestClient {
-	return &TumblrRestClient{NewTumblrRequest(consumerKey, consumerSecret, oauthToken, oauthSecret, callbackURL, host)}
+	return &TumblrRestClient{request: NewTumblrRequest(consumerKey, consumerSecret, oauthToken, oauthSecret, callbackURL, host)}
+}
+
+//WithContext returns a client sharing the settings of trc whose API requests use ctx:
+//they fail with ctx.Err() once ctx is done.
+func (trc *TumblrRestClient) WithContext(ctx context.Context) *TumblrRestClient {
+	return &TumblrRestClient{request: trc.request, ctx: ctx}
+}
+
+//context returns the context of the requests of the client.
+func (trc *TumblrRestClient) context() context.Context {
+	if trc.ctx == nil {
+		return context.Background()
+	}
+	return trc.ctx
+}
+
+//SetHTTPClient sets the http.Client used to send all requests, including avatar and media downloads.
+func (trc *TumblrRestClient) SetHTTPClient(client *http.Client) {
+	trc.request.SetHTTPClient(client)
+}
+
+//SetStrict enables or disables strict decoding of the responses, see TumblrRequest.SetStrict.
+func (trc *TumblrRestClient) SetStrict(strict bool) {
+	trc.request.SetStrict(strict)
+}
+
+//DecodePost decodes a post of a response, see DecodePost. Unlike the DecodePost function,
+//it reports the fields of the post type that are missing from the post in strict mode.
+func (trc *TumblrRestClient) DecodePost(data json.RawMessage) (Post, error) {
+	return trc.request.DecodePost(data)
+}
+
+//DecodePosts decodes the posts of a response with TumblrRestClient.DecodePost.
+func (trc *TumblrRestClient) DecodePosts(data []json.RawMessage) ([]Post, error) {
+	return trc.request.DecodePosts(data)
+}
+
+//SetResponseHook sets a function that is called with every response received from the API,
+//giving access to its HTTP status, headers and meta information, see TumblrRequest.SetResponseHook.
+func (trc *TumblrRestClient) SetResponseHook(hook func(*CompleteResponse)) {
+	trc.request.SetResponseHook(hook)
+}
+
+//SetCache sets the cache of the responses to GET requests, see Cache and TumblrRequest.SetCache.
+func (trc *TumblrRestClient) SetCache(cache *Cache) {
+	trc.request.SetCache(cache)
+}
+
+//SetHooks sets the hooks called around every request sent to the API, see Hooks and TumblrRequest.SetHooks.
+func (trc *TumblrRestClient) SetHooks(hooks Hooks) {
+	trc.request.SetHooks(hooks)
+}
+
+//SetRateLimiter sets the limiter every request waits for before being sent,
+//see RateLimiter and TumblrRequest.SetRateLimiter.
+func (trc *TumblrRestClient) SetRateLimiter(limiter RateLimiter) {
+	trc.request.SetRateLimiter(limiter)
+}
+
+//get makes a GET request, checks the status of the response and decodes it into result.
+func (trc *TumblrRestClient) get(requestURL string, params map[string]string, result interface{}) error {
+	data, err := trc.request.GetContext(trc.context(), requestURL, params)
+	if err != nil {
+		return err
+	}
+	return trc.handle(data, 200, result)
+}
+
+//post makes a POST request and checks that the response has the expected status.
+func (trc *TumblrRestClient) post(requestURL string, params map[string]string, expectedStatus int) error {
+	data, err := trc.request.PostContext(trc.context(), requestURL, params)
+	if err != nil {
+		return err
+	}
+	return trc.handle(data, expectedStatus, nil)
+}
+
+//handle checks the status of a response and decodes it into result, unless result is nil.
+func (trc *TumblrRestClient) handle(data *CompleteResponse, expectedStatus int, result interface{}) error {
+	if err := trc.request.CheckResponse(data, expectedStatus); err != nil {
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	return trc.request.Decode(data.Response, result)
 }
 
 //Info retrieves the user information.
 func (trc *TumblrRestClient) Info() (*UserInfoResponse, error) {
-	data, err := trc.request.Get("/v2/user/info", map[string]string{})
-	if err != nil {
+	var result UserInfoResponse
+	if err := trc.get("/v2/user/info", map[string]string{}, &result); err != nil {
 		return nil, err
 	}
-	var result UserInfoResponse
-	json.Unmarshal(data.Response, &result)
 	return &result, nil
 }
 
-//Avatar etrieves the url of the blog's avatar.
+//avatarSizes are the sizes (in pixels) the avatar endpoint can return.
+var avatarSizes = []int{16, 24, 30, 40, 48, 64, 96, 128, 512}
+
+//Avatar retrieves the url of the blog's avatar.
 //size can be: 16, 24, 30, 40, 48, 64, 96, 128 or 512.
-func (trc *TumblrRestClient) Avatar(blogname string, size int) (*AvatarResponse, error) {
-	requestURL := trc.request.host + fmt.Sprintf("/v2/blog/%s/avatar/%d", blogname, size)
-	httpRequest, err := http.NewRequest("GET", requestURL, nil)
-	if err != nil {
-		return nil, err
+func (trc *TumblrRestClient) Avatar(blog BlogIdentifier, size int) (*AvatarResponse, error) {
+	validSize := false
+	for _, avatarSize := range avatarSizes {
+		validSize = validSize || size == avatarSize
 	}
-	transport := &http.Transport{}
-	httpResponse, err := transport.RoundTrip(httpRequest)
-	if err != nil {
-		return nil, err
+	if !validSize {
+		return nil, fmt.Errorf("gotumblr: invalid avatar size %d", size)
 	}
-	defer httpResponse.Body.Close()
-	body, err := ioutil.ReadAll(httpResponse.Body)
+	requestURL, err := blog.apiPath(fmt.Sprintf("avatar/%d", size))
 	if err != nil {
 		return nil, err
 	}
-	data, err := trc.request.JSONParse(body)
+	data, location, err := trc.request.GetRedirectContext(trc.context(), requestURL, map[string]string{})
 	if err != nil {
 		return nil, err
 	}
+	if location != "" {
+		return &AvatarResponse{Avatar_url: location}, nil
+	}
 	var result AvatarResponse
-	json.Unmarshal(data.Response, &result)
+	if err := trc.handle(data, 200, &result); err != nil {
+		return nil, err
+	}
 	return &result, nil
 }
 
+//AvatarImage writes the image of the blog's avatar to w.
+//size can be: 16, 24, 30, 40, 48, 64, 96, 128 or 512.
+//It returns the number of bytes written.
+func (trc *TumblrRestClient) AvatarImage(blog BlogIdentifier, size int, w io.Writer) (int64, error) {
+	avatar, err := trc.Avatar(blog, size)
+	if err != nil {
+		return 0, err
+	}
+	if avatar.Avatar_url == "" {
+		return 0, errors.New("gotumblr: no avatar url in response")
+	}
+	return trc.request.DownloadContext(trc.context(), avatar.Avatar_url, w)
+}
+
 //Likes retrieves the likes of the given user.
 //options can be:
 //limit: the number of results to return, inclusive;
-//offset: liked post number to start at.
+//offset: liked post number to start at;
+//before: retrieve posts liked before this timestamp (see TimeRange);
+//after: retrieve posts liked after this timestamp (see TimeRange).
 func (trc *TumblrRestClient) Likes(options map[string]string) (*LikesResponse, error) {
-	data, err := trc.request.Get("/v2/user/likes", options)
-	if err != nil {
+	var result LikesResponse
+	if err := trc.get("/v2/user/likes", options, &result); err != nil {
 		return nil, err
 	}
-	if data.Meta.Status != 200 {
-		return nil, errors.New(data.Meta.Msg)
-	}
-	var result LikesResponse
-	json.Unmarshal(data.Response, &result)
 	return &result, nil
 }
 
@@ -85,15 +186,10 @@ func (trc *TumblrRestClient) Likes(options map[string]string) (*LikesResponse, e
 //limit: the number of results to return;
 //offset: result number to start at.
 func (trc *TumblrRestClient) Following(options map[string]string) (*FollowingResponse, error) {
-	data, err := trc.request.Get("/v2/user/following", options)
-	if err != nil {
+	var result FollowingResponse
+	if err := trc.get("/v2/user/following", options, &result); err != nil {
 		return nil, err
 	}
-	if data.Meta.Status != 200 {
-		return nil, errors.New(data.Meta.Msg)
-	}
-	var result FollowingResponse
-	json.Unmarshal(data.Response, &result)
 	return &result, nil
 }
 
@@ -106,41 +202,31 @@ func (trc *TumblrRestClient) Following(options map[string]string) (*FollowingRes
 //reblog_info: whether to return reblog information about the posts;
 //notes_info: whether to return notes information about the posts.
 func (trc *TumblrRestClient) Dashboard(options map[string]string) (*DraftsResponse, error) {
-	data, err := trc.request.Get("/v2/user/dashboard", options)
-	if err != nil {
+	var result DraftsResponse
+	if err := trc.get("/v2/user/dashboard", options, &result); err != nil {
 		return nil, err
 	}
-	if data.Meta.Status != 200 {
-		return nil, errors.New(data.Meta.Msg)
-	}
-	var result DraftsResponse
-	json.Unmarshal(data.Response, &result)
 	return &result, nil
 }
 
 //Tagged retrieves a list of posts with the given tag.
 //tag: the tag you want to look for.
 //options can be:
-//before: the timestamp of when you'd like to see posts before;
+//before: the timestamp of when you'd like to see posts before (see TimeRange);
 //limit: the number of results to return;
 //filter: the post format you want to get(e.g html, text, raw).
 func (trc *TumblrRestClient) Tagged(tag string, options map[string]string) ([]json.RawMessage, error) {
 	options["tag"] = tag
 	options["api_key"] = trc.request.apiKey
-	data, err := trc.request.Get("/v2/tagged", options)
-	if err != nil {
+	var result []json.RawMessage
+	if err := trc.get("/v2/tagged", options, &result); err != nil {
 		return nil, err
 	}
-	if data.Meta.Status != 200 {
-		return nil, errors.New(data.Meta.Msg)
-	}
-	result := []json.RawMessage{}
-	json.Unmarshal(data.Response, &result)
 	return result, nil
 }
 
 //Posts retrieves a list of posts from a blog.
-//blogname: the name of the blog you want to get posts from (e.g. mgterzieva.tumblr.com).
+//blog: the blog you want to get posts from.
 //postsType: the type of the posts you want to get
 //(e.g. text, quote, link, answer, video, audio, photo, chat, all).
 //options can be:
@@ -148,77 +234,74 @@ func (trc *TumblrRestClient) Tagged(tag string, options map[string]string) ([]js
 //tag: return only posts with this tag;
 //limit: the number of posts to return;
 //offset: the number of the post you want to start from;
+//before: retrieve posts published before this timestamp (see TimeRange);
 //filter: return only posts with a specific format(e.g. html, text, raw).
-func (trc *TumblrRestClient) Posts(blogname, postsType string, options map[string]string) (*PostsResponse, error) {
-	var requestURL string
-	if postsType == "" {
-		requestURL = fmt.Sprintf("/v2/blog/%s/posts", blogname)
-	} else {
-		requestURL = fmt.Sprintf("/v2/blog/%s/posts/%s", blogname, postsType)
+func (trc *TumblrRestClient) Posts(blog BlogIdentifier, postsType string, options map[string]string) (*PostsResponse, error) {
+	endpoint := "posts"
+	if postsType != "" {
+		endpoint += "/" + postsType
 	}
-	options["api_key"] = trc.request.apiKey
-	data, err := trc.request.Get(requestURL, options)
+	requestURL, err := blog.apiPath(endpoint)
 	if err != nil {
 		return nil, err
 	}
-	if data.Meta.Status != 200 {
-		return nil, errors.New(data.Meta.Msg)
-	}
+	options["api_key"] = trc.request.apiKey
 	var result PostsResponse
-	json.Unmarshal(data.Response, &result)
+	if err := trc.get(requestURL, options, &result); err != nil {
+		return nil, err
+	}
 	return &result, nil
 }
 
 //BlogInfo retrieves general information about the blog.
-//blogname: name of the blog you want to get information about(e.g. mgterzieva.tumblr.com).
-func (trc *TumblrRestClient) BlogInfo(blogname string) (*BlogInfoResponse, error) {
-	requestURL := fmt.Sprintf("/v2/blog/%s/info", blogname)
-	options := map[string]string{"api_key": trc.request.apiKey}
-	data, err := trc.request.Get(requestURL, options)
+//blog: the blog you want to get information about.
+func (trc *TumblrRestClient) BlogInfo(blog BlogIdentifier) (*BlogInfoResponse, error) {
+	requestURL, err := blog.apiPath("info")
 	if err != nil {
 		return nil, err
 	}
+	options := map[string]string{"api_key": trc.request.apiKey}
 	var result BlogInfoResponse
-	json.Unmarshal(data.Response, &result)
+	if err := trc.get(requestURL, options, &result); err != nil {
+		return nil, err
+	}
 	return &result, nil
 }
 
 //Followers retrieves the followers of the blog given.
-//blogname: name of the blog whose followers you want to get.
+//blog: the blog whose followers you want to get.
 //optons can be:
 //limit: the number of results to return, inclusive;
 //offset: result to start at.
-func (trc *TumblrRestClient) Followers(blogname string, options map[string]string) (*FollowersResponse, error) {
-	requestURL := fmt.Sprintf("/v2/blog/%s/followers", blogname)
-	data, err := trc.request.Get(requestURL, options)
+func (trc *TumblrRestClient) Followers(blog BlogIdentifier, options map[string]string) (*FollowersResponse, error) {
+	requestURL, err := blog.apiPath("followers")
 	if err != nil {
 		return nil, err
 	}
-	if data.Meta.Status != 200 {
-		return nil, errors.New(data.Meta.Msg)
-	}
 	var result FollowersResponse
-	json.Unmarshal(data.Response, &result)
+	if err := trc.get(requestURL, options, &result); err != nil {
+		return nil, err
+	}
 	return &result, nil
 }
 
 //BlogLikes retrieves the likes of blog given.
-//blogname: name of the blog whose likes you want to get.
+//blog: the blog whose likes you want to get.
 //options can be:
 //limit: how many likes do you want to get;
-//offset: the number of the like you want to start from.
-func (trc *TumblrRestClient) BlogLikes(blogname string, options map[string]string) (*LikesResponse, error) {
-	requestURL := fmt.Sprintf("/v2/blog/%s/likes", blogname)
-	options["api_key"] = trc.request.apiKey
-	data, err := trc.request.Get(requestURL, options)
+//offset: the number of the like you want to start from;
+//before: retrieve posts liked before this timestamp (see TimeRange);
+//after: retrieve posts liked after this timestamp (see TimeRange).
+func (trc *TumblrRestClient) BlogLikes(blog BlogIdentifier, options map[string]string) (*LikesResponse, error) {
+	requestURL, err := blog.apiPath("likes")
 	if err != nil {
 		return nil, err
 	}
-	if data.Meta.Status != 200 {
-		return nil, errors.New(data.Meta.Msg)
-	}
+	options["api_key"] = trc.request.apiKey
 	var result LikesResponse
-	json.Unmarshal(data.Response, &result)
+	if err := trc.get(requestURL, options, &result); err != nil {
+		return nil, err
+	}
 	return &result, nil
 }
 
@@ -227,34 +310,30 @@ func (trc *TumblrRestClient) BlogLikes(blogname string, options map[string]strin
 //limit: the number of results to return;
 //offset: post number to start at;
 //filter: specify posts' format(e.g. format="html", format="text", format="raw").
-func (trc *TumblrRestClient) Queue(blogname string, options map[string]string) (*DraftsResponse, error) {
-	requestURL := fmt.Sprintf("/v2/blog/%s/posts/queue", blogname)
-	data, err := trc.request.Get(requestURL, options)
+func (trc *TumblrRestClient) Queue(blog BlogIdentifier, options map[string]string) (*DraftsResponse, error) {
+	requestURL, err := blog.apiPath("posts/queue")
 	if err != nil {
 		return nil, err
 	}
-	if data.Meta.Status != 200 {
-		return nil, errors.New(data.Meta.Msg)
-	}
 	var result DraftsResponse
-	json.Unmarshal(data.Response, &result)
+	if err := trc.get(requestURL, options, &result); err != nil {
+		return nil, err
+	}
 	return &result, nil
 }
 
 //Drafts retrieves posts that are currently in the blog's drafts.
 //options can be:
 //filter: specify posts' format(e.g. format="html", format="text", format="raw").
-func (trc *TumblrRestClient) Drafts(blogname string, options map[string]string) (*DraftsResponse, error) {
-	requestURL := fmt.Sprintf("/v2/blog/%s/posts/draft", blogname)
-	data, err := trc.request.Get(requestURL, options)
+func (trc *TumblrRestClient) Drafts(blog BlogIdentifier, options map[string]string) (*DraftsResponse, error) {
+	requestURL, err := blog.apiPath("posts/draft")
 	if err != nil {
 		return nil, err
 	}
-	if data.Meta.Status != 200 {
-		return nil, errors.New(data.Meta.Msg)
-	}
 	var result DraftsResponse
-	json.Unmarshal(data.Response, &result)
+	if err := trc.get(requestURL, options, &result); err != nil {
+		return nil, err
+	}
 	return &result, nil
 }
 
@@ -262,46 +341,42 @@ func (trc *TumblrRestClient) Drafts(blogname string, options map[string]string)
 //options can be:
 //offset: post number to start at;
 //filter: specify posts' format(e.g. format="html", format="text", format="raw").
-func (trc *TumblrRestClient) Submission(blogname string, options map[string]string) (*DraftsResponse, error) {
-	requestURL := fmt.Sprintf("/v2/blog/%s/posts/submission", blogname)
-	data, err := trc.request.Get(requestURL, options)
+func (trc *TumblrRestClient) Submission(blog BlogIdentifier, options map[string]string) (*DraftsResponse, error) {
+	requestURL, err := blog.apiPath("posts/submission")
 	if err != nil {
 		return nil, err
 	}
-	if data.Meta.Status != 200 {
-		return nil, errors.New(data.Meta.Msg)
-	}
 	var result DraftsResponse
-	json.Unmarshal(data.Response, &result)
+	if err := trc.get(requestURL, options, &result); err != nil {
+		return nil, err
+	}
 	return &result, nil
 }
 
 //Follow a blog via a URL
-//blogname: the url of the blog to follow.
-func (trc *TumblrRestClient) Follow(blogname string) (bool, error) {
+//blog: the blog to follow; it cannot be identified only by its UUID.
+func (trc *TumblrRestClient) Follow(blog BlogIdentifier) (bool, error) {
 	requestURL := fmt.Sprintf("/v2/user/follow")
-	params := map[string]string{"url": blogname}
-	data, err := trc.request.Post(requestURL, params)
-	if err != nil {
-		return false, err
+	if blog.URL() == "" {
+		return false, fmt.Errorf("gotumblr: cannot follow %q without its URL", blog.String())
 	}
-	if data.Meta.Status != 200 {
-		return false, errors.New(data.Meta.Msg)
+	params := map[string]string{"url": blog.URL()}
+	if err := trc.post(requestURL, params, 200); err != nil {
+		return false, err
 	}
 	return true, nil
 }
 
 //Unfollow a blog via a URL
-//blogname: the url of the blog to unfollow.
-func (trc *TumblrRestClient) Unfollow(blogname string) (bool, error) {
+//blog: the blog to unfollow; it cannot be identified only by its UUID.
+func (trc *TumblrRestClient) Unfollow(blog BlogIdentifier) (bool, error) {
 	requestURL := fmt.Sprintf("/v2/user/unfollow")
-	params := map[string]string{"url": blogname}
-	data, err := trc.request.Post(requestURL, params)
-	if err != nil {
-		return false, err
+	if blog.URL() == "" {
+		return false, fmt.Errorf("gotumblr: cannot unfollow %q without its URL", blog.String())
 	}
-	if data.Meta.Status != 200 {
-		return false, errors.New(data.Meta.Msg)
+	params := map[string]string{"url": blog.URL()}
+	if err := trc.post(requestURL, params, 200); err != nil {
+		return false, err
 	}
 	return true, nil
 }
@@ -309,273 +384,317 @@ func (trc *TumblrRestClient) Unfollow(blogname string) (bool, error) {
 //Like post of a given blog.
 //id: the id of the post you want to like.
 //reblog_key: the reblog key for the post id.
-func (trc *TumblrRestClient) Like(id, reblogKey string) (bool, error) {
+func (trc *TumblrRestClient) Like(id PostID, reblogKey string) (bool, error) {
 	requestURL := fmt.Sprintf("/v2/user/like")
-	params := map[string]string{"id": id, "reblog_key": reblogKey}
-	data, err := trc.request.Post(requestURL, params)
-	if err != nil {
+	params := map[string]string{"id": id.String(), "reblog_key": reblogKey}
+	if err := trc.post(requestURL, params, 200); err != nil {
 		return false, err
 	}
-	if data.Meta.Status != 200 {
-		return false, errors.New(data.Meta.Msg)
-	}
 	return true, nil
 }
 
 //Unlike a post of a given blog.
 //id: the id of the post you want to unlike.
 //reblog_key: the reblog key for the post id.
-func (trc *TumblrRestClient) Unlike(id, reblogKey string) (bool, error) {
+func (trc *TumblrRestClient) Unlike(id PostID, reblogKey string) (bool, error) {
 	requestURL := fmt.Sprintf("/v2/user/unlike")
-	params := map[string]string{"id": id, "reblog_key": reblogKey}
-	data, err := trc.request.Post(requestURL, params)
-	if err != nil {
+	params := map[string]string{"id": id.String(), "reblog_key": reblogKey}
+	if err := trc.post(requestURL, params, 200); err != nil {
 		return false, err
 	}
-	if data.Meta.Status != 200 {
-		return false, errors.New(data.Meta.Msg)
-	}
 	return true, nil
 }
 
 //CreatePhoto creates a photo post or photoset on a blog.
-//blogname: the url of the blog you want to post to.
+//blog: the blog you want to post to.
 //options can be:
 //(with * are marked required options)
 //state: the state of the post(e.g. published, draft, queue, private);
+//publish_on: the GMT date and time a queued post is published (see PostTimes);
 //tags: a list of tags you want applied to the post;
 //tweet: manages the autotweet for this post: set to off for no tweet
 //or enter text to override the default tweet;
-//date: the GMT date and time of the post as a string;
+//date: the GMT date and time of the post as a string (see PostTimes);
 //format: sets the format type of the post(html or markdown);
 //slug: add a short text summary to the end of the post url;
 //caption: the caption that you want applied to the photo;
 //link: the 'click-through' url for the photo;
 //*source: the photo source url.
-func (trc *TumblrRestClient) CreatePhoto(blogname string, options map[string]string) (bool, error) {
-	requestURL := fmt.Sprintf("/v2/blog/%s/post", blogname)
-	options["type"] = "photo"
-	data, err := trc.request.Post(requestURL, options)
+func (trc *TumblrRestClient) CreatePhoto(blog BlogIdentifier, options map[string]string) (bool, error) {
+	requestURL, err := blog.apiPath("post")
 	if err != nil {
 		return false, err
 	}
-	if data.Meta.Status != 201 {
-		return false, errors.New(data.Meta.Msg)
+	options["type"] = "photo"
+	if err := trc.post(requestURL, options, 201); err != nil {
+		return false, err
 	}
 	return true, nil
 }
 
 //CreateText creates a text post on a blog.
-//blogname: the url of the blog you want to post to.
+//blog: the blog you want to post to.
 //options can be:
 //(with * are marked required options)
 //state: the state of the post(e.g. published, draft, queue, private);
+//publish_on: the GMT date and time a queued post is published (see PostTimes);
 //tags: a list of tags you want applied to the post;
 //tweet: manages the autotweet for this post: set to off for no tweet
 //or enter text to override the default tweet;
-//date: the GMT date and time of the post as a string;
+//date: the GMT date and time of the post as a string (see PostTimes);
 //format: sets the format type of the post(html or markdown);
 //slug: add a short text summary to the end of the post url;
 //title: the optional title of the post;
 //*body: the full text body.
-func (trc *TumblrRestClient) CreateText(blogname string, options map[string]string) (bool, error) {
-	requestURL := fmt.Sprintf("/v2/blog/%s/post", blogname)
-	options["type"] = "text"
-	data, err := trc.request.Post(requestURL, options)
+func (trc *TumblrRestClient) CreateText(blog BlogIdentifier, options map[string]string) (bool, error) {
+	requestURL, err := blog.apiPath("post")
 	if err != nil {
 		return false, err
 	}
-	if data.Meta.Status != 201 {
-		return false, errors.New(data.Meta.Msg)
+	options["type"] = "text"
+	if err := trc.post(requestURL, options, 201); err != nil {
+		return false, err
 	}
 	return true, nil
 }
 
 //CreateQuote creates a quote post on a blog.
-//blogname: the url of the blog you want to post to.
+//blog: the blog you want to post to.
 //options can be:
 //(with * are marked required options)
 //state: the state of the post(e.g. published, draft, queue, private);
+//publish_on: the GMT date and time a queued post is published (see PostTimes);
 //tags: a list of tags you want applied to the post;
 //tweet: manages the autotweet for this post: set to off for no tweet
 //or enter text to override the default tweet;
-//date: the GMT date and time of the post as a string;
+//date: the GMT date and time of the post as a string (see PostTimes);
 //format: sets the format type of the post(html or markdown);
 //slug: add a short text summary to the end of the post url;
 //*quote: the full text of the quote;
 //source: the cited source of the quote.
-func (trc *TumblrRestClient) CreateQuote(blogname string, options map[string]string) (bool, error) {
-	requestURL := fmt.Sprintf("/v2/blog/%s/post", blogname)
-	options["type"] = "quote"
-	data, err := trc.request.Post(requestURL, options)
+func (trc *TumblrRestClient) CreateQuote(blog BlogIdentifier, options map[string]string) (bool, error) {
+	requestURL, err := blog.apiPath("post")
 	if err != nil {
 		return false, err
 	}
-	if data.Meta.Status != 201 {
-		return false, errors.New(data.Meta.Msg)
+	options["type"] = "quote"
+	if err := trc.post(requestURL, options, 201); err != nil {
+		return false, err
 	}
 	return true, nil
 }
 
 //CreateLink creates a link post on a blog.
-//blogname: the url of the blog you want to post to.
+//blog: the blog you want to post to.
 //options can be:
 //(with * are marked required options)
 //state: the state of the post(e.g. published, draft, queue, private);
+//publish_on: the GMT date and time a queued post is published (see PostTimes);
 //tags: a list of tags you want applied to the post;
 //tweet: manages the autotweet for this post: set to off for no tweet
 //or enter text to override the default tweet;
-//date: the GMT date and time of the post as a string;
+//date: the GMT date and time of the post as a string (see PostTimes);
 //format: sets the format type of the post(html or markdown);
 //slug: add a short text summary to the end of the post url;
 //title: the title of the page the link points to;
 //*url: the link you are posting;
 //description: the description of the link you are posting.
-func (trc *TumblrRestClient) CreateLink(blogname string, options map[string]string) (bool, error) {
-	requestURL := fmt.Sprintf("/v2/blog/%s/post", blogname)
-	options["type"] = "link"
-	data, err := trc.request.Post(requestURL, options)
+func (trc *TumblrRestClient) CreateLink(blog BlogIdentifier, options map[string]string) (bool, error) {
+	requestURL, err := blog.apiPath("post")
 	if err != nil {
 		return false, err
 	}
-	if data.Meta.Status != 201 {
-		return false, errors.New(data.Meta.Msg)
+	options["type"] = "link"
+	if err := trc.post(requestURL, options, 201); err != nil {
+		return false, err
 	}
 	return true, nil
 }
 
 //CreateChatPost creates a chat post on a blog.
-//blogname: the url of the blog you want to post to.
+//blog: the blog you want to post to.
 //options can be:
 //(with * are marked required options)
 //state: the state of the post(e.g. published, draft, queue, private);
+//publish_on: the GMT date and time a queued post is published (see PostTimes);
 //tags: a list of tags you want applied to the post;
 //tweet: manages the autotweet for this post: set to off for no tweet
 //or enter text to override the default tweet;
-//date: the GMT date and time of the post as a string;
+//date: the GMT date and time of the post as a string (see PostTimes);
 //format: sets the format type of the post(html or markdown);
 //slug: add a short text summary to the end of the post url;
 //title: the title of the chat;
-//*conversation: the text of the conversation/chat, with dialogue labels.
-func (trc *TumblrRestClient) CreateChatPost(blogname string, options map[string]string) (bool, error) {
-	requestURL := fmt.Sprintf("/v2/blog/%s/post", blogname)
-	options["type"] = "chat"
-	data, err := trc.request.Post(requestURL, options)
+//*conversation: the text of the conversation/chat, with dialogue labels
+//(see FormatConversation; ChatPostParams builds all the options from structured dialogue).
+func (trc *TumblrRestClient) CreateChatPost(blog BlogIdentifier, options map[string]string) (bool, error) {
+	requestURL, err := blog.apiPath("post")
 	if err != nil {
 		return false, err
 	}
-	if data.Meta.Status != 201 {
-		return false, errors.New(data.Meta.Msg)
+	options["type"] = "chat"
+	if err := trc.post(requestURL, options, 201); err != nil {
+		return false, err
 	}
 	return true, nil
 }
 
 //CreateAudio creates an audio post on a blog.
-//blogname: the url of the blog you want to post to.
+//blog: the blog you want to post to.
 //options can be:
 //(with * are marked required options)
 //state: the state of the post(e.g. published, draft, queue, private);
+//publish_on: the GMT date and time a queued post is published (see PostTimes);
 //tags: a list of tags you want applied to the post;
 //tweet: manages the autotweet for this post: set to off for no tweet
 //or enter text to override the default tweet;
-//date: the GMT date and time of the post as a string;
+//date: the GMT date and time of the post as a string (see PostTimes);
 //format: sets the format type of the post(html or markdown);
 //slug: add a short text summary to the end of the post url;
 //caption: the caption of the post;
 //*external_url: the url of the site that hosts the audio file.
-func (trc *TumblrRestClient) CreateAudio(blogname string, options map[string]string) (bool, error) {
-	requestURL := fmt.Sprintf("/v2/blog/%s/post", blogname)
-	options["type"] = "audio"
-	data, err := trc.request.Post(requestURL, options)
+func (trc *TumblrRestClient) CreateAudio(blog BlogIdentifier, options map[string]string) (bool, error) {
+	requestURL, err := blog.apiPath("post")
 	if err != nil {
 		return false, err
 	}
-	if data.Meta.Status != 201 {
-		return false, errors.New(data.Meta.Msg)
+	options["type"] = "audio"
+	if err := trc.post(requestURL, options, 201); err != nil {
+		return false, err
 	}
 	return true, nil
 }
 
 //CreateVideo creates a video post on a blog.
-//blogname: the url of the blog you want to post to.
+//blog: the blog you want to post to.
 //options can be:
 //(with * are marked required options)
 //state: the state of the post(e.g. published, draft, queue, private);
+//publish_on: the GMT date and time a queued post is published (see PostTimes);
 //tags: a list of tags you want applied to the post;
 //tweet: manages the autotweet for this post: set to off for no tweet
 //or enter text to override the default tweet;
-//date: the GMT date and time of the post as a string;
+//date: the GMT date and time of the post as a string (see PostTimes);
 //format: sets the format type of the post(html or markdown);
 //slug: add a short text summary to the end of the post url;
 //caption: the caption for the post;
 //*embed: the html embed code for the video.
-func (trc *TumblrRestClient) CreateVideo(blogname string, options map[string]string) (bool, error) {
-	requestURL := fmt.Sprintf("/v2/blog/%s/post", blogname)
-	options["type"] = "video"
-	data, err := trc.request.Post(requestURL, options)
+func (trc *TumblrRestClient) CreateVideo(blog BlogIdentifier, options map[string]string) (bool, error) {
+	requestURL, err := blog.apiPath("post")
 	if err != nil {
 		return false, err
 	}
-	if data.Meta.Status != 201 {
-		return false, errors.New(data.Meta.Msg)
+	options["type"] = "video"
+	if err := trc.post(requestURL, options, 201); err != nil {
+		return false, err
 	}
 	return true, nil
 }
 
 //Reblog creates a reblog on the given blog.
-//blogname: the url of the blog you want to reblog to.
+//blog: the blog you want to reblog to.
 //options should be:
 //(with * are marked required options)
-//*id: the id of the reblogged post;
+//*id: the id of the reblogged post (see PostID.String);
 //*reblog_key: the reblog key of the rebloged post.
-func (trc *TumblrRestClient) Reblog(blogname string, options map[string]string) (bool, error) {
-	requestURL := fmt.Sprintf("/v2/blog/%s/post/reblog", blogname)
-	data, err := trc.request.Post(requestURL, options)
+func (trc *TumblrRestClient) Reblog(blog BlogIdentifier, options map[string]string) (bool, error) {
+	requestURL, err := blog.apiPath("post/reblog")
 	if err != nil {
 		return false, err
 	}
-	if data.Meta.Status != 201 {
-		return false, errors.New(data.Meta.Msg)
+	if err := trc.post(requestURL, options, 201); err != nil {
+		return false, err
 	}
 	return true, nil
 }
 
 //DeletePost deletes a post with a given id.
-//blogname: the url of the blog you want to delete from.
+//blog: the blog you want to delete from.
 //id: the id of the post you want to delete.
-func (trc *TumblrRestClient) DeletePost(blogname, id string) (bool, error) {
-	requestURL := fmt.Sprintf("/v2/blog/%s/post/delete", blogname)
-	params := map[string]string{"id": id}
-	data, err := trc.request.Post(requestURL, params)
+func (trc *TumblrRestClient) DeletePost(blog BlogIdentifier, id PostID) (bool, error) {
+	requestURL, err := blog.apiPath("post/delete")
 	if err != nil {
 		return false, err
 	}
-	if data.Meta.Status != 200 {
-		return false, errors.New(data.Meta.Msg)
+	params := map[string]string{"id": id.String()}
+	if err := trc.post(requestURL, params, 200); err != nil {
+		return false, err
 	}
 	return true, nil
 }
 
+//CreatePost creates a post of any type and returns its id.
+//blog: the blog you want to post to.
+//options are those of the CreateX method of the type of the post, and:
+//*type: the type of the post (text, photo, quote, link, chat, audio or video).
+//uploads are the files of the post, sent as multipart form data instead of a source or an external URL:
+//the photos of a photo post as data[0], data[1]..., the file of an audio or video post as data.
+func (trc *TumblrRestClient) CreatePost(blog BlogIdentifier, options map[string]string, uploads ...Upload) (PostID, error) {
+	requestURL, err := blog.apiPath("post")
+	if err != nil {
+		return 0, err
+	}
+	var data *CompleteResponse
+	if len(uploads) == 0 {
+		data, err = trc.request.PostContext(trc.context(), requestURL, options)
+	} else {
+		data, err = trc.request.PostUploadContext(trc.context(), requestURL, options, uploads)
+	}
+	if err != nil {
+		return 0, err
+	}
+	var result struct {
+		ID       PostID
+		IDString string `json:"id_string,optional"`
+	}
+	if err := trc.handle(data, 201, &result); err != nil {
+		return 0, err
+	}
+	if result.IDString != "" {
+		return ParsePostID(result.IDString)
+	}
+	return result.ID, nil
+}
+
+//CreateNPFPost creates a post in the Neue Post Format (NPF) and returns its id.
+//blog: the blog you want to post to.
+//params: the content blocks of the post and its other parameters.
+func (trc *TumblrRestClient) CreateNPFPost(blog BlogIdentifier, params NPFPostParams) (PostID, error) {
+	requestURL, err := blog.apiPath("posts")
+	if err != nil {
+		return 0, err
+	}
+	data, err := trc.request.PostJSONContext(trc.context(), requestURL, params.body())
+	if err != nil {
+		return 0, err
+	}
+	var result struct {
+		ID string
+	}
+	if err := trc.handle(data, 201, &result); err != nil {
+		return 0, err
+	}
+	return ParsePostID(result.ID)
+}
+
 //EditPost edits a post with a given id.
-//blogname: the url of the blog you want to post to.
+//blog: the blog you want to post to.
 //options can be:
 //(with * are marked required options)
 //tags: a list of tags you want applied to the post;
 //tweet: manages the autotweet for this post: set to off for no tweet
 //or enter text to override the default tweet;
-//date: the GMT date and time of the post as a string;
+//date: the GMT date and time of the post as a string (see PostTimes);
 //format: sets the format type of the post(html or markdown);
 //slug: add a short text summary to the end of the post url;
-//*id: the id of the post.
+//*id: the id of the post (see PostID.String).
 //The other options are specific to the type of post you want to edit.
-func (trc *TumblrRestClient) EditPost(blogname string, options map[string]string) (bool, error) {
-	requestURL := fmt.Sprintf("/v2/blog/%s/post/edit", blogname)
-	data, err := trc.request.Post(requestURL, options)
+func (trc *TumblrRestClient) EditPost(blog BlogIdentifier, options map[string]string) (bool, error) {
+	requestURL, err := blog.apiPath("post/edit")
 	if err != nil {
 		return false, err
 	}
-	if data.Meta.Status != 200 {
-		return false, errors.New(data.Meta.Msg)
+	if err := trc.post(requestURL, options, 200); err != nil {
+		return false, err
 	}
 	return true, nil
 }
@@ -0,0 +1,125 @@
+package gotumblr
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RequestInfo describes a request sent to the API, for Hooks.
+// The fields after URL are set once the response is received.
+type RequestInfo struct {
+	Method   string
+	Endpoint string // endpoint template of the request, see EndpointTemplate
+	URL      string // URL of the request, with its credentials redacted by RedactURL
+	Start    time.Time
+
+	StatusCode int           // HTTP status, 0 if no response was received
+	MetaStatus int64         // meta status of the response, 0 if it could not be parsed
+	Latency    time.Duration // time until the response was read
+	Bytes      int64         // size of the response body
+	// RateLimitRemaining is the number of requests left before the API rate limit is reached,
+	// the smallest of the hourly and daily limits, or -1 if the response does not tell.
+	RateLimitRemaining int
+	Err                error // error of the request, including API errors
+}
+
+// Hooks are called around every request sent to the API, see TumblrRequest.SetHooks.
+// They must be safe for concurrent use.
+type Hooks interface {
+	// BeforeRequest is called before a request is sent. The context it returns is used
+	// for the request and passed to AfterRequest, e.g. to carry a span.
+	BeforeRequest(ctx context.Context, info *RequestInfo) context.Context
+	// AfterRequest is called once the response is received and parsed, or the request failed.
+	AfterRequest(ctx context.Context, info *RequestInfo)
+}
+
+// CombineHooks returns Hooks calling each of hooks in turn.
+func CombineHooks(hooks ...Hooks) Hooks {
+	return combinedHooks(hooks)
+}
+
+type combinedHooks []Hooks
+
+func (hooks combinedHooks) BeforeRequest(ctx context.Context, info *RequestInfo) context.Context {
+	for _, h := range hooks {
+		ctx = h.BeforeRequest(ctx, info)
+	}
+	return ctx
+}
+
+func (hooks combinedHooks) AfterRequest(ctx context.Context, info *RequestInfo) {
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i].AfterRequest(ctx, info)
+	}
+}
+
+// RedactURL replaces the values of the api_key and OAuth parameters of a URL by REDACTED,
+// so that it can be logged.
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return rawURL
+	}
+	query := u.Query()
+	redacted := false
+	for key := range query {
+		if key == "api_key" || strings.HasPrefix(key, "oauth_") {
+			query.Set(key, "REDACTED")
+			redacted = true
+		}
+	}
+	if redacted {
+		u.RawQuery = query.Encode()
+	}
+	u.User = nil
+	return u.String()
+}
+
+// startRequest calls the BeforeRequest hook for a request about to be sent.
+// It returns the request to send and a function to call with its outcome, which calls the AfterRequest hook.
+func (tr *TumblrRequest) startRequest(httpRequest *http.Request) (*http.Request, func(*http.Response, int64, *CompleteResponse, error)) {
+	hooks := tr.hooks
+	if hooks == nil {
+		return httpRequest, func(*http.Response, int64, *CompleteResponse, error) {}
+	}
+	info := &RequestInfo{
+		Method:             httpRequest.Method,
+		Endpoint:           EndpointTemplate(httpRequest.URL.Path),
+		URL:                RedactURL(httpRequest.URL.String()),
+		Start:              time.Now(),
+		RateLimitRemaining: -1,
+	}
+	ctx := hooks.BeforeRequest(httpRequest.Context(), info)
+	return httpRequest.WithContext(ctx), func(httpResponse *http.Response, bytes int64, data *CompleteResponse, err error) {
+		info.Latency = time.Since(info.Start)
+		info.Bytes = bytes
+		info.Err = err
+		if httpResponse != nil {
+			info.StatusCode = httpResponse.StatusCode
+			info.RateLimitRemaining = rateLimitRemaining(httpResponse.Header)
+		}
+		if data != nil {
+			info.MetaStatus = data.Meta.Status
+			if err == nil && (data.StatusCode >= 400 || data.Meta.Status != 0 && data.Meta.Status/100 != 2) {
+				info.Err = &APIError{StatusCode: data.StatusCode, Meta: data.Meta}
+			}
+		}
+		hooks.AfterRequest(ctx, info)
+	}
+}
+
+// rateLimitRemaining returns the smallest number of remaining requests given by the rate limit headers, -1 if none.
+func rateLimitRemaining(header http.Header) int {
+	remaining := -1
+	for _, key := range []string{"X-Ratelimit-Perhour-Remaining", "X-Ratelimit-Perday-Remaining"} {
+		value, err := strconv.Atoi(header.Get(key))
+		if err == nil && (remaining < 0 || value < remaining) {
+			remaining = value
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,48 @@
+package gotumblr
+
+import (
+	"context"
+	"expvar"
+	"strconv"
+)
+
+// ExpvarHooks are Hooks counting the requests to the API in an expvar.Map with the variables:
+//
+//	requests, errors, bytes, latency_ns  totals over all requests
+//	requests:<endpoint>                  requests by endpoint template, e.g. requests:/v2/blog/{blog}/info
+//	status:<code>                        requests by HTTP status, status:0 for requests without response
+//	ratelimit_remaining                  requests left before the rate limit, as of the last response telling it
+type ExpvarHooks struct {
+	Map *expvar.Map
+}
+
+// NewExpvarHooks creates ExpvarHooks counting in a new expvar.Map published under name.
+// Like expvar.NewMap, it panics if name is already used.
+func NewExpvarHooks(name string) *ExpvarHooks {
+	return &ExpvarHooks{Map: expvar.NewMap(name)}
+}
+
+// BeforeRequest does nothing, requests are counted once they are done.
+func (h *ExpvarHooks) BeforeRequest(ctx context.Context, info *RequestInfo) context.Context {
+	return ctx
+}
+
+// AfterRequest counts the request.
+func (h *ExpvarHooks) AfterRequest(ctx context.Context, info *RequestInfo) {
+	h.Map.Add("requests", 1)
+	if info.Err != nil {
+		h.Map.Add("errors", 1)
+	}
+	h.Map.Add("bytes", info.Bytes)
+	h.Map.Add("latency_ns", int64(info.Latency))
+	h.Map.Add("requests:"+info.Endpoint, 1)
+	h.Map.Add("status:"+strconv.Itoa(info.StatusCode), 1)
+	if info.RateLimitRemaining >= 0 {
+		remaining, ok := h.Map.Get("ratelimit_remaining").(*expvar.Int)
+		if !ok {
+			remaining = new(expvar.Int)
+			h.Map.Set("ratelimit_remaining", remaining)
+		}
+		remaining.Set(int64(info.RateLimitRemaining))
+	}
+}
@@ -0,0 +1,55 @@
+package gotumblr
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogHooks are Hooks logging every request to the API with log/slog:
+// successful requests at Level, failed ones at slog.LevelError.
+type SlogHooks struct {
+	Logger *slog.Logger
+	Level  slog.Level
+}
+
+// NewSlogHooks creates SlogHooks logging successful requests with logger at debug level.
+// A nil logger uses slog.Default().
+func NewSlogHooks(logger *slog.Logger) *SlogHooks {
+	return &SlogHooks{Logger: logger, Level: slog.LevelDebug}
+}
+
+// BeforeRequest does nothing, requests are logged once they are done.
+func (h *SlogHooks) BeforeRequest(ctx context.Context, info *RequestInfo) context.Context {
+	return ctx
+}
+
+// AfterRequest logs the request.
+func (h *SlogHooks) AfterRequest(ctx context.Context, info *RequestInfo) {
+	logger := h.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	level := h.Level
+	if info.Err != nil {
+		level = slog.LevelError
+	}
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("method", info.Method),
+		slog.String("endpoint", info.Endpoint),
+		slog.String("url", info.URL),
+		slog.Int("status", info.StatusCode),
+		slog.Int64("meta_status", info.MetaStatus),
+		slog.Duration("latency", info.Latency),
+		slog.Int64("bytes", info.Bytes),
+	}
+	if info.RateLimitRemaining >= 0 {
+		attrs = append(attrs, slog.Int("ratelimit_remaining", info.RateLimitRemaining))
+	}
+	if info.Err != nil {
+		attrs = append(attrs, slog.String("error", info.Err.Error()))
+	}
+	logger.LogAttrs(ctx, level, "tumblr request", attrs...)
+}
@@ -0,0 +1,52 @@
+package gotumblr
+
+import "context"
+
+// Span is a tracing span, as created by TraceHooks. It mirrors the methods of
+// OpenTelemetry spans, which can be adapted with a few lines.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// TraceHooks are Hooks creating a span for every request to the API, named after
+// its method and endpoint template (e.g. "GET /v2/blog/{blog}/posts"), as a child
+// of the span in the context of the request, if the Start function supports it.
+// The span gets the attributes http.method, http.url (redacted), http.status_code,
+// tumblr.endpoint, tumblr.meta_status, tumblr.response_bytes and, when known,
+// tumblr.ratelimit_remaining, and records the error of failed requests.
+type TraceHooks struct {
+	// Start starts a span and returns it with a context holding it,
+	// e.g. a wrapper of an OpenTelemetry trace.Tracer's Start method.
+	Start func(ctx context.Context, name string) (context.Context, Span)
+}
+
+type traceSpanKey struct{}
+
+// BeforeRequest starts the span of the request.
+func (h *TraceHooks) BeforeRequest(ctx context.Context, info *RequestInfo) context.Context {
+	ctx, span := h.Start(ctx, info.Method+" "+info.Endpoint)
+	span.SetAttribute("http.method", info.Method)
+	span.SetAttribute("http.url", info.URL)
+	span.SetAttribute("tumblr.endpoint", info.Endpoint)
+	return context.WithValue(ctx, traceSpanKey{}, span)
+}
+
+// AfterRequest ends the span of the request.
+func (h *TraceHooks) AfterRequest(ctx context.Context, info *RequestInfo) {
+	span, ok := ctx.Value(traceSpanKey{}).(Span)
+	if !ok {
+		return
+	}
+	span.SetAttribute("http.status_code", info.StatusCode)
+	span.SetAttribute("tumblr.meta_status", info.MetaStatus)
+	span.SetAttribute("tumblr.response_bytes", info.Bytes)
+	if info.RateLimitRemaining >= 0 {
+		span.SetAttribute("tumblr.ratelimit_remaining", info.RateLimitRemaining)
+	}
+	if info.Err != nil {
+		span.RecordError(info.Err)
+	}
+	span.End()
+}
@@ -0,0 +1,65 @@
+package gotumblr_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/spbr/gotumblr"
+	"github.com/spbr/gotumblr/tumblrtest"
+)
+
+type testSpan struct {
+	name, parent string
+	attributes   map[string]interface{}
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) { s.attributes[key] = value }
+func (*testSpan) RecordError(err error)                        {}
+func (*testSpan) End()                                         {}
+
+type spanKey struct{}
+
+func TestTraceHooksChildSpans(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	var mutex sync.Mutex
+	var spans []*testSpan
+	hooks := &gotumblr.TraceHooks{Start: func(ctx context.Context, name string) (context.Context, gotumblr.Span) {
+		span := &testSpan{name: name, attributes: map[string]interface{}{}}
+		if parent, ok := ctx.Value(spanKey{}).(*testSpan); ok {
+			span.parent = parent.name
+		}
+		mutex.Lock()
+		spans = append(spans, span)
+		mutex.Unlock()
+		return context.WithValue(ctx, spanKey{}, span), span
+	}}
+	client := server.Client()
+	client.SetHooks(hooks)
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), spanKey{}, &testSpan{name: "caller"}))
+	defer cancel()
+	if _, err := client.WithContext(ctx).Info(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.WithContext(ctx).CreateText(gotumblr.MustParseBlogIdentifier("tester"), map[string]string{"body": "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(spans) != 2 {
+		t.Fatalf("%d spans started, want 2", len(spans))
+	}
+	for _, span := range spans {
+		if span.parent != "caller" {
+			t.Errorf("span %q has the parent %q, want the span of the caller", span.name, span.parent)
+		}
+		for _, key := range []string{"http.method", "http.url", "http.status_code", "tumblr.endpoint", "tumblr.meta_status", "tumblr.response_bytes"} {
+			if _, ok := span.attributes[key]; !ok {
+				t.Errorf("span %q has no attribute %s", span.name, key)
+			}
+		}
+		if len(span.attributes) > 7 {
+			t.Errorf("span %q has the attributes %v, want only the documented ones", span.name, span.attributes)
+		}
+	}
+}
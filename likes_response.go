@@ -4,6 +4,6 @@ import "encoding/json"
 
 // LikesResponse holds information about the posts a user liked on Tumblr
 type LikesResponse struct {
-	LikedPosts []json.RawMessage
-	LikedCount int64
+	LikedPosts []json.RawMessage `json:"liked_posts"`
+	LikedCount int64             `json:"liked_count"`
 }
@@ -0,0 +1,216 @@
+package markdown
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spbr/gotumblr"
+)
+
+// pageSize is the number of posts requested at once.
+const pageSize = 20
+
+// unsafeName matches the characters replaced in the names of the files.
+var unsafeName = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// Exporter exports the published posts of a blog to a static site directory.
+// The fields must be set before Run is called.
+//
+// The defaults suit Hugo. For Jekyll, set PostsDir to "_posts", MediaDir to "assets/media"
+// and MediaURL to "/assets/media".
+type Exporter struct {
+	Dir  string
+	Blog gotumblr.BlogIdentifier
+	// PostsDir is the directory of the Markdown files, relative to Dir, "content/posts" by default.
+	// The files are named after the date and the slug of the posts, e.g. 2014-01-02-hello.md.
+	PostsDir string
+	// MediaDir is the directory of the downloaded media, relative to Dir, "static/media" by default.
+	MediaDir string
+	// MediaURL is the URL the media directory is served at, "/media" by default.
+	MediaURL string
+	// Media downloads the photos, videos and audio files of the posts and the images
+	// embedded in them, and links to the downloaded files (set by New).
+	Media bool
+	// Type and Options select the posts exported, as the arguments of Posts (e.g. the tag option).
+	Type    string
+	Options map[string]string
+	// HTTPClient downloads the media, http.DefaultClient if nil.
+	HTTPClient *http.Client
+	// Logf, if set, is called to report the progress of the export.
+	Logf func(format string, args ...interface{})
+
+	client gotumblr.TumblrReader
+}
+
+// Summary tells what a run of an Exporter did.
+type Summary struct {
+	Posts int // number of posts exported
+	Media int // number of media files downloaded
+	// MediaErrors are the errors of the media that could not be downloaded;
+	// the exported posts link to their original URLs.
+	MediaErrors []error
+}
+
+// New creates an Exporter of blog to dir, downloading the media.
+func New(client gotumblr.TumblrReader, blog gotumblr.BlogIdentifier, dir string) *Exporter {
+	return &Exporter{Dir: dir, Blog: blog, Media: true, client: client}
+}
+
+// Run exports the posts, replacing the files of the posts exported before.
+func (e *Exporter) Run(ctx context.Context) (*Summary, error) {
+	postsDir := filepath.Join(e.Dir, filepath.FromSlash(orDefault(e.PostsDir, "content/posts")))
+	mediaDir := filepath.Join(e.Dir, filepath.FromSlash(orDefault(e.MediaDir, "static/media")))
+	for _, dir := range []string{postsDir, mediaDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	summary := new(Summary)
+	rewrite := func(u string) string { return u }
+	if e.Media {
+		rewrite = func(u string) string {
+			return e.download(ctx, u, mediaDir, summary)
+		}
+	}
+
+	names := map[string]bool{}
+	for offset := 0; ; {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+		options := map[string]string{}
+		for key, value := range e.Options {
+			options[key] = value
+		}
+		options["offset"] = strconv.Itoa(offset)
+		options["limit"] = strconv.Itoa(pageSize)
+		response, err := e.client.Posts(e.Blog, e.Type, options)
+		if err != nil {
+			return summary, err
+		}
+		if len(response.Posts) == 0 {
+			return summary, nil
+		}
+		for _, data := range response.Posts {
+			post, err := gotumblr.DecodePost(data)
+			if err != nil {
+				return summary, err
+			}
+			name := FileName(post)
+			if names[name] {
+				name = strings.TrimSuffix(name, ".md") + "-" + post.Base().ID.String() + ".md"
+			}
+			names[name] = true
+			if err := ioutil.WriteFile(filepath.Join(postsDir, name), Convert(post, rewrite), 0644); err != nil {
+				return summary, err
+			}
+			summary.Posts++
+		}
+		e.logf("exported %d posts", summary.Posts)
+		offset += len(response.Posts)
+		if response.TotalPosts != 0 && int64(offset) >= response.TotalPosts {
+			return summary, nil
+		}
+	}
+}
+
+// FileName returns the name of the Markdown file of a post: its date followed by its slug,
+// or its id if it has no slug.
+func FileName(post gotumblr.Post) string {
+	base := post.Base()
+	name := strings.Trim(unsafeName.ReplaceAllString(base.Slug, "-"), "-.")
+	if name == "" {
+		name = base.ID.String()
+	}
+	return base.Time().Format("2006-01-02") + "-" + name + ".md"
+}
+
+// download downloads a media file to dir unless it was already downloaded,
+// and returns the URL of the file. It returns rawURL if the download fails.
+func (e *Exporter) download(ctx context.Context, rawURL, dir string, summary *Summary) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "http" && u.Scheme != "https" {
+		return rawURL
+	}
+	sum := sha256.Sum256([]byte(rawURL))
+	name := strings.Trim(unsafeName.ReplaceAllString(path.Base(u.Path), "-"), "-.")
+	if name == "" {
+		name = "media"
+	}
+	name = hex.EncodeToString(sum[:6]) + "-" + name
+	local := strings.TrimSuffix(orDefault(e.MediaURL, "/media"), "/") + "/" + name
+	target := filepath.Join(dir, name)
+	if _, err := os.Stat(target); err == nil {
+		return local
+	}
+	if err := e.fetch(ctx, rawURL, target); err != nil {
+		summary.MediaErrors = append(summary.MediaErrors, err)
+		return rawURL
+	}
+	summary.Media++
+	return local
+}
+
+// fetch downloads a URL to a file, through a temporary file renamed once complete.
+func (e *Exporter) fetch(ctx context.Context, rawURL, target string) error {
+	client := e.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	request, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return err
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("markdown: downloading %s: %s", rawURL, response.Status)
+	}
+	file, err := ioutil.TempFile(filepath.Dir(target), ".tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, response.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), target)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return fmt.Errorf("markdown: downloading %s: %v", rawURL, err)
+	}
+	return nil
+}
+
+func (e *Exporter) logf(format string, args ...interface{}) {
+	if e.Logf != nil {
+		e.Logf(format, args...)
+	}
+}
+
+func orDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
@@ -0,0 +1,323 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// tag matches an HTML tag or comment: 1 is the slash of a closing tag, 2 the name, 3 the attributes.
+	tag = regexp.MustCompile(`<!--[\s\S]*?-->|<(/?)([a-zA-Z][a-zA-Z0-9]*)((?:[^>"']|"[^"]*"|'[^']*')*)>`)
+	// attribute matches an attribute of a tag: 1 is the name, 2, 3 or 4 the value.
+	attribute = regexp.MustCompile(`([a-zA-Z_:][-a-zA-Z0-9_:.]*)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	// space matches the white space collapsed in text.
+	space = regexp.MustCompile(`\s+`)
+	// escaped matches the characters escaped in text, as they have a meaning in Markdown.
+	escaped = regexp.MustCompile("[\\\\`*_\\[\\]<>]")
+	// entity matches the text that Markdown would read as an HTML entity.
+	entity = regexp.MustCompile(`&(?:[a-zA-Z][a-zA-Z0-9]*|#[0-9]+|#[xX][0-9a-fA-F]+);`)
+	// lineStart matches the text that would start a heading or a list item at the start of a line.
+	lineStart = regexp.MustCompile(`^(?:[#+-]|[0-9]+[.)])`)
+)
+
+// FromHTML converts the HTML of a post to Markdown. Paragraphs, line breaks, headings, emphasis,
+// links, images, lists, block quotes, code and rules are converted; the other tags are dropped
+// and their text is kept, except for scripts and styles. Videos, audio files and frames become links.
+// rewrite, if not nil, is called with the URL of every image, video and audio file and returns
+// the URL written instead, e.g. the URL of a downloaded copy.
+func FromHTML(source string, rewrite func(string) string) string {
+	if rewrite == nil {
+		rewrite = func(u string) string { return u }
+	}
+	c := &converter{rewrite: rewrite}
+	offset := 0
+	for _, match := range tag.FindAllStringSubmatchIndex(source, -1) {
+		c.text(source[offset:match[0]])
+		offset = match[1]
+		if match[4] < 0 {
+			continue // comment
+		}
+		name := strings.ToLower(source[match[4]:match[5]])
+		if match[3] > match[2] {
+			c.end(name)
+		} else {
+			c.start(name, attributes(source[match[6]:match[7]]))
+		}
+	}
+	c.text(source[offset:])
+	return strings.TrimSpace(c.out.String())
+}
+
+// attributes parses the attributes of a tag, unescaping their values.
+func attributes(s string) map[string]string {
+	attrs := map[string]string{}
+	for _, match := range attribute.FindAllStringSubmatch(s, -1) {
+		attrs[strings.ToLower(match[1])] = html.UnescapeString(match[2] + match[3] + match[4])
+	}
+	return attrs
+}
+
+// list is a list being converted.
+type list struct {
+	ordered bool
+	items   int
+	indent  int // width of the marker of the current item, which indents the lines of the item
+}
+
+// converter writes the Markdown of the tags and the text of an HTML document.
+type converter struct {
+	out       bytes.Buffer
+	rewrite   func(string) string
+	lists     []list
+	quotes    int      // depth of the block quotes
+	pre       int      // depth of the preformatted blocks
+	preStart  int      // length of the output after the opening fence of the preformatted block
+	code      int      // depth of the inline code
+	codeStart int      // length of the output after the opening backtick of the inline code
+	itemStart int      // length of the output after the marker of the current list item
+	skip      int      // depth of the scripts and styles
+	links     []string // targets of the links being converted
+	newlines  int      // number of newlines at the end of the output
+	started   bool     // whether the current line has text
+}
+
+func (c *converter) start(name string, attrs map[string]string) {
+	if c.skip > 0 && name != "script" && name != "style" {
+		return // the tags in scripts and styles are text
+	}
+	switch name {
+	case "script", "style":
+		c.skip++
+	case "p", "div", "figure", "table":
+		c.block()
+	case "br":
+		if c.pre > 0 {
+			c.write("\n")
+		} else {
+			c.write("  ")
+			c.newline()
+		}
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		c.block()
+		level, _ := strconv.Atoi(name[1:])
+		c.write(strings.Repeat("#", level) + " ")
+	case "hr":
+		c.block()
+		c.write("* * *")
+		c.block()
+	case "b", "strong":
+		c.write("**")
+	case "i", "em":
+		c.write("*")
+	case "s", "strike", "del":
+		c.write("~~")
+	case "code", "tt":
+		if c.pre == 0 && c.code == 0 {
+			c.write("`")
+			c.codeStart = c.out.Len()
+		}
+		c.code++
+	case "pre":
+		c.block()
+		c.write("```\n")
+		c.pre++
+		c.preStart = c.out.Len()
+	case "blockquote":
+		c.block()
+		c.quotes++
+		c.newlines = 2 // start the quote on a fresh line with its prefix
+		c.started = false
+	case "ul", "ol":
+		if len(c.lists) == 0 {
+			c.block()
+		}
+		c.lists = append(c.lists, list{ordered: name == "ol"})
+	case "li":
+		c.newline()
+		if len(c.lists) == 0 {
+			c.write("- ")
+			c.itemStart = c.out.Len()
+			break
+		}
+		// the marker is indented by the outer lists only
+		current := &c.lists[len(c.lists)-1]
+		current.items++
+		current.indent = 0
+		marker := "- "
+		if current.ordered {
+			marker = strconv.Itoa(current.items) + ". "
+		}
+		c.write(marker)
+		current.indent = len(marker)
+		c.itemStart = c.out.Len()
+	case "a":
+		c.links = append(c.links, attrs["href"])
+		c.write("[")
+	case "img":
+		if src := attrs["src"]; src != "" {
+			c.write("![" + escape(attrs["alt"]) + "](" + destination(c.rewrite(src)) + ")")
+		}
+	case "video", "audio", "source":
+		if src := attrs["src"]; src != "" {
+			c.write("[" + name + "](" + destination(c.rewrite(src)) + ")")
+		}
+	case "iframe":
+		if src := attrs["src"]; src != "" {
+			c.block()
+			c.write("[embedded content](" + destination(src) + ")")
+			c.block()
+		}
+	}
+}
+
+func (c *converter) end(name string) {
+	if c.skip > 0 && name != "script" && name != "style" {
+		return // the tags in scripts and styles are text
+	}
+	switch name {
+	case "script", "style":
+		if c.skip > 0 {
+			c.skip--
+		}
+	case "p", "div", "figure", "table", "h1", "h2", "h3", "h4", "h5", "h6":
+		c.block()
+	case "b", "strong":
+		c.write("**")
+	case "i", "em":
+		c.write("*")
+	case "s", "strike", "del":
+		c.write("~~")
+	case "code", "tt":
+		if c.code == 0 {
+			break
+		}
+		c.code--
+		if c.pre == 0 && c.code == 0 {
+			// code with backticks is delimited by two, and spaces that Markdown strips
+			if content := c.out.String()[c.codeStart:]; strings.Contains(content, "`") {
+				c.out.Truncate(c.codeStart - 1)
+				c.write("`` " + content + " ``")
+			} else {
+				c.write("`")
+			}
+		}
+	case "pre":
+		if c.pre > 0 {
+			c.pre--
+			c.newline()
+			c.write("```")
+			c.block()
+		}
+	case "blockquote":
+		if c.quotes > 0 {
+			// drop the blank line ending the last block of the quote
+			blank := "\n" + strings.TrimSpace(strings.Repeat("> ", c.quotes)) + "\n"
+			if bytes.HasSuffix(c.out.Bytes(), []byte(blank)) {
+				c.out.Truncate(c.out.Len() - len(blank) + 1)
+				c.newlines = 1
+			}
+			c.quotes--
+			c.block()
+		}
+	case "ul", "ol":
+		if len(c.lists) > 0 {
+			c.lists = c.lists[:len(c.lists)-1]
+		}
+		if len(c.lists) == 0 {
+			c.block()
+		}
+	case "a":
+		if len(c.links) > 0 {
+			href := c.links[len(c.links)-1]
+			c.links = c.links[:len(c.links)-1]
+			c.write("](" + destination(href) + ")")
+		}
+	}
+}
+
+// text writes the text between tags, collapsing its white space unless it is preformatted.
+func (c *converter) text(s string) {
+	if c.skip > 0 || s == "" {
+		return
+	}
+	s = html.UnescapeString(s)
+	if c.pre > 0 {
+		if c.out.Len() == c.preStart {
+			s = strings.TrimPrefix(s, "\n") // as in HTML, a newline right after the tag is dropped
+		}
+		c.write(s)
+		return
+	}
+	s = space.ReplaceAllString(s, " ")
+	if !c.started {
+		s = strings.TrimLeft(s, " ")
+	}
+	if c.code > 0 {
+		c.write(s)
+		return
+	}
+	s = escape(s)
+	if !c.started || c.out.Len() == c.itemStart {
+		if match := lineStart.FindString(s); match != "" {
+			s = match[:len(match)-1] + `\` + s[len(match)-1:]
+		}
+	}
+	c.write(s)
+}
+
+// write writes s, prefixing the lines in block quotes and indenting the lines in list items.
+func (c *converter) write(s string) {
+	for i, line := range strings.Split(s, "\n") {
+		if i > 0 {
+			c.out.WriteString("\n")
+			c.newlines++
+			c.started = false
+		}
+		if line == "" {
+			continue
+		}
+		if !c.started {
+			c.out.WriteString(strings.Repeat("> ", c.quotes))
+			for _, l := range c.lists {
+				c.out.WriteString(strings.Repeat(" ", l.indent))
+			}
+		}
+		c.out.WriteString(line)
+		c.newlines = 0
+		c.started = true
+	}
+}
+
+// newline ends the current line, if it has text.
+func (c *converter) newline() {
+	if c.newlines == 0 && c.out.Len() > 0 {
+		c.write("\n")
+	}
+}
+
+// block ends the current block with a blank line.
+func (c *converter) block() {
+	if c.out.Len() == 0 || c.out.Len() == c.itemStart {
+		return
+	}
+	for c.newlines < 2 {
+		if c.quotes > 0 && c.newlines == 1 {
+			c.out.WriteString(strings.TrimSpace(strings.Repeat("> ", c.quotes)))
+		}
+		c.write("\n")
+	}
+}
+
+// escape escapes the characters of text that have a meaning in Markdown.
+func escape(s string) string {
+	return entity.ReplaceAllString(escaped.ReplaceAllString(s, `\$0`), `\$0`)
+}
+
+// destination returns a URL as the destination of a Markdown link, which ends at a space or
+// an unbalanced parenthesis.
+func destination(u string) string {
+	return strings.NewReplacer(" ", "%20", "(", `\(`, ")", `\)`).Replace(u)
+}
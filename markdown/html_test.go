@@ -0,0 +1,83 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFromHTML(t *testing.T) {
+	tests := []struct {
+		name, html, want string
+	}{
+		{"paragraphs",
+			"<p>One\n  two</p><p>Three<br>four</p>",
+			"One two\n\nThree  \nfour"},
+		{"headings and emphasis",
+			"<h2>Title</h2><p><b>bold</b>, <em>italic</em> and <del>gone</del></p><hr><p>end</p>",
+			"## Title\n\n**bold**, *italic* and ~~gone~~\n\n* * *\n\nend"},
+		{"nested lists",
+			"<ul><li>one<ul><li>two</li><li>three<ol><li>four</li><li>five</li></ol></li></ul></li><li>six</li></ul><p>after</p>",
+			"- one\n  - two\n  - three\n    1. four\n    2. five\n- six\n\nafter"},
+		{"nested in an ordered list",
+			"<ol><li>one<ul><li>two</li></ul></li><li>three</li></ol>",
+			"1. one\n   - two\n2. three"},
+		{"paragraphs in list items",
+			"<ol><li><p>first</p><p>more</p></li><li><p>second</p></li></ol>",
+			"1. first\n\n   more\n\n2. second"},
+		{"block quotes",
+			"<blockquote><p>quoted</p><blockquote><p>inner</p></blockquote><p>back</p></blockquote><p>out</p>",
+			"> quoted\n>\n> > inner\n>\n> back\n\nout"},
+		{"list in a block quote",
+			"<blockquote><ul><li>a</li><li>b</li></ul></blockquote>",
+			"> - a\n> - b"},
+		{"pre and code",
+			"<pre><code>if x &lt; 1 {\n    y_z(*w*)\n}\n</code></pre><p>after</p>",
+			"```\nif x < 1 {\n    y_z(*w*)\n}\n```\n\nafter"},
+		{"newline after pre",
+			"<pre>\nfirst\n\nsecond</pre>",
+			"```\nfirst\n\nsecond\n```"},
+		{"pre in a block quote",
+			"<blockquote><pre>code\nline</pre></blockquote>",
+			"> ```\n> code\n> line\n> ```"},
+		{"inline code",
+			"<p>call <code>a_b(*c)</code> or <tt>x`y</tt></p>",
+			"call `a_b(*c)` or `` x`y ``"},
+		{"links",
+			`<p><a href="https://www.tumblr.com/explore?q=a&amp;b=c">explore <b>now</b></a></p>`,
+			"[explore **now**](https://www.tumblr.com/explore?q=a&b=c)"},
+		{"link destinations",
+			`<a href="https://en.wikipedia.org/wiki/Go_(game)">go</a> <a href="a b.html">space</a>`,
+			`[go](https://en.wikipedia.org/wiki/Go_\(game\)) [space](a%20b.html)`},
+		{"link wrapping an image",
+			`<figure><a href="https://staff.tumblr.com/"><img src="https://64.media.tumblr.com/a.jpg" alt="a [desk]"></a></figure>`,
+			`[![a \[desk\]](https://64.media.tumblr.com/a.jpg)](https://staff.tumblr.com/)`},
+		{"media",
+			`<video src="https://va.media.tumblr.com/v.mp4"></video><iframe src="https://www.youtube.com/embed/x"></iframe>`,
+			"[video](https://va.media.tumblr.com/v.mp4)\n\n[embedded content](https://www.youtube.com/embed/x)"},
+		{"escaping",
+			`<p>a * b _c_ [d] &lt;e&gt; \ f` + "`" + `</p><p>&amp;copy; &amp; co</p>`,
+			"a \\* b \\_c\\_ \\[d\\] \\<e\\> \\\\ f\\`\n\n\\&copy; & co"},
+		{"escaping at the start of lines",
+			"<p># not a heading</p><p>1. not a list</p><p>- nor <i>this</i></p><ul><li>+ item</li></ul><p>a - b, 2. c</p>",
+			"\\# not a heading\n\n1\\. not a list\n\n\\- nor *this*\n\n- \\+ item\n\na - b, 2. c"},
+		{"dropped tags",
+			"<div><span>kept</span><script>var x = '<b>';</script><style>p {}</style><!-- comment --></div>",
+			"kept"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := FromHTML(test.html, nil); got != test.want {
+				t.Errorf("FromHTML(%q) =\n%s\nwant\n%s", test.html, got, test.want)
+			}
+		})
+	}
+}
+
+func TestFromHTMLRewrite(t *testing.T) {
+	got := FromHTML(`<p><img src="https://64.media.tumblr.com/a.jpg"> <a href="https://64.media.tumblr.com/b.jpg">b</a></p>`,
+		func(u string) string { return "media/" + u[strings.LastIndex(u, "/")+1:] })
+	// images are rewritten, not the targets of links
+	if want := "![](media/a.jpg) [b](https://64.media.tumblr.com/b.jpg)"; got != want {
+		t.Errorf("FromHTML = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,192 @@
+// Package markdown exports the posts of a Tumblr blog to Markdown files with a YAML front matter,
+// as used by static site generators like Hugo and Jekyll.
+//
+// Each post becomes a file like:
+//
+//	---
+//	id: "72078164824"
+//	title: "Hello"
+//	date: 2014-01-02T15:04:05Z
+//	type: "text"
+//	slug: "hello"
+//	tags: ["golang", "tumblr"]
+//	source: "https://example.tumblr.com/post/72078164824/hello"
+//	---
+//
+//	Hello **world**!
+package markdown
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spbr/gotumblr"
+)
+
+// Convert returns the Markdown file of a post: its front matter and its content converted from HTML.
+// rewrite, if not nil, is called with the URL of every photo, video and audio file of the post
+// and returns the URL written instead, see FromHTML.
+func Convert(post gotumblr.Post, rewrite func(string) string) []byte {
+	if rewrite == nil {
+		rewrite = func(u string) string { return u }
+	}
+	base := post.Base()
+	title := ""
+	var body []string
+	add := func(parts ...string) {
+		for _, part := range parts {
+			if part = strings.TrimSpace(part); part != "" {
+				body = append(body, part)
+			}
+		}
+	}
+	switch post := post.(type) {
+	case *gotumblr.TextPost:
+		title = post.Title
+		add(FromHTML(post.Body, rewrite))
+	case *gotumblr.PhotoPost:
+		for i := range post.Photos {
+			photo := &post.Photos[i]
+			image := "![" + FromHTML(photo.Caption, nil) + "](" + rewrite(photo.Original().URL) + ")"
+			if post.LinkURL != "" {
+				image = "[" + image + "](" + post.LinkURL + ")"
+			}
+			add(image)
+		}
+		add(FromHTML(post.Caption, rewrite))
+	case *gotumblr.QuotePost:
+		add(quote(FromHTML(post.Text, rewrite)))
+		if source := FromHTML(post.Source, rewrite); source != "" {
+			add("— " + source)
+		}
+	case *gotumblr.LinkPost:
+		title = post.Title
+		label := post.Title
+		if label == "" {
+			label = post.URL
+		}
+		add("[" + escape(label) + "](" + post.URL + ")")
+		if post.Excerpt != "" {
+			add(quote(escape(post.Excerpt)))
+		}
+		add(FromHTML(post.Description, rewrite))
+	case *gotumblr.ChatPost:
+		title = post.Title
+		dialogue := post.Dialogue
+		if len(dialogue) == 0 {
+			dialogue = gotumblr.ParseConversation(post.Body)
+		}
+		lines := make([]string, 0, len(dialogue))
+		for _, entry := range dialogue {
+			label := entry.Label
+			if label == "" && entry.Name != "" {
+				label = entry.Name + ":"
+			}
+			if label == "" {
+				lines = append(lines, escape(entry.Phrase))
+			} else {
+				lines = append(lines, "**"+escape(label)+"** "+escape(entry.Phrase))
+			}
+		}
+		add(strings.Join(lines, "  \n"))
+	case *gotumblr.AnswerPost:
+		asker := escape(post.AskingName)
+		if asker == "" {
+			asker = "Anonymous"
+		} else if post.AskingURL != "" {
+			asker = "[" + asker + "](" + post.AskingURL + ")"
+		}
+		add(quote(asker+" asked: "+FromHTML(post.Question, rewrite)), FromHTML(post.Answer, rewrite))
+	case *gotumblr.AudioPost:
+		title = post.TrackName
+		label := strings.TrimSpace(strings.Join(nonEmpty(post.Artist, post.TrackName), " — "))
+		if label == "" {
+			label = "audio"
+		}
+		if post.AlbumArt != "" {
+			add("![" + escape(post.Album) + "](" + rewrite(post.AlbumArt) + ")")
+		}
+		if post.AudioType == "tumblr" && post.AudioURL != "" {
+			add("[" + escape(label) + "](" + rewrite(post.AudioURL) + ")")
+		} else if post.AudioSourceURL != "" {
+			add("[" + escape(label) + "](" + post.AudioSourceURL + ")")
+		}
+		add(FromHTML(post.Caption, rewrite))
+	case *gotumblr.VideoPost:
+		target := post.PermalinkURL
+		if post.VideoType == "tumblr" && post.VideoURL != "" {
+			target = rewrite(post.VideoURL)
+		}
+		if target != "" {
+			if post.ThumbnailURL != "" {
+				add("[![video](" + rewrite(post.ThumbnailURL) + ")](" + target + ")")
+			} else {
+				add("[video](" + target + ")")
+			}
+		}
+		add(FromHTML(post.Caption, rewrite))
+	}
+
+	var out bytes.Buffer
+	out.WriteString("---\n")
+	fmt.Fprintf(&out, "id: %s\n", yamlString(base.ID.String()))
+	if title != "" {
+		fmt.Fprintf(&out, "title: %s\n", yamlString(title))
+	}
+	fmt.Fprintf(&out, "date: %s\n", base.Time().Format(time.RFC3339))
+	fmt.Fprintf(&out, "type: %s\n", yamlString(base.PostType))
+	if base.Slug != "" {
+		fmt.Fprintf(&out, "slug: %s\n", yamlString(base.Slug))
+	}
+	tags := make([]string, len(base.Tags))
+	for i, tag := range base.Tags {
+		tags[i] = yamlString(tag)
+	}
+	fmt.Fprintf(&out, "tags: [%s]\n", strings.Join(tags, ", "))
+	if base.PostURL != "" {
+		fmt.Fprintf(&out, "source: %s\n", yamlString(base.PostURL))
+	}
+	out.WriteString("---\n")
+	if len(body) != 0 {
+		out.WriteString("\n" + strings.Join(body, "\n\n") + "\n")
+	}
+	return out.Bytes()
+}
+
+// quote makes a block quote of Markdown text.
+func quote(text string) string {
+	if text == "" {
+		return ""
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// yamlString quotes a string for YAML. JSON strings are valid YAML double quoted scalars.
+func yamlString(s string) string {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
+
+func nonEmpty(values ...string) []string {
+	var kept []string
+	for _, value := range values {
+		if value != "" {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
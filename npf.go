@@ -0,0 +1,51 @@
+package gotumblr
+
+import (
+	"strings"
+	"time"
+)
+
+// TextBlock is a text content block of the Neue Post Format (NPF), the JSON format
+// of the posts created with CreateNPFPost.
+type TextBlock struct {
+	Type    string `json:"type"`              // always "text"
+	Subtype string `json:"subtype,omitempty"` // e.g. heading1, quote, indented
+	Text    string `json:"text"`
+}
+
+// NewTextBlock returns a text block of the given subtype ("" for a plain paragraph).
+func NewTextBlock(subtype, text string) TextBlock {
+	return TextBlock{Type: "text", Subtype: subtype, Text: text}
+}
+
+// NPFPostParams holds the parameters for creating a post with CreateNPFPost.
+// Zero-valued fields are not sent.
+type NPFPostParams struct {
+	Content   []TextBlock
+	State     string // published, draft, queue or private
+	Tags      []string
+	Date      time.Time
+	PublishOn time.Time // for queued posts
+	Slug      string
+}
+
+// npfBody is the JSON body of the NPF create endpoint. Its dates are in ISO 8601.
+type npfBody struct {
+	Content   []TextBlock `json:"content"`
+	State     string      `json:"state,omitempty"`
+	Tags      string      `json:"tags,omitempty"`
+	Date      string      `json:"date,omitempty"`
+	PublishOn string      `json:"publish_on,omitempty"`
+	Slug      string      `json:"slug,omitempty"`
+}
+
+func (p NPFPostParams) body() npfBody {
+	body := npfBody{Content: p.Content, State: p.State, Tags: strings.Join(p.Tags, ","), Slug: p.Slug}
+	if !p.Date.IsZero() {
+		body.Date = p.Date.UTC().Format(time.RFC3339)
+	}
+	if !p.PublishOn.IsZero() {
+		body.PublishOn = p.PublishOn.UTC().Format(time.RFC3339)
+	}
+	return body
+}
@@ -0,0 +1,78 @@
+package gotumblr
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/kurrik/oauth1a"
+)
+
+// OAuthFlow runs the OAuth 1.0a authorization of an application by a Tumblr user,
+// which gives the token and token secret of a client acting on behalf of the user:
+//
+//	flow := gotumblr.NewOAuthFlow(consumerKey, consumerSecret, "http://127.0.0.1:8080/callback")
+//	authorizeURL, err := flow.AuthorizeURL()
+//	// send the user to authorizeURL; Tumblr then redirects them to the callback URL
+//	token, tokenSecret, err := flow.Callback(callbackRequest)
+type OAuthFlow struct {
+	service *oauth1a.Service
+	user    *oauth1a.UserConfig
+	client  *http.Client
+}
+
+// NewOAuthFlow creates an OAuthFlow for the application with the given consumer key and secret.
+// callbackURL is the URL Tumblr redirects the user to once they authorized the application.
+func NewOAuthFlow(consumerKey, consumerSecret, callbackURL string) *OAuthFlow {
+	return &OAuthFlow{
+		service: newOAuthService(consumerKey, consumerSecret, callbackURL),
+		user:    new(oauth1a.UserConfig),
+		client:  new(http.Client),
+	}
+}
+
+// SetHTTPClient sets the http.Client used to get the tokens.
+func (f *OAuthFlow) SetHTTPClient(client *http.Client) {
+	f.client = client
+}
+
+// AuthorizeURL gets a request token and returns the URL of the page where the user authorizes the application.
+func (f *OAuthFlow) AuthorizeURL() (string, error) {
+	if err := f.user.GetRequestToken(f.service, f.client); err != nil {
+		return "", fmt.Errorf("gotumblr: getting the request token: %v", err)
+	}
+	return f.user.GetAuthorizeURL(f.service)
+}
+
+// Callback reads the verifier from the request Tumblr redirected the user to
+// and exchanges it for the access token and token secret.
+func (f *OAuthFlow) Callback(request *http.Request) (token, tokenSecret string, err error) {
+	requestToken, verifier, err := f.user.ParseAuthorize(request, f.service)
+	if err != nil {
+		return "", "", fmt.Errorf("gotumblr: reading the authorization: %v", err)
+	}
+	return f.Exchange(requestToken, verifier)
+}
+
+// Exchange exchanges the request token and the verifier given by Tumblr once the user authorized
+// the application for the access token and token secret.
+func (f *OAuthFlow) Exchange(requestToken, verifier string) (token, tokenSecret string, err error) {
+	if err := f.user.GetAccessToken(requestToken, verifier, f.service, f.client); err != nil {
+		return "", "", fmt.Errorf("gotumblr: getting the access token: %v", err)
+	}
+	return f.user.AccessTokenKey, f.user.AccessTokenSecret, nil
+}
+
+// newOAuthService returns the OAuth service of the Tumblr API for an application.
+func newOAuthService(consumerKey, consumerSecret, callbackURL string) *oauth1a.Service {
+	return &oauth1a.Service{
+		RequestURL:   "https://www.tumblr.com/oauth/request_token",
+		AuthorizeURL: "https://www.tumblr.com/oauth/authorize",
+		AccessURL:    "https://www.tumblr.com/oauth/access_token",
+		ClientConfig: &oauth1a.ClientConfig{
+			ConsumerKey:    consumerKey,
+			ConsumerSecret: consumerSecret,
+			CallbackURL:    callbackURL,
+		},
+		Signer: new(oauth1a.HmacSha1Signer),
+	}
+}
@@ -7,7 +7,7 @@ type OwnedBlog struct {
 	Title     string
 	Primary   bool
 	Followers int64
-	Tweet     string
-	Facebook  string
+	Tweet     string `json:",optional"`
+	Facebook  string `json:",optional"`
 	Type      string
 }
@@ -0,0 +1,148 @@
+package gotumblr
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// AspectRatio returns the width of the image divided by its height, or 0 if its height is unknown.
+func (s AltSize) AspectRatio() float64 {
+	if s.Height == 0 {
+		return 0
+	}
+	return float64(s.Width) / float64(s.Height)
+}
+
+// Original returns the original size of the photo,
+// falling back to its largest alternative size if the original is not known.
+func (p *PhotoObject) Original() AltSize {
+	if p.OriginalSize.URL != "" {
+		return p.OriginalSize
+	}
+	var largest AltSize
+	for _, size := range p.AltSizes {
+		if size.Width > largest.Width {
+			largest = size
+		}
+	}
+	return largest
+}
+
+// BestSize returns the smallest size of the photo that is at least width wide and height high.
+// A zero width or height is not constrained. If no size is large enough, the original is returned.
+// The square crops of the photo (e.g. its 75sq size) are skipped, see BestSquareSize.
+func (p *PhotoObject) BestSize(width, height int64) AltSize {
+	best := p.Original()
+	for _, size := range p.AltSizes {
+		if size.Width >= width && size.Height >= height && size.Width < best.Width && !p.isSquareCrop(size) {
+			best = size
+		}
+	}
+	return best
+}
+
+// BestSquareSize returns the smallest square crop of the photo that is at least side wide,
+// or the best uncropped size if there is none.
+func (p *PhotoObject) BestSquareSize(side int64) AltSize {
+	var best AltSize
+	for _, size := range p.AltSizes {
+		if size.Width >= side && p.isSquareCrop(size) && (best.URL == "" || size.Width < best.Width) {
+			best = size
+		}
+	}
+	if best.URL == "" {
+		return p.BestSize(side, side)
+	}
+	return best
+}
+
+// squareCropURL matches the URLs of the square crops of photos: tumblr_abc_75sq.jpg and /s75x75_c1/.
+var squareCropURL = regexp.MustCompile(`_\d+sq\.\w+$|/s75x75(_c\d+)?/`)
+
+// isSquareCrop reports whether a size of the photo is a square crop of a photo that is not square.
+func (p *PhotoObject) isSquareCrop(size AltSize) bool {
+	if squareCropURL.MatchString(size.URL) {
+		return true
+	}
+	original := p.Original()
+	return size.Width == size.Height && original.Height != 0 && original.Width != original.Height
+}
+
+// BestSizeForRatio returns the best size of the photo to display it width CSS pixels wide
+// on a screen with the given device pixel ratio (e.g. 2 for high density screens).
+func (p *PhotoObject) BestSizeForRatio(width int64, pixelRatio float64) AltSize {
+	if pixelRatio <= 0 {
+		pixelRatio = 1
+	}
+	return p.BestSize(int64(float64(width)*pixelRatio+0.5), 0)
+}
+
+// PhotosetRow holds the photos of a photoset that are displayed side by side.
+// AspectRatio is the aspect ratio of the whole row when its photos are scaled to the same height.
+type PhotosetRow struct {
+	Photos      []PhotoObject
+	AspectRatio float64
+}
+
+// PhotosetRows splits the photos of the post into rows as described by its photoset layout
+// (e.g. "132" is one photo, then three, then two). Photos not covered by the layout,
+// or all photos if the post has no layout, are placed one per row.
+func (p *PhotoPost) PhotosetRows() []PhotosetRow {
+	var rows []PhotosetRow
+	photos := p.Photos
+	for _, count := range p.PhotosetLayout {
+		n := int(count - '0')
+		if n <= 0 || n > 9 || n > len(photos) {
+			break
+		}
+		rows = append(rows, newPhotosetRow(photos[:n]))
+		photos = photos[n:]
+	}
+	for i := range photos {
+		rows = append(rows, newPhotosetRow(photos[i:i+1]))
+	}
+	return rows
+}
+
+func newPhotosetRow(photos []PhotoObject) PhotosetRow {
+	row := PhotosetRow{Photos: photos}
+	for i := range photos {
+		row.AspectRatio += photos[i].Original().AspectRatio()
+	}
+	return row
+}
+
+// mediaSizes maps the widths Tumblr serves media in to the size variant used in media URLs:
+// the suffix of legacy URLs (tumblr_abc_500.jpg) and the bounding box of newer ones (s500x750).
+var mediaSizes = map[int64][2]string{
+	75:   {"75sq", "s75x75"},
+	100:  {"100", "s100x200"},
+	250:  {"250", "s250x400"},
+	400:  {"400", "s400x600"},
+	500:  {"500", "s500x750"},
+	540:  {"540", "s540x810"},
+	640:  {"640", "s640x960"},
+	1280: {"1280", "s1280x1920"},
+	2048: {"", "s2048x3072"},
+}
+
+var (
+	legacyMediaSize = regexp.MustCompile(`_(\d+|75sq)(\.\w+)$`)
+	mediaBox        = regexp.MustCompile(`/s\d+x\d+(_c\d+)?/`)
+)
+
+// ResizeMediaURL rewrites the URL of an image hosted on media.tumblr.com to request it at the given width.
+// width can be: 75, 100, 250, 400, 500, 540, 640, 1280 or 2048 (not available for legacy URLs).
+func ResizeMediaURL(mediaURL string, width int64) (string, error) {
+	size, ok := mediaSizes[width]
+	if !ok {
+		return "", fmt.Errorf("gotumblr: invalid media width %d", width)
+	}
+	if mediaBox.MatchString(mediaURL) {
+		return mediaBox.ReplaceAllLiteralString(mediaURL, "/"+size[1]+"/"), nil
+	}
+	if legacyMediaSize.MatchString(mediaURL) && size[0] != "" {
+		return legacyMediaSize.ReplaceAllString(mediaURL, "_"+size[0]+"$2"), nil
+	}
+	return "", fmt.Errorf("gotumblr: cannot resize %q to width %d", mediaURL, width)
+}
@@ -0,0 +1,98 @@
+package gotumblr_test
+
+import (
+	"testing"
+
+	"github.com/spbr/gotumblr"
+)
+
+func legacyPhoto() *gotumblr.PhotoObject {
+	size := func(width, height int64, suffix string) gotumblr.AltSize {
+		return gotumblr.AltSize{Width: width, Height: height, URL: "https://64.media.tumblr.com/abc/tumblr_abc_" + suffix + ".jpg"}
+	}
+	return &gotumblr.PhotoObject{
+		OriginalSize: size(1280, 853, "1280"),
+		AltSizes: []gotumblr.AltSize{
+			size(1280, 853, "1280"), size(640, 427, "640"), size(500, 333, "500"), size(400, 267, "400"),
+			size(250, 167, "250"), size(100, 67, "100"), size(75, 75, "75sq"),
+		},
+	}
+}
+
+func TestBestSize(t *testing.T) {
+	photo := legacyPhoto()
+	for _, test := range []struct {
+		width, height int64
+		want          string
+	}{
+		{0, 0, "tumblr_abc_100.jpg"},
+		{50, 50, "tumblr_abc_100.jpg"},
+		{75, 0, "tumblr_abc_100.jpg"},
+		{75, 75, "tumblr_abc_250.jpg"},
+		{500, 0, "tumblr_abc_500.jpg"},
+		{501, 0, "tumblr_abc_640.jpg"},
+		{0, 400, "tumblr_abc_640.jpg"},
+		{2000, 0, "tumblr_abc_1280.jpg"},
+	} {
+		got := photo.BestSize(test.width, test.height)
+		if got.URL != "https://64.media.tumblr.com/abc/"+test.want {
+			t.Errorf("BestSize(%d, %d) = %s, want %s", test.width, test.height, got.URL, test.want)
+		}
+	}
+	if got := photo.BestSizeForRatio(250, 2); got.Width != 500 {
+		t.Errorf("BestSizeForRatio(250, 2) = %+v, want the 500 size", got)
+	}
+}
+
+func TestBestSquareSize(t *testing.T) {
+	photo := legacyPhoto()
+	if got := photo.BestSquareSize(64); got.Width != 75 || got.Height != 75 {
+		t.Errorf("BestSquareSize(64) = %+v, want the 75sq crop", got)
+	}
+	if got := photo.BestSquareSize(100); got.Width != 250 {
+		t.Errorf("BestSquareSize(100) = %+v, want the smallest size covering a square of 100", got)
+	}
+
+	// the sizes of a square photo are not crops
+	square := &gotumblr.PhotoObject{AltSizes: []gotumblr.AltSize{
+		{Width: 500, Height: 500, URL: "https://64.media.tumblr.com/abc/s500x750/x.jpg"},
+		{Width: 250, Height: 250, URL: "https://64.media.tumblr.com/abc/s250x400/x.jpg"},
+		{Width: 100, Height: 100, URL: "https://64.media.tumblr.com/abc/s100x200/x.jpg"},
+		{Width: 75, Height: 75, URL: "https://64.media.tumblr.com/abc/s75x75_c1/x.jpg"},
+	}}
+	if got := square.BestSize(0, 0); got.Width != 100 {
+		t.Errorf("BestSize(0, 0) of a square photo = %+v, want the 100 size", got)
+	}
+	if got := square.BestSquareSize(64); got.Width != 75 {
+		t.Errorf("BestSquareSize(64) of a square photo = %+v, want the 75 crop", got)
+	}
+}
+
+func TestResizeMediaURL(t *testing.T) {
+	for _, test := range []struct {
+		url   string
+		width int64
+		want  string
+	}{
+		{"https://64.media.tumblr.com/abc/tumblr_abc_500.jpg", 1280, "https://64.media.tumblr.com/abc/tumblr_abc_1280.jpg"},
+		{"https://64.media.tumblr.com/abc/tumblr_abc_500.jpg", 75, "https://64.media.tumblr.com/abc/tumblr_abc_75sq.jpg"},
+		{"https://64.media.tumblr.com/abc/tumblr_abc_75sq.png", 400, "https://64.media.tumblr.com/abc/tumblr_abc_400.png"},
+		{"https://64.media.tumblr.com/abc/tumblr_abc_500.jpg", 2048, ""},
+		{"https://64.media.tumblr.com/abc/s640x960/def.jpg", 250, "https://64.media.tumblr.com/abc/s250x400/def.jpg"},
+		{"https://64.media.tumblr.com/abc/s640x960/def.jpg", 2048, "https://64.media.tumblr.com/abc/s2048x3072/def.jpg"},
+		{"https://64.media.tumblr.com/abc/s75x75_c1/def.jpg", 640, "https://64.media.tumblr.com/abc/s640x960/def.jpg"},
+		{"https://64.media.tumblr.com/abc/s640x960/def.jpg", 300, ""},
+		{"https://example.com/photo.jpg", 500, ""},
+	} {
+		got, err := gotumblr.ResizeMediaURL(test.url, test.width)
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("ResizeMediaURL(%s, %d) = %s, want an error", test.url, test.width, got)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("ResizeMediaURL(%s, %d) = %s, %v, want %s", test.url, test.width, got, err, test.want)
+		}
+	}
+}
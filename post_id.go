@@ -0,0 +1,49 @@
+package gotumblr
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// PostID is the id of a Tumblr post.
+// Post ids exceed 2^53, so they are decoded from either a JSON number or a JSON string
+// without going through a float64, and can be safely passed back to the API.
+type PostID int64
+
+// ParsePostID parses a post id given in decimal.
+func ParsePostID(s string) (PostID, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("gotumblr: invalid post id %q", s)
+	}
+	return PostID(id), nil
+}
+
+// String returns the id in decimal, as expected by the API parameters.
+func (id PostID) String() string {
+	return strconv.FormatInt(int64(id), 10)
+}
+
+// MarshalJSON encodes the id as a JSON number.
+func (id PostID) MarshalJSON() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// UnmarshalJSON decodes the id from a JSON number or a JSON string holding a number.
+// A JSON null leaves the id unchanged.
+func (id *PostID) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	s := string(data)
+	if len(data) >= 2 && data[0] == '"' && data[len(data)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	parsed, err := ParsePostID(s)
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
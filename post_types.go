@@ -14,16 +14,21 @@ type AltSize struct {
 
 // PhotoObject holds information about a photo
 type PhotoObject struct {
-	Caption  string
-	AltSizes []AltSize
+	Caption      string
+	AltSizes     []AltSize              `json:"alt_sizes"`
+	OriginalSize AltSize                `json:"original_size"`
+	Exif         map[string]interface{} `json:",optional"` // e.g. Camera, ISO, Aperture, Exposure, FocalLength
 }
 
 // PhotoPost holds the information for a Tumblr photo post
 type PhotoPost struct {
 	BasePost
-	Photos        []PhotoObject
-	Caption       string
-	Width, Height int64
+	Photos         []PhotoObject
+	Caption        string
+	Width, Height  int64  `json:",optional"`
+	LinkURL        string `json:"link_url,optional"`
+	ImagePermalink string `json:"image_permalink"`
+	PhotosetLayout string `json:"photoset_layout,optional"`
 }
 
 // QuotePost holds the information for a Tumblr quote post
@@ -32,10 +37,21 @@ type QuotePost struct {
 	Text, Source string
 }
 
+// LinkImageDimensions holds the dimensions of the image of a link post
+type LinkImageDimensions struct {
+	Width, Height int64
+}
+
 // LinkPost holds the information for a Tumblr link post
 type LinkPost struct {
 	BasePost
 	Title, URL, Description string
+	LinkImage               string              `json:"link_image,optional"`
+	LinkImageDimensions     LinkImageDimensions `json:"link_image_dimensions,optional"`
+	Excerpt                 string              `json:",optional"`
+	Publisher               string              `json:",optional"`
+	Author                  string              `json:"link_author,optional"`
+	Photos                  []PhotoObject       `json:",optional"`
 }
 
 // DialogueInfo holds the dialog information from a chat item
@@ -53,35 +69,48 @@ type ChatPost struct {
 // AudioPost holds the information for a Tumblr audio post
 type AudioPost struct {
 	BasePost
-	Caption     string
-	Player      string
-	Plays       int64
-	AlbumArt    string
-	Artist      string
-	Album       string
-	TrackName   string
-	TrackNumber int64
-	Year        int64
+	Caption        string
+	Player         string
+	Plays          int64
+	AlbumArt       string `json:"album_art,optional"`
+	Artist         string `json:",optional"`
+	Album          string `json:",optional"`
+	ID3Title       string `json:"id3_title,optional"`
+	TrackName      string `json:"track_name,optional"`
+	TrackNumber    int64  `json:"track_number,optional"`
+	Year           int64  `json:",optional"`
+	AudioURL       string `json:"audio_url"`
+	AudioSourceURL string `json:"audio_source_url,optional"`
+	AudioType      string `json:"audio_type"`
+	Embed          string
 }
 
 // PlayerInfo holds infromation about a video player
 type PlayerInfo struct {
 	Width     int64
-	EmbedCode string
+	EmbedCode string `json:"embed_code"`
 }
 
 // VideoPost contains the information for a Tumblr video post
 type VideoPost struct {
 	BasePost
-	Caption string
-	Player  []PlayerInfo
+	Caption         string
+	Player          []PlayerInfo
+	VideoURL        string  `json:"video_url,optional"`
+	VideoType       string  `json:"video_type"`
+	PermalinkURL    string  `json:"permalink_url,optional"`
+	HTML5Capable    bool    `json:"html5_capable,optional"`
+	ThumbnailURL    string  `json:"thumbnail_url,optional"`
+	ThumbnailWidth  int64   `json:"thumbnail_width,optional"`
+	ThumbnailHeight int64   `json:"thumbnail_height,optional"`
+	Duration        float64 `json:",optional"` // in seconds
 }
 
 // AnswerPost contains the information for a Tumblr answer post
 type AnswerPost struct {
 	BasePost
-	AskingName string
-	AskingURL  string
+	AskingName string `json:"asking_name"`
+	AskingURL  string `json:"asking_url"`
 	Question   string
 	Answer     string
 }
@@ -0,0 +1,186 @@
+package gotumblr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// DefaultHost is the host of the Tumblr API used by profiles that do not set one.
+const DefaultHost = "https://api.tumblr.com"
+
+// Profile holds the credentials of a client of the API and the host it connects to.
+type Profile struct {
+	ConsumerKey    string `json:"consumer_key"`
+	ConsumerSecret string `json:"consumer_secret"`
+	Token          string `json:"token,omitempty"`
+	TokenSecret    string `json:"token_secret,omitempty"`
+	Host           string `json:"host,omitempty"`
+}
+
+// Config is the configuration file of gotumblr, holding named profiles:
+//
+//	{
+//	  "default_profile": "work",
+//	  "profiles": {
+//	    "work": {"consumer_key": "...", "consumer_secret": "...", "token": "...", "token_secret": "..."}
+//	  }
+//	}
+type Config struct {
+	DefaultProfile string             `json:"default_profile,omitempty"`
+	Profiles       map[string]Profile `json:"profiles"`
+}
+
+// Environment variables read by LoadProfile.
+const (
+	EnvConfig         = "TUMBLR_CONFIG"          // path of the configuration file
+	EnvProfile        = "TUMBLR_PROFILE"         // name of the profile used by default
+	EnvConsumerKey    = "TUMBLR_CONSUMER_KEY"    // overrides the consumer key of the profile
+	EnvConsumerSecret = "TUMBLR_CONSUMER_SECRET" // overrides the consumer secret of the profile
+	EnvToken          = "TUMBLR_TOKEN"           // overrides the token of the profile
+	EnvTokenSecret    = "TUMBLR_TOKEN_SECRET"    // overrides the token secret of the profile
+	EnvHost           = "TUMBLR_HOST"            // overrides the host of the profile
+)
+
+// DefaultConfigPath returns the path of the configuration file: the value of TUMBLR_CONFIG if set,
+// otherwise gotumblr/config.json in the user's configuration directory (e.g. ~/.config on Linux).
+func DefaultConfigPath() (string, error) {
+	if path := os.Getenv(EnvConfig); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gotumblr", "config.json"), nil
+}
+
+// LoadConfig reads a configuration file. A missing file gives an empty configuration.
+func LoadConfig(path string) (*Config, error) {
+	config := &Config{Profiles: map[string]Profile{}}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("gotumblr: reading %s: %v", path, err)
+	}
+	if config.Profiles == nil {
+		config.Profiles = map[string]Profile{}
+	}
+	return config, nil
+}
+
+// Save writes the configuration file, creating its directory if needed. The file is replaced
+// atomically, by renaming a temporary file. As it holds credentials, the file is only readable
+// and writable by the user.
+func (c *Config) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	file, err := ioutil.TempFile(dir, ".config-")
+	if err != nil {
+		return err
+	}
+	// the file is replaced only by a complete new file, synced to the disk first
+	_, err = file.Write(append(data, '\n'))
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), 0600)
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+// Profile returns the profile with the given name. An empty name selects the profile named
+// by TUMBLR_PROFILE, else the default profile of the configuration, else the profile "default".
+// Only a profile selected by name must exist; otherwise an empty profile is returned.
+func (c *Config) Profile(name string) (Profile, error) {
+	explicit := true
+	if name == "" {
+		name = os.Getenv(EnvProfile)
+	}
+	if name == "" {
+		name, explicit = c.DefaultProfile, false
+	}
+	if name == "" {
+		name = "default"
+	}
+	profile, ok := c.Profiles[name]
+	if !ok && explicit {
+		return Profile{}, fmt.Errorf("gotumblr: no profile named %q", name)
+	}
+	return profile, nil
+}
+
+// LoadProfile returns a profile of the configuration file at DefaultConfigPath (see Config.Profile),
+// with the values set by the TUMBLR_CONSUMER_KEY, TUMBLR_CONSUMER_SECRET, TUMBLR_TOKEN,
+// TUMBLR_TOKEN_SECRET and TUMBLR_HOST environment variables taking precedence.
+// It fails if the profile has no consumer key.
+func LoadProfile(name string) (Profile, error) {
+	path, err := DefaultConfigPath()
+	if err != nil {
+		return Profile{}, err
+	}
+	config, err := LoadConfig(path)
+	if err != nil {
+		return Profile{}, err
+	}
+	profile, err := config.Profile(name)
+	if err != nil {
+		return Profile{}, err
+	}
+	for variable, field := range map[string]*string{
+		EnvConsumerKey:    &profile.ConsumerKey,
+		EnvConsumerSecret: &profile.ConsumerSecret,
+		EnvToken:          &profile.Token,
+		EnvTokenSecret:    &profile.TokenSecret,
+		EnvHost:           &profile.Host,
+	} {
+		if value := os.Getenv(variable); value != "" {
+			*field = value
+		}
+	}
+	if profile.ConsumerKey == "" {
+		return Profile{}, fmt.Errorf("gotumblr: no consumer key in the profile or in %s", EnvConsumerKey)
+	}
+	return profile, nil
+}
+
+// NewClient creates a client with the credentials of the profile.
+func (p Profile) NewClient() *TumblrRestClient {
+	host := p.Host
+	if host == "" {
+		host = DefaultHost
+	}
+	return NewTumblrRestClient(p.ConsumerKey, p.ConsumerSecret, p.Token, p.TokenSecret, "", host)
+}
+
+// NewClientFromProfile creates a client with the credentials of a profile loaded by LoadProfile.
+func NewClientFromProfile(name string) (*TumblrRestClient, error) {
+	profile, err := LoadProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	return profile.NewClient(), nil
+}
@@ -0,0 +1,126 @@
+package gotumblr_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spbr/gotumblr"
+)
+
+// setConfig points TUMBLR_CONFIG to a new configuration file and clears the other variables.
+func setConfig(t *testing.T, config *gotumblr.Config) string {
+	path := filepath.Join(t.TempDir(), "gotumblr", "config.json")
+	if err := config.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(gotumblr.EnvConfig, path)
+	for _, variable := range []string{gotumblr.EnvProfile, gotumblr.EnvConsumerKey, gotumblr.EnvConsumerSecret,
+		gotumblr.EnvToken, gotumblr.EnvTokenSecret, gotumblr.EnvHost} {
+		t.Setenv(variable, "")
+	}
+	return path
+}
+
+func TestLoadProfile(t *testing.T) {
+	setConfig(t, &gotumblr.Config{
+		DefaultProfile: "work",
+		Profiles: map[string]gotumblr.Profile{
+			"work":     {ConsumerKey: "work-key", ConsumerSecret: "work-secret", Token: "work-token", TokenSecret: "work-token-secret"},
+			"personal": {ConsumerKey: "personal-key", Host: "https://example.com"},
+		},
+	})
+	for _, test := range []struct {
+		name, envProfile, want string
+	}{
+		{"", "", "work-key"},
+		{"", "personal", "personal-key"},
+		{"work", "personal", "work-key"},
+	} {
+		t.Setenv(gotumblr.EnvProfile, test.envProfile)
+		profile, err := gotumblr.LoadProfile(test.name)
+		if err != nil || profile.ConsumerKey != test.want {
+			t.Errorf("LoadProfile(%q) with %s=%q = %+v, %v, want %s", test.name, gotumblr.EnvProfile, test.envProfile, profile, err, test.want)
+		}
+	}
+	t.Setenv(gotumblr.EnvProfile, "")
+	if _, err := gotumblr.LoadProfile("missing"); err == nil {
+		t.Error("LoadProfile of a missing profile succeeded")
+	}
+}
+
+func TestLoadProfileEnvPrecedence(t *testing.T) {
+	setConfig(t, &gotumblr.Config{Profiles: map[string]gotumblr.Profile{
+		"default": {ConsumerKey: "key", ConsumerSecret: "secret", Token: "token", TokenSecret: "token-secret", Host: "https://example.com"},
+	}})
+	t.Setenv(gotumblr.EnvConsumerKey, "env-key")
+	t.Setenv(gotumblr.EnvToken, "env-token")
+	t.Setenv(gotumblr.EnvHost, "https://env.example.com")
+	profile, err := gotumblr.LoadProfile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := gotumblr.Profile{ConsumerKey: "env-key", ConsumerSecret: "secret", Token: "env-token",
+		TokenSecret: "token-secret", Host: "https://env.example.com"}
+	if profile != want {
+		t.Errorf("LoadProfile = %+v, want %+v", profile, want)
+	}
+
+	// the variables are enough without a configuration file
+	t.Setenv(gotumblr.EnvConfig, filepath.Join(t.TempDir(), "missing.json"))
+	if profile, err := gotumblr.LoadProfile(""); err != nil || profile.ConsumerKey != "env-key" {
+		t.Errorf("LoadProfile without a file = %+v, %v, want the key of the environment", profile, err)
+	}
+	t.Setenv(gotumblr.EnvConsumerKey, "")
+	if _, err := gotumblr.LoadProfile(""); err == nil {
+		t.Error("LoadProfile without a consumer key succeeded")
+	}
+}
+
+func TestConfigSave(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	// an existing file readable by others is replaced by a private one
+	if err := ioutil.WriteFile(path, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := &gotumblr.Config{DefaultProfile: "a", Profiles: map[string]gotumblr.Profile{"a": {ConsumerKey: "key"}}}
+	if err := config.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("the configuration file has the mode %v, want 0600", info.Mode().Perm())
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("files %v left in the directory, want the configuration only", entries)
+	}
+	loaded, err := gotumblr.LoadConfig(path)
+	if err != nil || loaded.DefaultProfile != "a" || loaded.Profiles["a"].ConsumerKey != "key" {
+		t.Errorf("LoadConfig = %+v, %v, want the saved configuration", loaded, err)
+	}
+
+	// a failed save leaves the file as it was
+	if runtime.GOOS != "windows" && os.Getuid() != 0 {
+		if err := os.Chmod(dir, 0500); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Chmod(dir, 0700)
+		config.DefaultProfile = "b"
+		if err := config.Save(path); err == nil {
+			t.Error("Save to a read-only directory succeeded")
+		}
+		if loaded, err := gotumblr.LoadConfig(path); err != nil || loaded.DefaultProfile != "a" {
+			t.Errorf("LoadConfig after a failed save = %+v, %v, want the previous configuration", loaded, err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package gotumblr
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimiter limits the rate of the requests sent by a client, see TumblrRestClient.SetRateLimiter.
+// It is shared by all the goroutines using the client.
+type RateLimiter interface {
+	// Wait blocks until a request may be sent, or returns the error of ctx if it is done first.
+	Wait(ctx context.Context) error
+}
+
+// TokenBucket is a RateLimiter letting through a number of requests per second on average,
+// and bursts of up to a number of requests. The Tumblr API limits most applications to
+// 1000 requests per hour and 5000 per day, and 250 new posts per day.
+type TokenBucket struct {
+	mutex    sync.Mutex
+	interval time.Duration // time between two tokens, 0 for no limit
+	burst    int
+	tokens   float64
+	last     time.Time
+}
+
+// NewTokenBucket creates a TokenBucket letting through perSecond requests per second
+// and bursts of burst requests, starting full. A bucket with no positive rate never waits.
+func NewTokenBucket(perSecond float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	interval := time.Duration(0)
+	if perSecond > 0 && !math.IsInf(perSecond, 1) {
+		interval = time.Duration(float64(time.Second) / perSecond)
+	}
+	return &TokenBucket{
+		interval: interval,
+		burst:    burst,
+		tokens:   float64(burst),
+		last:     time.Now(),
+	}
+}
+
+// Wait takes a token from the bucket, waiting for one if it is empty.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	if b.interval <= 0 {
+		return nil
+	}
+	b.mutex.Lock()
+	now := time.Now()
+	b.tokens += float64(now.Sub(b.last)) / float64(b.interval)
+	if b.tokens > float64(b.burst) {
+		b.tokens = float64(b.burst)
+	}
+	b.last = now
+	// the token is taken now, so that the waiting requests are let through in order
+	b.tokens--
+	wait := time.Duration(-b.tokens * float64(b.interval))
+	b.mutex.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// give the token back
+		b.mutex.Lock()
+		b.tokens++
+		b.mutex.Unlock()
+		return ctx.Err()
+	}
+}
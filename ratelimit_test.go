@@ -0,0 +1,40 @@
+package gotumblr
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketNoLimit(t *testing.T) {
+	for _, perSecond := range []float64{0, -1, math.NaN(), math.Inf(1), math.Inf(-1), 1e-300 * 1e-300} {
+		bucket := NewTokenBucket(perSecond, 1)
+		start := time.Now()
+		for i := 0; i < 100; i++ {
+			if err := bucket.Wait(context.Background()); err != nil {
+				t.Fatalf("rate %v: %v", perSecond, err)
+			}
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("rate %v: 100 requests waited for %v, want no wait", perSecond, elapsed)
+		}
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	bucket := NewTokenBucket(0.001, 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		if err := bucket.Wait(ctx); err != nil {
+			t.Fatalf("request %d of the burst: %v", i, err)
+		}
+	}
+	if err := bucket.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("request after the burst: err = %v, want the error of the context", err)
+	}
+	if bucket.tokens < 0 || bucket.tokens > 0.01 {
+		t.Errorf("%v tokens after a cancelled wait, want the token given back", bucket.tokens)
+	}
+}
@@ -3,12 +3,22 @@
 package gotumblr
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"github.com/kurrik/oauth1a"
+	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 	"net/url"
+	"reflect"
+	"strconv"
 	"strings"
+	"time"
 )
 
 //TumblrRequest a structure to connect to Tumblr
@@ -17,6 +27,14 @@ type TumblrRequest struct {
 	userConfig *oauth1a.UserConfig
 	host       string
 	apiKey     string
+	client     *http.Client
+	strict     bool
+	cache      *Cache
+	flights    flightGroup
+	hooks      Hooks
+	limiter    RateLimiter
+
+	responseHook func(*CompleteResponse)
 }
 
 //NewTumblrRequest initializes the TumblrRequest.
@@ -27,79 +45,378 @@ type TumblrRequest struct {
 //oauthSecret is the user specific secret, received from the /access_token endpoint.
 //host is the host that you are tryng to send information to (e.g. http://api.tumblr.com).
 func NewTumblrRequest(consumerKey, consumerSecret, oauthToken, oauthSecret, callbackURL, host string) *TumblrRequest {
-	service := &oauth1a.Service{
-		RequestURL:   "http://www.tumblr.com/oauth/request_token",
-		AuthorizeURL: "http://www.tumblr.com/oauth/authorize",
-		AccessURL:    "http://www.tumblr.com/oauth/access_token",
-		ClientConfig: &oauth1a.ClientConfig{
-			ConsumerKey:    consumerKey,
-			ConsumerSecret: consumerSecret,
-			CallbackURL:    callbackURL,
-		},
-		Signer: new(oauth1a.HmacSha1Signer),
-	}
+	service := newOAuthService(consumerKey, consumerSecret, callbackURL)
 	userConfig := oauth1a.NewAuthorizedConfig(oauthToken, oauthSecret)
-	return &TumblrRequest{service, userConfig, host, consumerKey}
+	return &TumblrRequest{service: service, userConfig: userConfig, host: host, apiKey: consumerKey}
+}
+
+//SetHTTPClient sets the http.Client used to send the requests to the API.
+//If it is not set or is nil, a new http.Client is used for every request.
+func (tr *TumblrRequest) SetHTTPClient(client *http.Client) {
+	tr.client = client
+}
+
+//SetStrict enables or disables strict decoding. In strict mode the fields modelled by gotumblr
+//that are missing from a response are reported as a *DecodeError, which helps to catch the fields
+//renamed or removed by the API; the fields gotumblr does not model are ignored.
+//The posts of the responses, left undecoded, are checked when decoded with TumblrRequest.DecodePost.
+func (tr *TumblrRequest) SetStrict(strict bool) {
+	tr.strict = strict
+}
+
+//SetResponseHook sets a function that is called with every response received from the API,
+//giving access to its HTTP status, headers and meta information.
+//The hook must not modify the response.
+func (tr *TumblrRequest) SetResponseHook(hook func(*CompleteResponse)) {
+	tr.responseHook = hook
+}
+
+//SetCache sets the cache of the responses to GET requests, see Cache.
+//A nil cache disables caching.
+func (tr *TumblrRequest) SetCache(cache *Cache) {
+	tr.cache = cache
+}
+
+//SetHooks sets the hooks called around every request sent to the API, see Hooks.
+//Use CombineHooks to set several hooks.
+func (tr *TumblrRequest) SetHooks(hooks Hooks) {
+	tr.hooks = hooks
+}
+
+//SetRateLimiter sets the limiter every request waits for before being sent, see RateLimiter.
+//Cached responses and coalesced requests do not wait.
+func (tr *TumblrRequest) SetRateLimiter(limiter RateLimiter) {
+	tr.limiter = limiter
+}
+
+//httpClient returns the http.Client requests should be sent with.
+func (tr *TumblrRequest) httpClient() *http.Client {
+	if tr.client == nil {
+		return new(http.Client)
+	}
+	return tr.client
 }
 
 //Get makes a GET request to the API with properly formatted parameters.
 //requestURL: the url you are making the request to.
 //params: the parameters needed for the request.
 func (tr *TumblrRequest) Get(requestURL string, params map[string]string) (*CompleteResponse, error) {
-	fullURL := tr.host + requestURL
-	if len(params) != 0 {
-		values := url.Values{}
-		for key, value := range params {
-			values.Set(key, value)
+	return tr.GetContext(context.Background(), requestURL, params)
+}
+
+//GetContext makes a GET request to the API like Get, returning ctx.Err() once ctx is done.
+//Identical GET requests made concurrently are coalesced: a single request is sent
+//and its response is returned to all the callers, so it must not be modified.
+//The request is cancelled once the contexts of all its callers are done.
+func (tr *TumblrRequest) GetContext(ctx context.Context, requestURL string, params map[string]string) (*CompleteResponse, error) {
+	key := cacheKey(requestURL, params, tr.account())
+	var entry *cacheEntry
+	flightKey := key
+	if tr.cache != nil {
+		if ttl := tr.cache.ttl(requestURL); ttl > 0 {
+			entry = &cacheEntry{key: key, ttl: ttl, blog: blogOf(requestURL)}
+			// the requests made after an invalidation do not join the flights started before
+			entry.generation = tr.cache.generation(entry.blog)
+			flightKey += "@" + strconv.FormatUint(entry.generation, 10)
 		}
-		fullURL = fullURL + "?" + values.Encode()
 	}
-	httpRequest, err := http.NewRequest("GET", fullURL, nil)
-	if err != nil {
-		return nil, err
+	return tr.flights.do(ctx, flightKey, func(ctx context.Context) (*CompleteResponse, error) {
+		httpRequest, err := tr.newGetRequest(ctx, requestURL, params)
+		if err != nil {
+			return nil, err
+		}
+		if entry != nil {
+			return tr.getCached(httpRequest, entry)
+		}
+		data, _, err := tr.send(httpRequest, tr.httpClient(), nil)
+		return data, err
+	})
+}
+
+//account identifies the API host and the account requests are sent for,
+//by a fingerprint of the consumer key and the token, e.g. api.tumblr.com/3f2a9c1e04d5b6a7.
+func (tr *TumblrRequest) account() string {
+	host := tr.host
+	if u, err := url.Parse(tr.host); err == nil && u.Host != "" {
+		host = u.Host
 	}
-	tr.service.Sign(httpRequest, tr.userConfig)
-	var httpResponse *http.Response
-	httpClient := new(http.Client)
-	httpResponse, err2 := httpClient.Do(httpRequest)
-	if err2 != nil {
-		return nil, err2
+	sum := sha256.Sum256([]byte(tr.apiKey + "&" + tr.userConfig.AccessTokenKey))
+	return host + "/" + hex.EncodeToString(sum[:8])
+}
+
+//getCached answers a GET request from the cache while the cached response is fresh,
+//and sends it otherwise, see send.
+func (tr *TumblrRequest) getCached(httpRequest *http.Request, entry *cacheEntry) (*CompleteResponse, error) {
+	cached, ok := tr.cache.Store.Get(entry.key)
+	if ok && tr.cache.now().Before(cached.Expires) {
+		return tr.parse(cached.StatusCode, cached.Header, cached.Body)
 	}
-	defer httpResponse.Body.Close()
-	body, err3 := ioutil.ReadAll(httpResponse.Body)
-	if err3 != nil {
-		return nil, err3
+	if ok {
+		entry.stale = cached
 	}
-	return tr.JSONParse(body)
+	data, _, err := tr.send(httpRequest, tr.httpClient(), entry)
+	return data, err
 }
 
 //Post makes a POST request to the API, allows for multipart data uploads.
 //requestURL: the url you are making the request to.
 //params: all the parameters needed for the request.
 func (tr *TumblrRequest) Post(requestURL string, params map[string]string) (*CompleteResponse, error) {
+	return tr.PostContext(context.Background(), requestURL, params)
+}
+
+//PostContext makes a POST request to the API like Post, with a context.
+func (tr *TumblrRequest) PostContext(ctx context.Context, requestURL string, params map[string]string) (*CompleteResponse, error) {
 	fullURL := tr.host + requestURL
 	values := url.Values{}
 	for key, value := range params {
 		values.Set(key, value)
 	}
-	httpRequest, err := http.NewRequest("POST", fullURL, strings.NewReader(values.Encode()))
+	httpRequest, err := http.NewRequestWithContext(ctx, "POST", fullURL, strings.NewReader(values.Encode()))
 	if err != nil {
 		return nil, err
 	}
 	httpRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return tr.sendPost(httpRequest, requestURL)
+}
+
+//PostUploadContext makes a POST request to the API like PostContext, sending the parameters
+//and the uploaded files as multipart form data. As required by OAuth, only the query
+//parameters are signed. The uploads are read before the request is sent.
+func (tr *TumblrRequest) PostUploadContext(ctx context.Context, requestURL string, params map[string]string, uploads []Upload) (*CompleteResponse, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for key, value := range params {
+		if err := writer.WriteField(key, value); err != nil {
+			return nil, err
+		}
+	}
+	for _, upload := range uploads {
+		part, err := writer.CreateFormFile(upload.Param, upload.Filename)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(part, upload.Content); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, "POST", tr.host+requestURL, &body)
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Header.Set("Content-Type", writer.FormDataContentType())
+	return tr.sendPost(httpRequest, requestURL)
+}
+
+//PostJSONContext makes a POST request to the API like PostContext, sending body encoded as JSON.
+//As required by OAuth, only the query parameters are signed.
+func (tr *TumblrRequest) PostJSONContext(ctx context.Context, requestURL string, body interface{}) (*CompleteResponse, error) {
+	content, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, "POST", tr.host+requestURL, bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	return tr.sendPost(httpRequest, requestURL)
+}
+
+//sendPost sends a POST request and drops the cached responses of the blog it changes.
+func (tr *TumblrRequest) sendPost(httpRequest *http.Request, requestURL string) (*CompleteResponse, error) {
+	data, _, err := tr.send(httpRequest, tr.httpClient(), nil)
+	if tr.cache != nil {
+		if blog := blogOf(requestURL); blog != "" {
+			tr.cache.invalidate(blog)
+		}
+	}
+	return data, err
+}
+
+//GetRedirect makes a GET request to the API without following redirects.
+//If the API answers with a redirect, the redirect location is returned
+//along with a response that has no meta information,
+//otherwise the JSON response is parsed and the location is empty.
+//requestURL: the url you are making the request to.
+//params: the parameters needed for the request.
+func (tr *TumblrRequest) GetRedirect(requestURL string, params map[string]string) (*CompleteResponse, string, error) {
+	return tr.GetRedirectContext(context.Background(), requestURL, params)
+}
+
+//GetRedirectContext makes a GET request to the API like GetRedirect, with a context.
+func (tr *TumblrRequest) GetRedirectContext(ctx context.Context, requestURL string, params map[string]string) (*CompleteResponse, string, error) {
+	httpRequest, err := tr.newGetRequest(ctx, requestURL, params)
+	if err != nil {
+		return nil, "", err
+	}
+	httpClient := *tr.httpClient()
+	httpClient.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return tr.send(httpRequest, &httpClient, nil)
+}
+
+//newGetRequest creates a GET request to the API with the parameters encoded in the query.
+func (tr *TumblrRequest) newGetRequest(ctx context.Context, requestURL string, params map[string]string) (*http.Request, error) {
+	fullURL := tr.host + requestURL
+	if len(params) != 0 {
+		values := url.Values{}
+		for key, value := range params {
+			values.Set(key, value)
+		}
+		fullURL = fullURL + "?" + values.Encode()
+	}
+	return http.NewRequestWithContext(ctx, "GET", fullURL, nil)
+}
+
+//cacheEntry is the cache entry of a GET request sent by getCached.
+type cacheEntry struct {
+	key        string
+	ttl        time.Duration
+	blog       string          // the blog of the endpoint, see blogOf
+	generation uint64          // the generation of the blog when the request was made, see Cache.set
+	stale      *CachedResponse // the stale cached response, or nil
+}
+
+//send signs and sends a request to the API, once the rate limiter lets it through, and parses its response.
+//Redirect responses are not parsed, their location is returned instead.
+//Responses that are not JSON and have an error status are reported as an *APIError.
+//The response hook, if any, is called with every response.
+//
+//With a cache entry, the request is made conditional if the stale response has a validator,
+//a 304 response refreshes the stale response, and a successful response is cached.
+func (tr *TumblrRequest) send(httpRequest *http.Request, httpClient *http.Client, entry *cacheEntry) (data *CompleteResponse, location string, err error) {
+	if entry != nil && entry.stale != nil {
+		if entry.stale.ETag != "" {
+			httpRequest.Header.Set("If-None-Match", entry.stale.ETag)
+		}
+		if entry.stale.LastModified != "" {
+			httpRequest.Header.Set("If-Modified-Since", entry.stale.LastModified)
+		}
+	}
+	if tr.limiter != nil {
+		if err := tr.limiter.Wait(httpRequest.Context()); err != nil {
+			return nil, "", err
+		}
+	}
+	httpRequest, finish := tr.startRequest(httpRequest)
+	httpResponse, body, err := tr.roundTrip(httpRequest, httpClient)
+	defer func() { finish(httpResponse, int64(len(body)), data, err) }()
+	if err != nil {
+		return nil, "", err
+	}
+	switch httpResponse.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		redirect, err := httpResponse.Location()
+		if err != nil {
+			return nil, "", err
+		}
+		location = redirect.String()
+		data = &CompleteResponse{StatusCode: httpResponse.StatusCode, Header: httpResponse.Header}
+	case http.StatusNotModified:
+		if entry == nil || entry.stale == nil {
+			return nil, "", &APIError{StatusCode: httpResponse.StatusCode}
+		}
+		data, err = tr.parse(entry.stale.StatusCode, entry.stale.Header, entry.stale.Body)
+		if err != nil {
+			return nil, "", err
+		}
+		refreshed := *entry.stale
+		refreshed.Expires = tr.cache.now().Add(entry.ttl)
+		tr.cache.set(&refreshed, entry.blog, entry.generation)
+	default:
+		data, err = tr.parse(httpResponse.StatusCode, httpResponse.Header, body)
+		if err != nil {
+			return nil, "", err
+		}
+		if entry != nil && httpResponse.StatusCode == http.StatusOK && data.Meta.Status == http.StatusOK {
+			tr.cache.set(&CachedResponse{
+				Key:          entry.key,
+				StatusCode:   httpResponse.StatusCode,
+				Header:       httpResponse.Header,
+				Body:         body,
+				Expires:      tr.cache.now().Add(entry.ttl),
+				ETag:         httpResponse.Header.Get("ETag"),
+				LastModified: httpResponse.Header.Get("Last-Modified"),
+			}, entry.blog, entry.generation)
+		}
+	}
+	if tr.responseHook != nil {
+		tr.responseHook(data)
+	}
+	return data, location, nil
+}
+
+//roundTrip signs and sends a request to the API and reads the body of its response,
+//unless it is a redirect. The body of the returned response is closed.
+func (tr *TumblrRequest) roundTrip(httpRequest *http.Request, httpClient *http.Client) (*http.Response, []byte, error) {
 	tr.service.Sign(httpRequest, tr.userConfig)
-	var httpResponse *http.Response
-	httpClient := new(http.Client)
-	httpResponse, err2 := httpClient.Do(httpRequest)
-	if err2 != nil {
-		return nil, err2
+	httpResponse, err := httpClient.Do(httpRequest)
+	if err != nil {
+		return nil, nil, err
 	}
 	defer httpResponse.Body.Close()
-	body, err3 := ioutil.ReadAll(httpResponse.Body)
-	if err3 != nil {
-		return nil, err3
+	if httpResponse.StatusCode/100 == 3 && httpResponse.Header.Get("Location") != "" {
+		return httpResponse, nil, nil
 	}
-	return tr.JSONParse(body)
+	body, err := ioutil.ReadAll(httpResponse.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return httpResponse, body, nil
+}
+
+//parse parses the body of a response.
+//Bodies that are not JSON are reported as an *APIError if the status is an error.
+func (tr *TumblrRequest) parse(statusCode int, header http.Header, body []byte) (*CompleteResponse, error) {
+	data, err := tr.JSONParse(body)
+	if err != nil {
+		if statusCode/100 != 2 {
+			return nil, &APIError{StatusCode: statusCode}
+		}
+		return nil, err
+	}
+	data.StatusCode = statusCode
+	data.Header = header
+	return data, nil
+}
+
+//CheckResponse returns an *APIError unless both the HTTP status
+//and the meta status of the response are the expected status.
+//The HTTP status is not checked for responses parsed with JSONParse.
+func (tr *TumblrRequest) CheckResponse(data *CompleteResponse, expectedStatus int) error {
+	if (data.StatusCode != 0 && data.StatusCode != expectedStatus) || data.Meta.Status != int64(expectedStatus) {
+		return &APIError{StatusCode: data.StatusCode, Meta: data.Meta}
+	}
+	return nil
+}
+
+//Download makes an unsigned GET request to the given absolute URL (e.g. a media URL)
+//and copies the response body to w. It returns the number of bytes written.
+func (tr *TumblrRequest) Download(rawURL string, w io.Writer) (int64, error) {
+	return tr.DownloadContext(context.Background(), rawURL, w)
+}
+
+//DownloadContext downloads a URL like Download, with a context.
+func (tr *TumblrRequest) DownloadContext(ctx context.Context, rawURL string, w io.Writer) (int64, error) {
+	httpRequest, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	httpResponse, err := tr.httpClient().Do(httpRequest)
+	if err != nil {
+		return 0, err
+	}
+	defer httpResponse.Body.Close()
+	if httpResponse.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("gotumblr: downloading %s: %s", rawURL, httpResponse.Status)
+	}
+	return io.Copy(w, httpResponse.Body)
 }
 
 //JSONParse is a convenience function to parse JSON response.
@@ -108,7 +425,33 @@ func (tr *TumblrRequest) JSONParse(content []byte) (*CompleteResponse, error) {
 	var data CompleteResponse
 	err := json.Unmarshal(content, &data)
 	if err != nil {
-		return nil, err
+		return nil, &DecodeError{content, err}
 	}
 	return &data, nil
 }
+
+//Decode decodes the response field of a CompleteResponse into v,
+//checking in strict mode that the response has all the fields of v.
+//Errors are reported as a *DecodeError.
+func (tr *TumblrRequest) Decode(response json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(response, v); err != nil {
+		return &DecodeError{response, err}
+	}
+	if tr.strict {
+		if err := checkFields(response, reflect.TypeOf(v), ""); err != nil {
+			return &DecodeError{response, err}
+		}
+	}
+	return nil
+}
+
+//DecodePost decodes a post like the DecodePost function,
+//reporting the fields of the post type that are missing from the post in strict mode.
+func (tr *TumblrRequest) DecodePost(data json.RawMessage) (Post, error) {
+	return decodePost(data, tr.strict)
+}
+
+//DecodePosts decodes a list of posts with TumblrRequest.DecodePost.
+func (tr *TumblrRequest) DecodePosts(data []json.RawMessage) ([]Post, error) {
+	return decodePosts(data, tr.strict)
+}
@@ -0,0 +1,98 @@
+package gotumblr
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// checkFields reports the first field of the type t that is missing from the JSON data, which
+// is how a renamed or removed field of the API shows. Fields that the API only sends with some
+// responses carry the optional option in their JSON tag, e.g. `json:"featured_timestamp,optional"`.
+// The fields of the data that t does not model are ignored, as the API adds fields freely.
+func checkFields(data json.RawMessage, t reflect.Type, path string) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if string(data) == "null" {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(data, &fields) != nil {
+			return nil // not an object: the type errors are reported by the decoding
+		}
+		return checkStruct(fields, t, path)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return nil // json.RawMessage
+		}
+		var items []json.RawMessage
+		if json.Unmarshal(data, &items) != nil {
+			return nil
+		}
+		for i, item := range items {
+			if err := checkFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkStruct checks the fields of an object decoded into the struct type t, see checkFields.
+func checkStruct(fields map[string]json.RawMessage, t reflect.Type, path string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if field.PkgPath != "" || tag == "-" {
+			continue
+		}
+		options := strings.Split(tag, ",")
+		name := options[0]
+		if field.Anonymous && name == "" {
+			if err := checkStruct(fields, field.Type, path); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name) // the keys of the API are lower case
+		}
+		value, ok := fields[name]
+		if !ok {
+			// encoding/json matches the keys ignoring case
+			for key, v := range fields {
+				if strings.EqualFold(key, name) {
+					value, ok = v, true
+					break
+				}
+			}
+		}
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+		if !ok {
+			if hasOption(options[1:], "optional") {
+				continue
+			}
+			return fmt.Errorf("json: missing field %q", fieldPath)
+		}
+		if err := checkFields(value, field.Type, fieldPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasOption(options []string, option string) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package gotumblr_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/spbr/gotumblr"
+	"github.com/spbr/gotumblr/tumblrtest"
+)
+
+// TestStrictResponses checks that the responses of the fake server have all the fields modelled by gotumblr.
+func TestStrictResponses(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	client.SetStrict(true)
+	tester := gotumblr.MustParseBlogIdentifier("tester")
+	server.AddFollower("tester", "fan")
+	size := map[string]interface{}{"url": server.URL + "/media/a.png", "width": 10, "height": 10}
+	for _, post := range []map[string]interface{}{
+		{"type": "text", "title": "Title", "body": "<p>Body</p>", "tags": []string{"a"}},
+		{"type": "photo", "photos": []interface{}{map[string]interface{}{"caption": "", "alt_sizes": []interface{}{size}, "original_size": size}}},
+		{"type": "quote", "text": "Quote", "source": "Someone"},
+		{"type": "link", "url": "https://example.com/"},
+		{"type": "chat", "body": "a: b"},
+		{"type": "audio", "audio_url": server.URL + "/media/a.mp3"},
+		{"type": "video", "video_url": server.URL + "/media/a.mp4"},
+		{"type": "answer", "question": "Why?", "answer": "Because."},
+		{"type": "text", "body": "Draft", "state": "draft"},
+		{"type": "text", "body": "Queued", "state": "queued"},
+	} {
+		server.AddPost("tester", post)
+	}
+	id := server.AddPost("tester", map[string]interface{}{"type": "text", "body": "Liked"})
+	stored, _ := server.Post(id)
+	if _, err := client.Like(id, stored["reblog_key"].(string)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Follow(tester); err != nil {
+		t.Fatal(err)
+	}
+
+	var posts []json.RawMessage
+	check := func(name string, err error) {
+		t.Helper()
+		if err != nil {
+			t.Errorf("strict %s: %v", name, err)
+		}
+	}
+	_, err := client.Info()
+	check("Info", err)
+	_, err = client.BlogInfo(tester)
+	check("BlogInfo", err)
+	followers, err := client.Followers(tester, map[string]string{})
+	check("Followers", err)
+	if followers != nil && len(followers.Users) == 0 {
+		t.Error("Followers returned no user")
+	}
+	_, err = client.Following(map[string]string{})
+	check("Following", err)
+	response, err := client.Posts(tester, "", map[string]string{})
+	check("Posts", err)
+	if response != nil {
+		posts = append(posts, response.Posts...)
+	}
+	likes, err := client.Likes(map[string]string{})
+	check("Likes", err)
+	if likes != nil {
+		posts = append(posts, likes.LikedPosts...)
+	}
+	drafts, err := client.Drafts(tester, map[string]string{})
+	check("Drafts", err)
+	if drafts != nil {
+		posts = append(posts, drafts.Posts...)
+	}
+	queue, err := client.Queue(tester, map[string]string{})
+	check("Queue", err)
+	if queue != nil {
+		posts = append(posts, queue.Posts...)
+	}
+	if len(posts) < 12 {
+		t.Errorf("%d posts listed, want 12 or more", len(posts))
+	}
+	_, err = client.DecodePosts(posts)
+	check("DecodePosts", err)
+}
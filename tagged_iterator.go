@@ -0,0 +1,145 @@
+package gotumblr
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// TaggedIterator iterates over the posts with a tag, newest first, following the before cursor
+// of the tagged endpoint. Posts sharing a timestamp across pages are returned once;
+// since the cursor is a timestamp, more posts than Limit sharing one timestamp cannot all be reached.
+//
+//	it := gotumblr.NewTaggedIterator(client, "golang")
+//	for it.Next() {
+//		fmt.Println(it.Post().Base().PostURL)
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+//
+// The fields must be set before the first call to Next.
+type TaggedIterator struct {
+	Options  map[string]string // other options of Tagged, e.g. filter
+	Limit    int               // posts requested per page, 20 by default
+	Before   time.Time         // start with the posts before this time, now if zero
+	Horizon  time.Time         // stop at the first post older than this time, if not zero
+	Featured bool              // iterate only over the featured posts
+
+	client TumblrReader
+	tag    string
+	before int64            // the before option of the next page
+	seen   map[PostID]int64 // timestamps of the returned posts that the next page can return again
+	buffer []Post
+	post   Post
+	err    error
+	done   bool
+}
+
+// NewTaggedIterator creates a TaggedIterator of the posts with the given tag.
+func NewTaggedIterator(client TumblrReader, tag string) *TaggedIterator {
+	return &TaggedIterator{client: client, tag: tag}
+}
+
+// Next advances to the next post, fetching a new page if needed.
+// It returns false when there are no more posts, the horizon is reached or an error occurred.
+func (it *TaggedIterator) Next() bool {
+	for len(it.buffer) == 0 {
+		if it.done || it.err != nil {
+			it.post = nil
+			return false
+		}
+		if it.err = it.fetch(); it.err != nil {
+			it.post = nil
+			return false
+		}
+	}
+	it.post, it.buffer = it.buffer[0], it.buffer[1:]
+	if !it.Horizon.IsZero() && it.post.Base().Timestamp < it.Horizon.Unix() {
+		it.post, it.buffer, it.done = nil, nil, true
+		return false
+	}
+	return true
+}
+
+// Post returns the current post.
+func (it *TaggedIterator) Post() Post {
+	return it.post
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *TaggedIterator) Err() error {
+	return it.err
+}
+
+// fetch fetches the next page into the buffer and moves the cursor.
+// The cursor is placed just after the oldest timestamp of the page, so that posts
+// with that timestamp missing from the page are returned by the next one;
+// if a page has only posts already seen, the cursor skips their timestamp.
+// In featured mode the posts that are not featured are skipped, but move the cursor.
+func (it *TaggedIterator) fetch() error {
+	if it.seen == nil {
+		it.seen = map[PostID]int64{}
+		if !it.Before.IsZero() {
+			it.before = it.Before.Unix()
+		}
+	}
+	options := map[string]string{}
+	for key, value := range it.Options {
+		options[key] = value
+	}
+	limit := it.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	options["limit"] = strconv.Itoa(limit)
+	if it.before != 0 {
+		options["before"] = strconv.FormatInt(it.before, 10)
+	}
+	data, err := it.client.Tagged(it.tag, options)
+	if err != nil {
+		return err
+	}
+	posts, err := DecodePosts(data)
+	if err != nil {
+		return err
+	}
+	// the cursor follows the timestamps of all the posts of the page, featured or not
+	oldest, fresh := int64(0), false
+	for _, post := range posts {
+		base := post.Base()
+		if base.Timestamp == 0 {
+			continue
+		}
+		if oldest == 0 || base.Timestamp < oldest {
+			oldest = base.Timestamp
+		}
+		if _, ok := it.seen[base.ID]; ok {
+			continue
+		}
+		it.seen[base.ID] = base.Timestamp
+		if !it.Featured || base.FeaturedTimestamp != 0 {
+			it.buffer = append(it.buffer, post)
+		}
+		fresh = true
+	}
+	sort.SliceStable(it.buffer, func(i, j int) bool {
+		return it.buffer[i].Base().Timestamp > it.buffer[j].Base().Timestamp
+	})
+	switch {
+	case oldest == 0 || !it.Horizon.IsZero() && oldest < it.Horizon.Unix():
+		it.done = true // no posts, or the next ones are past the horizon
+	case fresh:
+		it.before = oldest + 1
+	case it.before == oldest:
+		it.done = true
+	default:
+		it.before = oldest
+	}
+	for id, timestamp := range it.seen {
+		if timestamp > oldest {
+			delete(it.seen, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package gotumblr_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spbr/gotumblr"
+	"github.com/spbr/gotumblr/tumblrtest"
+)
+
+// tagPosts adds posts tagged golang with the given timestamps, featured if the timestamp is negative,
+// and returns their ids.
+func tagPosts(server *tumblrtest.Server, timestamps ...int64) []gotumblr.PostID {
+	var ids []gotumblr.PostID
+	for _, timestamp := range timestamps {
+		fields := map[string]interface{}{"type": "text", "body": "x", "tags": []string{"golang"}, "timestamp": timestamp}
+		if timestamp < 0 {
+			fields["timestamp"] = -timestamp
+			fields["featured_timestamp"] = -timestamp + 3600
+		}
+		ids = append(ids, server.AddPost("tester", fields))
+	}
+	return ids
+}
+
+func iterate(t *testing.T, it *gotumblr.TaggedIterator) []gotumblr.PostID {
+	var ids []gotumblr.PostID
+	for it.Next() {
+		ids = append(ids, it.Post().Base().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+func TestTaggedIteratorEqualTimestamps(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	ids := tagPosts(server, 1000, 999, 998, 997, 997, 997, 996, 995)
+	it := gotumblr.NewTaggedIterator(server.Client(), "golang")
+	it.Limit = 4
+	got := iterate(t, it)
+	// the posts sharing the timestamp 997 are ordered by descending id by the server
+	want := []gotumblr.PostID{ids[0], ids[1], ids[2], ids[5], ids[4], ids[3], ids[6], ids[7]}
+	if !equalIDs(got, want) {
+		t.Errorf("iterated over %v, want %v", got, want)
+	}
+}
+
+func TestTaggedIteratorHorizon(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	ids := tagPosts(server, 1000, 990, 980, 970, 960, 950, 940)
+	it := gotumblr.NewTaggedIterator(server.Client(), "golang")
+	it.Limit = 2
+	it.Before = time.Unix(990, 0)
+	it.Horizon = time.Unix(960, 0)
+	if got, want := iterate(t, it), ids[2:5]; !equalIDs(got, want) {
+		t.Errorf("iterated over %v, want %v", got, want)
+	}
+	requests := 0
+	for _, request := range server.Requests() {
+		if request == "GET /v2/tagged" {
+			requests++
+		}
+	}
+	if requests != 3 {
+		t.Errorf("%d pages fetched, want 3, up to the first post past the horizon", requests)
+	}
+}
+
+func TestTaggedIteratorFeatured(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	// a whole page without featured posts in the middle
+	ids := tagPosts(server, -1000, 990, 980, 970, 960, -950, 940, -930)
+	it := gotumblr.NewTaggedIterator(server.Client(), "golang")
+	it.Limit = 2
+	it.Featured = true
+	if got, want := iterate(t, it), []gotumblr.PostID{ids[0], ids[5], ids[7]}; !equalIDs(got, want) {
+		t.Errorf("iterated over %v, want %v", got, want)
+	}
+
+	it = gotumblr.NewTaggedIterator(server.Client(), "golang")
+	it.Limit = 2
+	it.Featured = true
+	it.Horizon = time.Unix(945, 0)
+	if got, want := iterate(t, it), []gotumblr.PostID{ids[0], ids[5]}; !equalIDs(got, want) {
+		t.Errorf("iterated up to the horizon over %v, want %v", got, want)
+	}
+}
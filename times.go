@@ -0,0 +1,93 @@
+package gotumblr
+
+import (
+	"strconv"
+	"time"
+)
+
+// DateLayout is the layout of the dates used by the Tumblr API, e.g. in BasePost.Date
+// and in the date and publish_on parameters.
+const DateLayout = "2006-01-02 15:04:05 GMT"
+
+// ParseDate parses a date in the format used by the Tumblr API. The result is in UTC.
+func ParseDate(date string) (time.Time, error) {
+	t, err := time.Parse(DateLayout, date)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC(), nil
+}
+
+// FormatDate formats t in the format used by the Tumblr API, converting it to UTC (GMT).
+func FormatDate(t time.Time) string {
+	return t.UTC().Format(DateLayout)
+}
+
+// SetTimeOption stores t in options under key, formatted as the API expects it:
+// before and after as Unix timestamps, any other key (e.g. date or publish_on)
+// as a GMT date. A nil options is replaced by a new map. It returns the options.
+func SetTimeOption(options map[string]string, key string, t time.Time) map[string]string {
+	if options == nil {
+		options = map[string]string{}
+	}
+	switch key {
+	case "before", "after":
+		options[key] = strconv.FormatInt(t.Unix(), 10)
+	default:
+		options[key] = FormatDate(t)
+	}
+	return options
+}
+
+// TimeRange holds the before and after parameters of the listing methods: Likes and
+// BlogLikes take both, Posts and Tagged take before. Zero times are not sent.
+type TimeRange struct {
+	Before time.Time
+	After  time.Time
+}
+
+// Apply stores the range in options as Unix timestamps. A nil options is replaced by a new map.
+// It returns the options.
+func (r TimeRange) Apply(options map[string]string) map[string]string {
+	if options == nil {
+		options = map[string]string{}
+	}
+	if !r.Before.IsZero() {
+		SetTimeOption(options, "before", r.Before)
+	}
+	if !r.After.IsZero() {
+		SetTimeOption(options, "after", r.After)
+	}
+	return options
+}
+
+// PostTimes holds the date and publish_on parameters of the Create* methods and EditPost.
+// Zero times are not sent.
+type PostTimes struct {
+	Date      time.Time
+	PublishOn time.Time // for queued posts
+}
+
+// Apply stores the times in options as GMT dates. A nil options is replaced by a new map.
+// It returns the options.
+func (p PostTimes) Apply(options map[string]string) map[string]string {
+	if options == nil {
+		options = map[string]string{}
+	}
+	if !p.Date.IsZero() {
+		SetTimeOption(options, "date", p.Date)
+	}
+	if !p.PublishOn.IsZero() {
+		SetTimeOption(options, "publish_on", p.PublishOn)
+	}
+	return options
+}
+
+// unixTime converts a Unix timestamp returned by the API to a time.Time in UTC.
+// A zero timestamp is converted to the zero time.
+func unixTime(timestamp int64) time.Time {
+	if timestamp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(timestamp, 0).UTC()
+}
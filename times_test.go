@@ -0,0 +1,34 @@
+package gotumblr_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spbr/gotumblr"
+)
+
+func TestTimeRange(t *testing.T) {
+	before := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	options := gotumblr.TimeRange{Before: before}.Apply(map[string]string{"limit": "5"})
+	want := map[string]string{"limit": "5", "before": "1709290800"}
+	if !reflect.DeepEqual(options, want) {
+		t.Errorf("Apply = %v, want %v", options, want)
+	}
+	if options := (gotumblr.TimeRange{}).Apply(nil); len(options) != 0 {
+		t.Errorf("Apply of a zero range = %v, want no options", options)
+	}
+}
+
+func TestPostTimes(t *testing.T) {
+	publishOn := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	options := gotumblr.PostTimes{PublishOn: publishOn}.Apply(nil)
+	want := map[string]string{"publish_on": "2024-03-01 11:00:00 GMT"}
+	if !reflect.DeepEqual(options, want) {
+		t.Errorf("Apply = %v, want %v", options, want)
+	}
+	params := gotumblr.ChatPostParams{Date: publishOn}
+	if date := params.Options()["date"]; date != "2024-03-01 11:00:00 GMT" {
+		t.Errorf("ChatPostParams date = %q", date)
+	}
+}
@@ -0,0 +1,375 @@
+// Package tumblrmock provides a mock of gotumblr.TumblrClient for testing code that uses the Tumblr API
+// without a server.
+//
+// Every method of Client calls the function in the field of the same name with the Func suffix,
+// after recording the call:
+//
+//	mock := &tumblrmock.Client{
+//		InfoFunc: func() (*gotumblr.UserInfoResponse, error) {
+//			return &gotumblr.UserInfoResponse{User: gotumblr.UserInfo{Name: "tester"}}, nil
+//		},
+//	}
+//	service := NewService(mock)
+//	...
+//	if len(mock.CallsTo("Info")) != 1 {
+//		t.Error("Info was not called once")
+//	}
+//
+// Calling a method whose function is nil panics.
+package tumblrmock
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/spbr/gotumblr"
+)
+
+// Call is a recorded call to a method of Client.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// Client is a mock of gotumblr.TumblrClient.
+type Client struct {
+	InfoFunc           func() (*gotumblr.UserInfoResponse, error)
+	AvatarFunc         func(blog gotumblr.BlogIdentifier, size int) (*gotumblr.AvatarResponse, error)
+	AvatarImageFunc    func(blog gotumblr.BlogIdentifier, size int, w io.Writer) (int64, error)
+	LikesFunc          func(options map[string]string) (*gotumblr.LikesResponse, error)
+	FollowingFunc      func(options map[string]string) (*gotumblr.FollowingResponse, error)
+	DashboardFunc      func(options map[string]string) (*gotumblr.DraftsResponse, error)
+	TaggedFunc         func(tag string, options map[string]string) ([]json.RawMessage, error)
+	PostsFunc          func(blog gotumblr.BlogIdentifier, postsType string, options map[string]string) (*gotumblr.PostsResponse, error)
+	BlogInfoFunc       func(blog gotumblr.BlogIdentifier) (*gotumblr.BlogInfoResponse, error)
+	FollowersFunc      func(blog gotumblr.BlogIdentifier, options map[string]string) (*gotumblr.FollowersResponse, error)
+	BlogLikesFunc      func(blog gotumblr.BlogIdentifier, options map[string]string) (*gotumblr.LikesResponse, error)
+	QueueFunc          func(blog gotumblr.BlogIdentifier, options map[string]string) (*gotumblr.DraftsResponse, error)
+	DraftsFunc         func(blog gotumblr.BlogIdentifier, options map[string]string) (*gotumblr.DraftsResponse, error)
+	SubmissionFunc     func(blog gotumblr.BlogIdentifier, options map[string]string) (*gotumblr.DraftsResponse, error)
+	FollowFunc         func(blog gotumblr.BlogIdentifier) (bool, error)
+	UnfollowFunc       func(blog gotumblr.BlogIdentifier) (bool, error)
+	LikeFunc           func(id gotumblr.PostID, reblogKey string) (bool, error)
+	UnlikeFunc         func(id gotumblr.PostID, reblogKey string) (bool, error)
+	CreatePhotoFunc    func(blog gotumblr.BlogIdentifier, options map[string]string) (bool, error)
+	CreateTextFunc     func(blog gotumblr.BlogIdentifier, options map[string]string) (bool, error)
+	CreateQuoteFunc    func(blog gotumblr.BlogIdentifier, options map[string]string) (bool, error)
+	CreateLinkFunc     func(blog gotumblr.BlogIdentifier, options map[string]string) (bool, error)
+	CreateChatPostFunc func(blog gotumblr.BlogIdentifier, options map[string]string) (bool, error)
+	CreateAudioFunc    func(blog gotumblr.BlogIdentifier, options map[string]string) (bool, error)
+	CreateVideoFunc    func(blog gotumblr.BlogIdentifier, options map[string]string) (bool, error)
+	CreatePostFunc     func(blog gotumblr.BlogIdentifier, options map[string]string, uploads ...gotumblr.Upload) (gotumblr.PostID, error)
+	CreateNPFPostFunc  func(blog gotumblr.BlogIdentifier, params gotumblr.NPFPostParams) (gotumblr.PostID, error)
+	ReblogFunc         func(blog gotumblr.BlogIdentifier, options map[string]string) (bool, error)
+	DeletePostFunc     func(blog gotumblr.BlogIdentifier, id gotumblr.PostID) (bool, error)
+	EditPostFunc       func(blog gotumblr.BlogIdentifier, options map[string]string) (bool, error)
+
+	mutex sync.Mutex
+	calls []Call
+}
+
+var _ gotumblr.TumblrClient = (*Client)(nil)
+
+// Calls returns all calls made to the mock, in order.
+func (m *Client) Calls() []Call {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// CallsTo returns the calls made to the given method, in order.
+func (m *Client) CallsTo(method string) []Call {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var calls []Call
+	for _, call := range m.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset forgets the recorded calls.
+func (m *Client) Reset() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.calls = nil
+}
+
+func (m *Client) record(method string, args ...interface{}) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.calls = append(m.calls, Call{method, args})
+}
+
+// Info calls InfoFunc.
+func (m *Client) Info() (*gotumblr.UserInfoResponse, error) {
+	m.record("Info")
+	if m.InfoFunc == nil {
+		panic("tumblrmock: Client.InfoFunc is nil but Info was called")
+	}
+	return m.InfoFunc()
+}
+
+// Avatar calls AvatarFunc.
+func (m *Client) Avatar(blog gotumblr.BlogIdentifier, size int) (*gotumblr.AvatarResponse, error) {
+	m.record("Avatar", blog, size)
+	if m.AvatarFunc == nil {
+		panic("tumblrmock: Client.AvatarFunc is nil but Avatar was called")
+	}
+	return m.AvatarFunc(blog, size)
+}
+
+// AvatarImage calls AvatarImageFunc.
+func (m *Client) AvatarImage(blog gotumblr.BlogIdentifier, size int, w io.Writer) (int64, error) {
+	m.record("AvatarImage", blog, size, w)
+	if m.AvatarImageFunc == nil {
+		panic("tumblrmock: Client.AvatarImageFunc is nil but AvatarImage was called")
+	}
+	return m.AvatarImageFunc(blog, size, w)
+}
+
+// Likes calls LikesFunc.
+func (m *Client) Likes(options map[string]string) (*gotumblr.LikesResponse, error) {
+	m.record("Likes", options)
+	if m.LikesFunc == nil {
+		panic("tumblrmock: Client.LikesFunc is nil but Likes was called")
+	}
+	return m.LikesFunc(options)
+}
+
+// Following calls FollowingFunc.
+func (m *Client) Following(options map[string]string) (*gotumblr.FollowingResponse, error) {
+	m.record("Following", options)
+	if m.FollowingFunc == nil {
+		panic("tumblrmock: Client.FollowingFunc is nil but Following was called")
+	}
+	return m.FollowingFunc(options)
+}
+
+// Dashboard calls DashboardFunc.
+func (m *Client) Dashboard(options map[string]string) (*gotumblr.DraftsResponse, error) {
+	m.record("Dashboard", options)
+	if m.DashboardFunc == nil {
+		panic("tumblrmock: Client.DashboardFunc is nil but Dashboard was called")
+	}
+	return m.DashboardFunc(options)
+}
+
+// Tagged calls TaggedFunc.
+func (m *Client) Tagged(tag string, options map[string]string) ([]json.RawMessage, error) {
+	m.record("Tagged", tag, options)
+	if m.TaggedFunc == nil {
+		panic("tumblrmock: Client.TaggedFunc is nil but Tagged was called")
+	}
+	return m.TaggedFunc(tag, options)
+}
+
+// Posts calls PostsFunc.
+func (m *Client) Posts(blog gotumblr.BlogIdentifier, postsType string, options map[string]string) (*gotumblr.PostsResponse, error) {
+	m.record("Posts", blog, postsType, options)
+	if m.PostsFunc == nil {
+		panic("tumblrmock: Client.PostsFunc is nil but Posts was called")
+	}
+	return m.PostsFunc(blog, postsType, options)
+}
+
+// BlogInfo calls BlogInfoFunc.
+func (m *Client) BlogInfo(blog gotumblr.BlogIdentifier) (*gotumblr.BlogInfoResponse, error) {
+	m.record("BlogInfo", blog)
+	if m.BlogInfoFunc == nil {
+		panic("tumblrmock: Client.BlogInfoFunc is nil but BlogInfo was called")
+	}
+	return m.BlogInfoFunc(blog)
+}
+
+// Followers calls FollowersFunc.
+func (m *Client) Followers(blog gotumblr.BlogIdentifier, options map[string]string) (*gotumblr.FollowersResponse, error) {
+	m.record("Followers", blog, options)
+	if m.FollowersFunc == nil {
+		panic("tumblrmock: Client.FollowersFunc is nil but Followers was called")
+	}
+	return m.FollowersFunc(blog, options)
+}
+
+// BlogLikes calls BlogLikesFunc.
+func (m *Client) BlogLikes(blog gotumblr.BlogIdentifier, options map[string]string) (*gotumblr.LikesResponse, error) {
+	m.record("BlogLikes", blog, options)
+	if m.BlogLikesFunc == nil {
+		panic("tumblrmock: Client.BlogLikesFunc is nil but BlogLikes was called")
+	}
+	return m.BlogLikesFunc(blog, options)
+}
+
+// Queue calls QueueFunc.
+func (m *Client) Queue(blog gotumblr.BlogIdentifier, options map[string]string) (*gotumblr.DraftsResponse, error) {
+	m.record("Queue", blog, options)
+	if m.QueueFunc == nil {
+		panic("tumblrmock: Client.QueueFunc is nil but Queue was called")
+	}
+	return m.QueueFunc(blog, options)
+}
+
+// Drafts calls DraftsFunc.
+func (m *Client) Drafts(blog gotumblr.BlogIdentifier, options map[string]string) (*gotumblr.DraftsResponse, error) {
+	m.record("Drafts", blog, options)
+	if m.DraftsFunc == nil {
+		panic("tumblrmock: Client.DraftsFunc is nil but Drafts was called")
+	}
+	return m.DraftsFunc(blog, options)
+}
+
+// Submission calls SubmissionFunc.
+func (m *Client) Submission(blog gotumblr.BlogIdentifier, options map[string]string) (*gotumblr.DraftsResponse, error) {
+	m.record("Submission", blog, options)
+	if m.SubmissionFunc == nil {
+		panic("tumblrmock: Client.SubmissionFunc is nil but Submission was called")
+	}
+	return m.SubmissionFunc(blog, options)
+}
+
+// Follow calls FollowFunc.
+func (m *Client) Follow(blog gotumblr.BlogIdentifier) (bool, error) {
+	m.record("Follow", blog)
+	if m.FollowFunc == nil {
+		panic("tumblrmock: Client.FollowFunc is nil but Follow was called")
+	}
+	return m.FollowFunc(blog)
+}
+
+// Unfollow calls UnfollowFunc.
+func (m *Client) Unfollow(blog gotumblr.BlogIdentifier) (bool, error) {
+	m.record("Unfollow", blog)
+	if m.UnfollowFunc == nil {
+		panic("tumblrmock: Client.UnfollowFunc is nil but Unfollow was called")
+	}
+	return m.UnfollowFunc(blog)
+}
+
+// Like calls LikeFunc.
+func (m *Client) Like(id gotumblr.PostID, reblogKey string) (bool, error) {
+	m.record("Like", id, reblogKey)
+	if m.LikeFunc == nil {
+		panic("tumblrmock: Client.LikeFunc is nil but Like was called")
+	}
+	return m.LikeFunc(id, reblogKey)
+}
+
+// Unlike calls UnlikeFunc.
+func (m *Client) Unlike(id gotumblr.PostID, reblogKey string) (bool, error) {
+	m.record("Unlike", id, reblogKey)
+	if m.UnlikeFunc == nil {
+		panic("tumblrmock: Client.UnlikeFunc is nil but Unlike was called")
+	}
+	return m.UnlikeFunc(id, reblogKey)
+}
+
+// CreatePhoto calls CreatePhotoFunc.
+func (m *Client) CreatePhoto(blog gotumblr.BlogIdentifier, options map[string]string) (bool, error) {
+	m.record("CreatePhoto", blog, options)
+	if m.CreatePhotoFunc == nil {
+		panic("tumblrmock: Client.CreatePhotoFunc is nil but CreatePhoto was called")
+	}
+	return m.CreatePhotoFunc(blog, options)
+}
+
+// CreateText calls CreateTextFunc.
+func (m *Client) CreateText(blog gotumblr.BlogIdentifier, options map[string]string) (bool, error) {
+	m.record("CreateText", blog, options)
+	if m.CreateTextFunc == nil {
+		panic("tumblrmock: Client.CreateTextFunc is nil but CreateText was called")
+	}
+	return m.CreateTextFunc(blog, options)
+}
+
+// CreateQuote calls CreateQuoteFunc.
+func (m *Client) CreateQuote(blog gotumblr.BlogIdentifier, options map[string]string) (bool, error) {
+	m.record("CreateQuote", blog, options)
+	if m.CreateQuoteFunc == nil {
+		panic("tumblrmock: Client.CreateQuoteFunc is nil but CreateQuote was called")
+	}
+	return m.CreateQuoteFunc(blog, options)
+}
+
+// CreateLink calls CreateLinkFunc.
+func (m *Client) CreateLink(blog gotumblr.BlogIdentifier, options map[string]string) (bool, error) {
+	m.record("CreateLink", blog, options)
+	if m.CreateLinkFunc == nil {
+		panic("tumblrmock: Client.CreateLinkFunc is nil but CreateLink was called")
+	}
+	return m.CreateLinkFunc(blog, options)
+}
+
+// CreateChatPost calls CreateChatPostFunc.
+func (m *Client) CreateChatPost(blog gotumblr.BlogIdentifier, options map[string]string) (bool, error) {
+	m.record("CreateChatPost", blog, options)
+	if m.CreateChatPostFunc == nil {
+		panic("tumblrmock: Client.CreateChatPostFunc is nil but CreateChatPost was called")
+	}
+	return m.CreateChatPostFunc(blog, options)
+}
+
+// CreateAudio calls CreateAudioFunc.
+func (m *Client) CreateAudio(blog gotumblr.BlogIdentifier, options map[string]string) (bool, error) {
+	m.record("CreateAudio", blog, options)
+	if m.CreateAudioFunc == nil {
+		panic("tumblrmock: Client.CreateAudioFunc is nil but CreateAudio was called")
+	}
+	return m.CreateAudioFunc(blog, options)
+}
+
+// CreateVideo calls CreateVideoFunc.
+func (m *Client) CreateVideo(blog gotumblr.BlogIdentifier, options map[string]string) (bool, error) {
+	m.record("CreateVideo", blog, options)
+	if m.CreateVideoFunc == nil {
+		panic("tumblrmock: Client.CreateVideoFunc is nil but CreateVideo was called")
+	}
+	return m.CreateVideoFunc(blog, options)
+}
+
+// CreatePost calls CreatePostFunc.
+func (m *Client) CreatePost(blog gotumblr.BlogIdentifier, options map[string]string, uploads ...gotumblr.Upload) (gotumblr.PostID, error) {
+	m.record("CreatePost", blog, options, uploads)
+	if m.CreatePostFunc == nil {
+		panic("tumblrmock: Client.CreatePostFunc is nil but CreatePost was called")
+	}
+	return m.CreatePostFunc(blog, options, uploads...)
+}
+
+// CreateNPFPost calls CreateNPFPostFunc.
+func (m *Client) CreateNPFPost(blog gotumblr.BlogIdentifier, params gotumblr.NPFPostParams) (gotumblr.PostID, error) {
+	m.record("CreateNPFPost", blog, params)
+	if m.CreateNPFPostFunc == nil {
+		panic("tumblrmock: Client.CreateNPFPostFunc is nil but CreateNPFPost was called")
+	}
+	return m.CreateNPFPostFunc(blog, params)
+}
+
+// Reblog calls ReblogFunc.
+func (m *Client) Reblog(blog gotumblr.BlogIdentifier, options map[string]string) (bool, error) {
+	m.record("Reblog", blog, options)
+	if m.ReblogFunc == nil {
+		panic("tumblrmock: Client.ReblogFunc is nil but Reblog was called")
+	}
+	return m.ReblogFunc(blog, options)
+}
+
+// DeletePost calls DeletePostFunc.
+func (m *Client) DeletePost(blog gotumblr.BlogIdentifier, id gotumblr.PostID) (bool, error) {
+	m.record("DeletePost", blog, id)
+	if m.DeletePostFunc == nil {
+		panic("tumblrmock: Client.DeletePostFunc is nil but DeletePost was called")
+	}
+	return m.DeletePostFunc(blog, id)
+}
+
+// EditPost calls EditPostFunc.
+func (m *Client) EditPost(blog gotumblr.BlogIdentifier, options map[string]string) (bool, error) {
+	m.record("EditPost", blog, options)
+	if m.EditPostFunc == nil {
+		panic("tumblrmock: Client.EditPostFunc is nil but EditPost was called")
+	}
+	return m.EditPostFunc(blog, options)
+}
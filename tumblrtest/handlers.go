@@ -0,0 +1,973 @@
+package tumblrtest
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"html/template"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spbr/gotumblr"
+)
+
+// blog is a blog known to the server.
+type blog struct {
+	name        string
+	title       string
+	description string
+	uuid        string   // the blog UUID, with its "t:" prefix
+	domain      string   // the custom domain, or ""
+	owned       bool     // owned by the user of the server
+	followers   []string // names of the users following the blog
+}
+
+// newBlog returns a blog not owned by the user, with a UUID derived from its name.
+func newBlog(name, title, description string) *blog {
+	sum := sha1.Sum([]byte(name))
+	uuid := "t:" + base64.RawURLEncoding.EncodeToString(sum[:16])
+	return &blog{name: name, title: title, description: description, uuid: uuid}
+}
+
+// url returns the URL of the blog, on its custom domain if it has one.
+func (b *blog) url() string {
+	if b.domain != "" {
+		return "https://" + b.domain + "/"
+	}
+	return "https://" + b.name + ".tumblr.com/"
+}
+
+// storedPost is a post in the JSON form returned by the API.
+type storedPost struct {
+	blog   string
+	fields map[string]interface{}
+}
+
+func (p *storedPost) id() gotumblr.PostID {
+	return gotumblr.PostID(p.fields["id"].(int64))
+}
+
+func (p *storedPost) str(key string) string {
+	value, _ := p.fields[key].(string)
+	return value
+}
+
+func (p *storedPost) timestamp() int64 {
+	value, _ := p.fields["timestamp"].(int64)
+	return value
+}
+
+func (p *storedPost) tags() []string {
+	tags, _ := p.fields["tags"].([]string)
+	return tags
+}
+
+// AddBlog adds a blog not owned by the user of the server.
+func (s *Server) AddBlog(name, title, description string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.blogs[name] = newBlog(name, title, description)
+}
+
+// SetCustomDomain makes a blog answer on a custom domain, e.g. blog.example.com, as well as on its name.
+func (s *Server) SetCustomDomain(blogName, domain string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if b := s.blogs[blogName]; b != nil {
+		b.domain = strings.ToLower(domain)
+	}
+}
+
+// BlogUUID returns the UUID of a blog, with its "t:" prefix, or "" if there is no such blog.
+func (s *Server) BlogUUID(blogName string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if b := s.blogs[blogName]; b != nil {
+		return b.uuid
+	}
+	return ""
+}
+
+// AddFollower makes the user named follower follow the blog.
+func (s *Server) AddFollower(blogName, follower string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if b := s.blogs[blogName]; b != nil {
+		b.followers = append(b.followers, follower)
+	}
+}
+
+// AddPost adds a post to a blog, creating the blog if needed, and returns its id.
+// fields holds the post in the JSON form returned by the API (e.g. "type", "body", "tags" as a []string,
+// "state" as published, queued, draft, submission or private).
+// The id, blog name, URLs and reblog key are assigned by the server;
+// the timestamp, date, state and the other common fields are filled in unless given.
+func (s *Server) AddPost(blogName string, fields map[string]interface{}) gotumblr.PostID {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.blogs[blogName] == nil {
+		s.blogs[blogName] = newBlog(blogName, blogName, "")
+	}
+	return s.addPost(blogName, fields).id()
+}
+
+// Post returns a copy of a post in the JSON form returned by the API.
+func (s *Server) Post(id gotumblr.PostID) (map[string]interface{}, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	post := s.findPost(id)
+	if post == nil {
+		return nil, false
+	}
+	return copyFields(post.fields), true
+}
+
+// BlogPosts returns copies of all posts of a blog, in any state, newest first.
+func (s *Server) BlogPosts(blogName string) []map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var posts []map[string]interface{}
+	for _, post := range s.sortedPosts() {
+		if post.blog == blogName {
+			posts = append(posts, copyFields(post.fields))
+		}
+	}
+	return posts
+}
+
+// Following returns the names of the blogs the user follows.
+func (s *Server) Following() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.following...)
+}
+
+// Liked reports whether the user likes the post.
+func (s *Server) Liked(id gotumblr.PostID) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return indexOfID(s.likes, id) >= 0
+}
+
+// addPost stores a new post. The mutex must be held.
+func (s *Server) addPost(blogName string, fields map[string]interface{}) *storedPost {
+	s.nextID++
+	now := s.Now()
+	post := &storedPost{blog: blogName, fields: copyFields(fields)}
+	defaults := map[string]interface{}{
+		"id":          s.nextID,
+		"id_string":   strconv.FormatInt(s.nextID, 10),
+		"blog_name":   blogName,
+		"post_url":    s.blogs[blogName].url() + "post/" + strconv.FormatInt(s.nextID, 10),
+		"short_url":   "https://tmblr.co/" + strconv.FormatInt(s.nextID, 36),
+		"type":        "text",
+		"timestamp":   now.Unix(),
+		"date":        gotumblr.FormatDate(now),
+		"format":      "html",
+		"reblog_key":  "rk" + strconv.FormatInt(s.nextID, 36),
+		"tags":        []string{},
+		"state":       "published",
+		"note_count":  int64(0),
+		"slug":        "",
+		"summary":     "",
+		"can_like":    true,
+		"can_reblog":  true,
+		"can_reply":   true,
+		"liked":       false,
+		"followed":    false,
+		"bookmarklet": false,
+		"mobile":      false,
+		// the fields of the posts served by the API since the Neue Post Format
+		"reblog":                 map[string]interface{}{"comment": "", "tree_html": ""},
+		"trail":                  []interface{}{},
+		"should_open_in_legacy":  true,
+		"is_blocks_post_format":  false,
+		"can_send_in_message":    true,
+		"interactability_reblog": "everyone",
+		"display_avatar":         true,
+	}
+	for _, key := range []string{"id", "id_string", "blog_name", "post_url", "short_url", "reblog_key"} {
+		delete(post.fields, key)
+	}
+	for key, value := range defaults {
+		if _, ok := post.fields[key]; !ok {
+			post.fields[key] = value
+		}
+	}
+	postType, _ := post.fields["type"].(string)
+	for key, value := range typeDefaults[postType] {
+		if _, ok := post.fields[key]; !ok {
+			post.fields[key] = value
+		}
+	}
+	for _, key := range []string{"timestamp", "note_count"} {
+		post.fields[key] = toInt64(post.fields[key])
+	}
+	s.posts = append(s.posts, post)
+	return post
+}
+
+// typeDefaults holds the fields always sent by the API for each post type, with their empty values.
+var typeDefaults = map[string]map[string]interface{}{
+	"text":   {"title": "", "body": ""},
+	"photo":  {"caption": "", "photos": []interface{}{}, "image_permalink": ""},
+	"quote":  {"text": "", "source": ""},
+	"link":   {"title": "", "url": "", "description": ""},
+	"chat":   {"title": "", "body": "", "dialogue": []interface{}{}},
+	"audio":  {"caption": "", "player": "", "plays": int64(0), "audio_url": "", "audio_type": "", "embed": ""},
+	"video":  {"caption": "", "player": []interface{}{}, "video_type": ""},
+	"answer": {"asking_name": "", "asking_url": "", "question": "", "answer": ""},
+}
+
+// route handles a request and returns the status and the response, or the path of a redirect.
+// The response of a failed request is its error message. The mutex must be held.
+func (s *Server) route(r *http.Request) (int, interface{}, string) {
+	path := strings.Trim(r.URL.Path, "/")
+	switch {
+	case r.Method == "GET" && path == "v2/user/info":
+		return s.userInfo()
+	case r.Method == "GET" && path == "v2/user/likes":
+		return s.likesOf(r.Form)
+	case r.Method == "GET" && path == "v2/user/following":
+		return s.followingList(r.Form)
+	case r.Method == "GET" && path == "v2/user/dashboard":
+		return s.dashboard(r.Form)
+	case r.Method == "GET" && path == "v2/tagged":
+		return s.tagged(r.Form)
+	case r.Method == "POST" && (path == "v2/user/follow" || path == "v2/user/unfollow"):
+		return s.follow(r.Form, path == "v2/user/follow")
+	case r.Method == "POST" && (path == "v2/user/like" || path == "v2/user/unlike"):
+		return s.like(r.Form, path == "v2/user/like")
+	}
+	segments := strings.Split(path, "/")
+	if len(segments) < 4 || segments[0] != "v2" || segments[1] != "blog" {
+		return http.StatusNotFound, "Not Found", ""
+	}
+	b := s.lookupBlog(segments[2])
+	if b == nil {
+		return http.StatusNotFound, "Not Found", ""
+	}
+	endpoint := strings.Join(segments[3:], "/")
+	switch {
+	case r.Method == "GET" && endpoint == "info":
+		return http.StatusOK, map[string]interface{}{"blog": s.blogInfo(b)}, ""
+	case r.Method == "GET" && len(segments) == 5 && segments[3] == "avatar":
+		return 0, nil, "/media/avatar_" + b.name + "_" + segments[4] + ".png"
+	case r.Method == "GET" && endpoint == "followers":
+		return s.followers(b, r.Form)
+	case r.Method == "GET" && endpoint == "likes":
+		if !b.owned {
+			return http.StatusForbidden, "Forbidden", ""
+		}
+		return s.likesOf(r.Form)
+	case r.Method == "GET" && (endpoint == "posts/queue" || endpoint == "posts/draft" || endpoint == "posts/submission"):
+		if !b.owned {
+			return http.StatusForbidden, "Forbidden", ""
+		}
+		return s.privatePosts(b, segments[4], r.Form)
+	case r.Method == "GET" && segments[3] == "posts" && len(segments) <= 5:
+		postType := ""
+		if len(segments) == 5 {
+			postType = segments[4]
+		}
+		return s.blogPosts(b, postType, r.Form)
+	}
+	if r.Method != "POST" {
+		return http.StatusNotFound, "Not Found", ""
+	}
+	if !b.owned {
+		return http.StatusForbidden, "Forbidden", ""
+	}
+	switch endpoint {
+	case "posts":
+		return s.createNPFPost(b, r)
+	case "post":
+		return s.createPost(b, r.Form, r.MultipartForm)
+	case "post/edit":
+		return s.editPost(b, r.Form)
+	case "post/reblog":
+		return s.reblog(b, r.Form)
+	case "post/delete":
+		return s.deletePost(b, r.Form)
+	}
+	return http.StatusNotFound, "Not Found", ""
+}
+
+func (s *Server) userInfo() (int, interface{}, string) {
+	var blogs []map[string]interface{}
+	names := s.blogNames()
+	for _, name := range names {
+		if b := s.blogs[name]; b.owned {
+			blogs = append(blogs, map[string]interface{}{
+				"name":      b.name,
+				"url":       b.url(),
+				"title":     b.title,
+				"primary":   b.name == s.user,
+				"followers": len(b.followers),
+				"type":      "public",
+			})
+		}
+	}
+	return http.StatusOK, map[string]interface{}{"user": map[string]interface{}{
+		"name":                s.user,
+		"likes":               len(s.likes),
+		"following":           len(s.following),
+		"default_post_format": "html",
+		"blogs":               blogs,
+	}}, ""
+}
+
+func (s *Server) likesOf(form url.Values) (int, interface{}, string) {
+	var liked []*storedPost
+	for i := len(s.likes) - 1; i >= 0; i-- {
+		if post := s.findPost(s.likes[i]); post != nil {
+			liked = append(liked, post)
+		}
+	}
+	return http.StatusOK, map[string]interface{}{
+		"liked_posts": s.render(paginate(liked, form)),
+		"liked_count": len(liked),
+	}, ""
+}
+
+func (s *Server) followingList(form url.Values) (int, interface{}, string) {
+	offset, limit := pageBounds(form, len(s.following))
+	var blogs []map[string]interface{}
+	for _, name := range s.following[offset:limit] {
+		b := s.blogs[name]
+		blogs = append(blogs, map[string]interface{}{
+			"name":        b.name,
+			"url":         b.url(),
+			"updated":     s.updated(b),
+			"title":       b.title,
+			"description": b.description,
+		})
+	}
+	return http.StatusOK, map[string]interface{}{"total_blogs": len(s.following), "blogs": blogs}, ""
+}
+
+func (s *Server) dashboard(form url.Values) (int, interface{}, string) {
+	sinceID, _ := gotumblr.ParsePostID(form.Get("since_id"))
+	var posts []*storedPost
+	for _, post := range s.sortedPosts() {
+		b := s.blogs[post.blog]
+		if post.str("state") != "published" || !b.owned && indexOf(s.following, b.name) < 0 {
+			continue
+		}
+		if post.id() <= sinceID || form.Get("type") != "" && post.str("type") != form.Get("type") {
+			continue
+		}
+		posts = append(posts, post)
+	}
+	return http.StatusOK, map[string]interface{}{"posts": s.render(paginate(posts, form))}, ""
+}
+
+func (s *Server) tagged(form url.Values) (int, interface{}, string) {
+	tag := form.Get("tag")
+	if tag == "" {
+		return http.StatusBadRequest, "Bad Request", ""
+	}
+	before, _ := strconv.ParseInt(form.Get("before"), 10, 64)
+	var posts []*storedPost
+	for _, post := range s.sortedPosts() {
+		if post.str("state") != "published" || before != 0 && post.timestamp() >= before {
+			continue
+		}
+		for _, postTag := range post.tags() {
+			if strings.EqualFold(postTag, tag) {
+				posts = append(posts, post)
+				break
+			}
+		}
+	}
+	limit := formInt(form, "limit", 20)
+	if limit > 20 || limit <= 0 {
+		limit = 20
+	}
+	if len(posts) > limit {
+		posts = posts[:limit]
+	}
+	return http.StatusOK, s.render(posts), ""
+}
+
+func (s *Server) follow(form url.Values, follow bool) (int, interface{}, string) {
+	target, err := gotumblr.ParseBlogIdentifier(form.Get("url"))
+	if err != nil {
+		return http.StatusBadRequest, "Bad Request", ""
+	}
+	b := s.lookupBlog(target.Hostname())
+	if b == nil {
+		return http.StatusNotFound, "Not Found", ""
+	}
+	i := indexOf(s.following, b.name)
+	switch {
+	case follow && i < 0:
+		s.following = append(s.following, b.name)
+		b.followers = append(b.followers, s.user)
+	case !follow && i >= 0:
+		s.following = append(s.following[:i], s.following[i+1:]...)
+		if j := indexOf(b.followers, s.user); j >= 0 {
+			b.followers = append(b.followers[:j], b.followers[j+1:]...)
+		}
+	}
+	return http.StatusOK, map[string]interface{}{"blog": s.blogInfo(b)}, ""
+}
+
+func (s *Server) like(form url.Values, like bool) (int, interface{}, string) {
+	id, err := gotumblr.ParsePostID(form.Get("id"))
+	if err != nil {
+		return http.StatusBadRequest, "Bad Request", ""
+	}
+	post := s.findPost(id)
+	if post == nil || post.str("reblog_key") != form.Get("reblog_key") {
+		return http.StatusNotFound, "Not Found", ""
+	}
+	i := indexOfID(s.likes, id)
+	switch {
+	case like && i < 0:
+		s.likes = append(s.likes, id)
+		post.fields["note_count"] = post.fields["note_count"].(int64) + 1
+	case !like && i >= 0:
+		s.likes = append(s.likes[:i], s.likes[i+1:]...)
+		post.fields["note_count"] = post.fields["note_count"].(int64) - 1
+	}
+	return http.StatusOK, []interface{}{}, ""
+}
+
+func (s *Server) followers(b *blog, form url.Values) (int, interface{}, string) {
+	if !b.owned {
+		return http.StatusForbidden, "Forbidden", ""
+	}
+	offset, limit := pageBounds(form, len(b.followers))
+	var users []map[string]interface{}
+	for _, name := range b.followers[offset:limit] {
+		users = append(users, map[string]interface{}{
+			"name":      name,
+			"following": indexOf(s.following, name) >= 0,
+			"url":       "https://" + name + ".tumblr.com",
+			"updated":   int64(0),
+		})
+	}
+	return http.StatusOK, map[string]interface{}{"total_users": len(b.followers), "users": users}, ""
+}
+
+func (s *Server) blogPosts(b *blog, postType string, form url.Values) (int, interface{}, string) {
+	id, _ := gotumblr.ParsePostID(form.Get("id"))
+	tag := form.Get("tag")
+	var posts []*storedPost
+	for _, post := range s.sortedPosts() {
+		if post.blog != b.name || post.str("state") != "published" ||
+			postType != "" && post.str("type") != postType || id != 0 && post.id() != id {
+			continue
+		}
+		if tag != "" && !hasTag(post.tags(), tag) {
+			continue
+		}
+		posts = append(posts, post)
+	}
+	return http.StatusOK, map[string]interface{}{
+		"blog":        s.blogInfo(b),
+		"posts":       s.render(paginate(posts, form)),
+		"total_posts": len(posts),
+	}, ""
+}
+
+func (s *Server) privatePosts(b *blog, listing string, form url.Values) (int, interface{}, string) {
+	state := map[string]string{"queue": "queued", "draft": "draft", "submission": "submission"}[listing]
+	// the drafts are paged with before_id, the id of the last draft of the previous page
+	beforeID, _ := gotumblr.ParsePostID(form.Get("before_id"))
+	var posts []*storedPost
+	for _, post := range s.sortedPosts() {
+		if post.blog == b.name && post.str("state") == state && (beforeID == 0 || post.id() < beforeID) {
+			posts = append(posts, post)
+		}
+	}
+	return http.StatusOK, map[string]interface{}{"posts": s.render(paginate(posts, form))}, ""
+}
+
+// postStates maps the state parameter of the create and edit endpoints to the state of the posts.
+var postStates = map[string]string{"": "published", "published": "published", "draft": "draft",
+	"queue": "queued", "private": "private"}
+
+func (s *Server) createPost(b *blog, form url.Values, multipartForm *multipart.Form) (int, interface{}, string) {
+	state, ok := postStates[form.Get("state")]
+	if !ok {
+		return http.StatusBadRequest, "Bad Request", ""
+	}
+	fields, ok := postFields(form, form.Get("type"), true)
+	if multipartForm != nil {
+		uploaded, err := s.uploadFields(fields, form.Get("type"), multipartForm.File)
+		if err != nil {
+			return http.StatusBadRequest, "Bad Request", ""
+		}
+		ok = ok || uploaded
+	}
+	if !ok {
+		return http.StatusBadRequest, "Bad Request", ""
+	}
+	fields["type"] = form.Get("type")
+	fields["state"] = state
+	if !s.applyCommonFields(fields, form) {
+		return http.StatusBadRequest, "Bad Request", ""
+	}
+	post := s.addPost(b.name, fields)
+	return http.StatusCreated, map[string]interface{}{"id": post.fields["id"]}, ""
+}
+
+// createNPFPost creates a text post from the JSON body of the NPF create endpoint, its text blocks
+// becoming the paragraphs of the body of the post.
+func (s *Server) createNPFPost(b *blog, r *http.Request) (int, interface{}, string) {
+	var body struct {
+		Content []struct {
+			Type    string `json:"type"`
+			Subtype string `json:"subtype"`
+			Text    string `json:"text"`
+		} `json:"content"`
+		State string `json:"state"`
+		Tags  string `json:"tags"`
+		Date  string `json:"date"`
+		Slug  string `json:"slug"`
+	}
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") ||
+		json.NewDecoder(r.Body).Decode(&body) != nil || len(body.Content) == 0 {
+		return http.StatusBadRequest, "Bad Request", ""
+	}
+	state, ok := postStates[body.State]
+	if !ok {
+		return http.StatusBadRequest, "Bad Request", ""
+	}
+	var html strings.Builder
+	for _, block := range body.Content {
+		if block.Type != "text" {
+			return http.StatusBadRequest, "Bad Request", ""
+		}
+		tag := map[string]string{"heading1": "h1", "heading2": "h2", "quote": "blockquote", "indented": "blockquote"}[block.Subtype]
+		if tag == "" {
+			tag = "p"
+		}
+		html.WriteString("<" + tag + ">" + template.HTMLEscapeString(block.Text) + "</" + tag + ">")
+	}
+	fields := map[string]interface{}{"type": "text", "body": html.String(), "state": state, "is_blocks_post_format": true}
+	form := url.Values{}
+	if body.Tags != "" {
+		form.Set("tags", body.Tags)
+	}
+	if body.Slug != "" {
+		form.Set("slug", body.Slug)
+	}
+	if body.Date != "" {
+		t, err := time.Parse(time.RFC3339, body.Date)
+		if err != nil {
+			return http.StatusBadRequest, "Bad Request", ""
+		}
+		form.Set("date", gotumblr.FormatDate(t))
+	}
+	s.applyCommonFields(fields, form)
+	post := s.addPost(b.name, fields)
+	return http.StatusCreated, map[string]interface{}{"id": post.id().String()}, ""
+}
+
+func (s *Server) editPost(b *blog, form url.Values) (int, interface{}, string) {
+	post := s.ownPost(b, form.Get("id"))
+	if post == nil {
+		return http.StatusNotFound, "Not Found", ""
+	}
+	fields, _ := postFields(form, post.str("type"), false)
+	if form.Get("state") != "" {
+		state, ok := postStates[form.Get("state")]
+		if !ok {
+			return http.StatusBadRequest, "Bad Request", ""
+		}
+		fields["state"] = state
+	}
+	if !s.applyCommonFields(fields, form) {
+		return http.StatusBadRequest, "Bad Request", ""
+	}
+	for key, value := range fields {
+		post.fields[key] = value
+	}
+	return http.StatusOK, map[string]interface{}{"id": post.fields["id"]}, ""
+}
+
+func (s *Server) reblog(b *blog, form url.Values) (int, interface{}, string) {
+	id, err := gotumblr.ParsePostID(form.Get("id"))
+	if err != nil {
+		return http.StatusBadRequest, "Bad Request", ""
+	}
+	original := s.findPost(id)
+	if original == nil || original.str("reblog_key") != form.Get("reblog_key") {
+		return http.StatusNotFound, "Not Found", ""
+	}
+	fields := copyFields(original.fields)
+	for _, key := range []string{"id", "id_string", "blog_name", "post_url", "short_url", "reblog_key",
+		"timestamp", "date", "state", "note_count", "tags"} {
+		delete(fields, key)
+	}
+	fields["reblogged_from_id"] = original.fields["id_string"]
+	fields["reblogged_from_name"] = original.blog
+	if comment := form.Get("comment"); comment != "" {
+		fields["reblog"] = map[string]interface{}{"comment": comment, "tree_html": ""}
+	}
+	state, ok := postStates[form.Get("state")]
+	if !ok {
+		return http.StatusBadRequest, "Bad Request", ""
+	}
+	fields["state"] = state
+	if !s.applyCommonFields(fields, form) {
+		return http.StatusBadRequest, "Bad Request", ""
+	}
+	post := s.addPost(b.name, fields)
+	original.fields["note_count"] = original.fields["note_count"].(int64) + 1
+	return http.StatusCreated, map[string]interface{}{"id": post.fields["id"]}, ""
+}
+
+func (s *Server) deletePost(b *blog, form url.Values) (int, interface{}, string) {
+	post := s.ownPost(b, form.Get("id"))
+	if post == nil {
+		return http.StatusNotFound, "Not Found", ""
+	}
+	for i := range s.posts {
+		if s.posts[i] == post {
+			s.posts = append(s.posts[:i], s.posts[i+1:]...)
+			break
+		}
+	}
+	return http.StatusOK, map[string]interface{}{"id": post.fields["id"]}, ""
+}
+
+// postFields converts the type specific parameters of the create and edit endpoints to post fields.
+// With required set, it reports whether the required parameter of the type is present.
+func postFields(form url.Values, postType string, required bool) (map[string]interface{}, bool) {
+	fields := map[string]interface{}{}
+	set := func(field, param string) bool {
+		if value, ok := form[param]; ok {
+			fields[field] = value[0]
+			return true
+		}
+		return false
+	}
+	present := true
+	switch postType {
+	case "text":
+		set("title", "title")
+		present = set("body", "body")
+	case "photo":
+		set("caption", "caption")
+		set("link_url", "link")
+		if present = form.Get("source") != ""; present {
+			size := map[string]interface{}{"width": 500, "height": 500, "url": form.Get("source")}
+			fields["photos"] = []interface{}{map[string]interface{}{
+				"caption": "", "alt_sizes": []interface{}{size}, "original_size": size,
+			}}
+		}
+	case "quote":
+		present = set("text", "quote")
+		set("source", "source")
+	case "link":
+		set("title", "title")
+		set("description", "description")
+		present = set("url", "url")
+	case "chat":
+		set("title", "title")
+		if present = set("body", "conversation"); present {
+			fields["dialogue"] = gotumblr.ParseConversation(form.Get("conversation"))
+		}
+	case "audio":
+		set("caption", "caption")
+		if present = set("audio_url", "external_url"); present {
+			fields["audio_source_url"] = form.Get("external_url")
+		}
+	case "video":
+		set("caption", "caption")
+		if present = form.Get("embed") != ""; present {
+			fields["player"] = []interface{}{map[string]interface{}{"width": 500, "embed_code": form.Get("embed")}}
+		}
+	default:
+		present = false
+	}
+	return fields, present || !required
+}
+
+// uploadFields converts the files uploaded to the create endpoint to post fields: the photos
+// of a photo post, sent as data or data[0], data[1]..., or the file of an audio or video post, sent as data.
+// The files are served under /media/uploads/. It reports whether the post type got its file.
+// The mutex must be held.
+func (s *Server) uploadFields(fields map[string]interface{}, postType string, files map[string][]*multipart.FileHeader) (bool, error) {
+	var params []string
+	for param := range files {
+		if param == "data" || postType == "photo" && strings.HasPrefix(param, "data[") {
+			params = append(params, param)
+		}
+	}
+	if len(params) == 0 {
+		return false, nil
+	}
+	// data[10] comes after data[9]
+	sort.Slice(params, func(i, j int) bool {
+		return len(params[i]) < len(params[j]) || len(params[i]) == len(params[j]) && params[i] < params[j]
+	})
+	var urls []string
+	for _, param := range params {
+		for _, header := range files[param] {
+			file, err := header.Open()
+			if err != nil {
+				return false, err
+			}
+			content, err := ioutil.ReadAll(file)
+			file.Close()
+			if err != nil {
+				return false, err
+			}
+			path := "/media/uploads/" + strconv.Itoa(len(s.uploads)+1) + "_" + url.PathEscape(header.Filename)
+			s.uploads[path] = content
+			urls = append(urls, s.URL+path)
+		}
+	}
+	switch postType {
+	case "photo":
+		var photos []interface{}
+		for _, u := range urls {
+			size := map[string]interface{}{"width": 500, "height": 500, "url": u}
+			photos = append(photos, map[string]interface{}{
+				"caption": "", "alt_sizes": []interface{}{size}, "original_size": size,
+			})
+		}
+		fields["photos"] = photos
+	case "audio":
+		fields["audio_url"], fields["audio_type"] = urls[0], "tumblr"
+	case "video":
+		fields["video_url"], fields["video_type"] = urls[0], "tumblr"
+		fields["player"] = []interface{}{map[string]interface{}{
+			"width": 500, "embed_code": `<video src="` + urls[0] + `"></video>`,
+		}}
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// applyCommonFields converts the parameters common to all post types to post fields.
+// It reports whether they are valid.
+func (s *Server) applyCommonFields(fields map[string]interface{}, form url.Values) bool {
+	if _, ok := form["tags"]; ok {
+		tags := []string{}
+		for _, tag := range strings.Split(form.Get("tags"), ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		fields["tags"] = tags
+	}
+	for _, key := range []string{"slug", "format"} {
+		if _, ok := form[key]; ok {
+			fields[key] = form.Get(key)
+		}
+	}
+	if date := form.Get("date"); date != "" {
+		t, err := gotumblr.ParseDate(date)
+		if err != nil {
+			return false
+		}
+		fields["date"] = gotumblr.FormatDate(t)
+		fields["timestamp"] = t.Unix()
+	}
+	return true
+}
+
+// render returns the posts in the JSON form returned by the API, marking the liked and followed ones.
+func (s *Server) render(posts []*storedPost) []map[string]interface{} {
+	rendered := []map[string]interface{}{}
+	for _, post := range posts {
+		fields := copyFields(post.fields)
+		fields["liked"] = indexOfID(s.likes, post.id()) >= 0
+		fields["followed"] = indexOf(s.following, post.blog) >= 0
+		b := s.blogs[post.blog]
+		fields["blog"] = map[string]interface{}{
+			"name":        b.name,
+			"title":       b.title,
+			"description": b.description,
+			"url":         b.url(),
+			"uuid":        b.uuid,
+			"updated":     s.updated(b),
+		}
+		rendered = append(rendered, fields)
+	}
+	return rendered
+}
+
+func (s *Server) blogInfo(b *blog) map[string]interface{} {
+	count := 0
+	for _, post := range s.posts {
+		if post.blog == b.name && post.str("state") == "published" {
+			count++
+		}
+	}
+	return map[string]interface{}{
+		"title":       b.title,
+		"name":        b.name,
+		"uuid":        b.uuid,
+		"posts":       count,
+		"url":         b.url(),
+		"updated":     s.updated(b),
+		"description": b.description,
+		"ask":         b.owned, // the blog of the user accepts asks, including anonymous ones
+		"ask_anon":    b.owned,
+		"likes":       len(s.likes),
+	}
+}
+
+// updated returns the timestamp of the most recent published post of a blog.
+func (s *Server) updated(b *blog) int64 {
+	updated := int64(0)
+	for _, post := range s.posts {
+		if post.blog == b.name && post.str("state") == "published" && post.timestamp() > updated {
+			updated = post.timestamp()
+		}
+	}
+	return updated
+}
+
+// lookupBlog returns the blog with the given identifier, as it appears in the endpoint paths:
+// its standard hostname, custom domain or UUID.
+func (s *Server) lookupBlog(identifier string) *blog {
+	id, err := gotumblr.ParseBlogIdentifier(identifier)
+	if err != nil {
+		return nil
+	}
+	if id.Name() != "" {
+		return s.blogs[id.Name()]
+	}
+	for _, b := range s.blogs {
+		if id.UUID() != "" && b.uuid == id.UUID() || id.UUID() == "" && b.domain != "" && b.domain == id.Hostname() {
+			return b
+		}
+	}
+	return nil
+}
+
+func (s *Server) blogNames() []string {
+	names := make([]string, 0, len(s.blogs))
+	for name := range s.blogs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Server) findPost(id gotumblr.PostID) *storedPost {
+	for _, post := range s.posts {
+		if post.id() == id {
+			return post
+		}
+	}
+	return nil
+}
+
+// ownPost returns the post of the blog with the given id, or nil.
+func (s *Server) ownPost(b *blog, rawID string) *storedPost {
+	id, err := gotumblr.ParsePostID(rawID)
+	if err != nil {
+		return nil
+	}
+	post := s.findPost(id)
+	if post == nil || post.blog != b.name {
+		return nil
+	}
+	return post
+}
+
+// sortedPosts returns all posts, newest first.
+func (s *Server) sortedPosts() []*storedPost {
+	posts := append([]*storedPost(nil), s.posts...)
+	sort.SliceStable(posts, func(i, j int) bool {
+		if posts[i].timestamp() != posts[j].timestamp() {
+			return posts[i].timestamp() > posts[j].timestamp()
+		}
+		return posts[i].id() > posts[j].id()
+	})
+	return posts
+}
+
+// pageBounds returns the bounds of the page selected by the offset and limit parameters
+// in a list of n items. The limit is 20 by default and at most.
+func pageBounds(form url.Values, n int) (int, int) {
+	offset := formInt(form, "offset", 0)
+	limit := formInt(form, "limit", 20)
+	if limit <= 0 || limit > 20 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > n {
+		offset = n
+	}
+	if offset+limit > n {
+		return offset, n
+	}
+	return offset, offset + limit
+}
+
+func paginate(posts []*storedPost, form url.Values) []*storedPost {
+	offset, limit := pageBounds(form, len(posts))
+	return posts[offset:limit]
+}
+
+func copyFields(fields map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		copied[key] = value
+	}
+	return copied
+}
+
+func indexOf(list []string, s string) int {
+	for i, item := range list {
+		if item == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// hasTag reports whether tags hold tag, ignoring case as the tag filters of the API do.
+func hasTag(tags []string, tag string) bool {
+	for _, item := range tags {
+		if strings.EqualFold(item, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func indexOfID(list []gotumblr.PostID, id gotumblr.PostID) int {
+	for i, item := range list {
+		if item == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// toInt64 converts a JSON number given as any Go numeric type to an int64.
+func toInt64(value interface{}) int64 {
+	switch n := value.(type) {
+	case int:
+		return int64(n)
+	case int32:
+		return int64(n)
+	case int64:
+		return n
+	case float64:
+		return int64(n)
+	case gotumblr.PostID:
+		return int64(n)
+	}
+	return 0
+}
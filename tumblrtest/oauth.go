@@ -0,0 +1,88 @@
+package tumblrtest
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// verifySignature reports whether the request carries a valid OAuth 1.0a HMAC-SHA1 signature
+// made with the credentials of the server and a nonce that was not used before.
+func (s *Server) verifySignature(r *http.Request) bool {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "OAuth ") {
+		return false
+	}
+	oauthParams := map[string]string{}
+	for _, param := range strings.Split(header[len("OAuth "):], ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok {
+			return false
+		}
+		unquoted, err := url.QueryUnescape(strings.Trim(value, `"`))
+		if err != nil {
+			return false
+		}
+		oauthParams[key] = unquoted
+	}
+	if oauthParams["oauth_consumer_key"] != s.ConsumerKey || oauthParams["oauth_token"] != s.Token ||
+		oauthParams["oauth_signature_method"] != "HMAC-SHA1" {
+		return false
+	}
+
+	// r.Form holds both the query and the url encoded body parameters.
+	var params []string
+	for key, values := range r.Form {
+		for _, value := range values {
+			params = append(params, percentEncode(key)+"="+percentEncode(value))
+		}
+	}
+	for key, value := range oauthParams {
+		if key != "oauth_signature" && key != "realm" {
+			params = append(params, percentEncode(key)+"="+percentEncode(value))
+		}
+	}
+	sort.Strings(params)
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	baseURL := scheme + "://" + strings.ToLower(r.Host) + r.URL.EscapedPath()
+	base := r.Method + "&" + percentEncode(baseURL) + "&" + percentEncode(strings.Join(params, "&"))
+	mac := hmac.New(sha1.New, []byte(percentEncode(s.ConsumerSecret)+"&"+percentEncode(s.TokenSecret)))
+	mac.Write([]byte(base))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(oauthParams["oauth_signature"])) {
+		return false
+	}
+
+	nonce := oauthParams["oauth_timestamp"] + ":" + oauthParams["oauth_nonce"]
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.nonces[nonce] {
+		return false
+	}
+	s.nonces[nonce] = true
+	return true
+}
+
+// percentEncode encodes s as required by OAuth 1.0a (RFC 3986), leaving only the unreserved characters.
+func percentEncode(s string) string {
+	const hex = "0123456789ABCDEF"
+	var encoded strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			encoded.WriteByte(c)
+		} else {
+			encoded.WriteByte('%')
+			encoded.WriteByte(hex[c>>4])
+			encoded.WriteByte(hex[c&15])
+		}
+	}
+	return encoded.String()
+}
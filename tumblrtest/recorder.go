@@ -0,0 +1,335 @@
+package tumblrtest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Redacted replaces the credentials stored in cassettes.
+const Redacted = "REDACTED"
+
+// RecorderMode tells a Recorder whether to replay or to record interactions.
+type RecorderMode int
+
+const (
+	// Replay answers the requests from the cassette and fails those it has no interaction for.
+	Replay RecorderMode = iota
+	// Record sends the requests and stores the interactions, replacing the cassette when saved.
+	Record
+	// ReplayOrRecord answers the requests from the cassette when it can, and sends and stores the others.
+	ReplayOrRecord
+)
+
+// Cassette holds recorded interactions with the Tumblr API.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and the response it received.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request stored in a cassette, without its credentials.
+type RecordedRequest struct {
+	Method       string `json:"method"`
+	URL          string `json:"url"`
+	ContentType  string `json:"content_type,omitempty"`
+	Body         string `json:"body,omitempty"`
+	BodyEncoding string `json:"body_encoding,omitempty"` // base64 for the bodies that are not UTF-8, e.g. uploads
+}
+
+// RecordedResponse is a response stored in a cassette.
+type RecordedResponse struct {
+	StatusCode   int         `json:"status_code"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body"`
+	BodyEncoding string      `json:"body_encoding,omitempty"` // base64 for the bodies that are not UTF-8, e.g. images
+}
+
+// Recorder is an http.RoundTripper that records interactions with the API into a cassette file
+// and replays them, so tests can run offline against real responses:
+//
+//	recorder, err := tumblrtest.NewRecorder("testdata/dashboard.json", tumblrtest.Replay)
+//	client.SetHTTPClient(recorder.Client())
+//	...
+//	err = recorder.Save() // when recording
+//
+// Requests are matched by method, URL path and parameters, ignoring the OAuth parameters
+// (nonce, timestamp, signature, ...) and the api_key; the uploads of multipart requests
+// are matched by their parameter, file name and content. The Authorization header is never stored,
+// api_key parameters are replaced by Redacted, and so are the strings listed in Secrets wherever
+// they appear in text. Bodies that are not UTF-8 are stored in base64, unredacted.
+type Recorder struct {
+	// Transport sends the requests when recording, http.DefaultTransport if nil.
+	Transport http.RoundTripper
+	// Secrets lists strings, such as the consumer key and the tokens, to redact from the cassette.
+	Secrets []string
+
+	mode     RecorderMode
+	path     string
+	mutex    sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// NewRecorder creates a Recorder using the cassette file at path.
+// The cassette is loaded unless mode is Record; it must exist in Replay mode.
+func NewRecorder(path string, mode RecorderMode) (*Recorder, error) {
+	r := &Recorder{mode: mode, path: path}
+	if mode == Record {
+		return r, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && mode == ReplayOrRecord {
+			return r, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("tumblrtest: reading cassette %s: %v", path, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Client returns an http.Client using the recorder as its transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Save writes the cassette to its file.
+func (r *Recorder) Save() error {
+	r.mutex.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+// RoundTrip answers the request from the cassette or sends and records it, depending on the mode.
+func (r *Recorder) RoundTrip(request *http.Request) (*http.Response, error) {
+	var body []byte
+	if request.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(request.Body)
+		request.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	recorded := r.redactRequest(request, body)
+	key := matchKey(recorded)
+
+	if r.mode != Record {
+		if interaction, ok := r.find(key); ok {
+			return interaction.Response.toHTTP(request), nil
+		}
+		if r.mode == Replay {
+			return nil, fmt.Errorf("tumblrtest: no recorded interaction for %s %s", recorded.Method, recorded.URL)
+		}
+	}
+
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	response, err := transport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	responseBody, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	recordedResponse := RecordedResponse{StatusCode: response.StatusCode, Header: r.redactHeader(response.Header)}
+	recordedResponse.Body, recordedResponse.BodyEncoding = r.encodeBody(responseBody)
+	interaction := Interaction{recorded, recordedResponse}
+	r.mutex.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.used = append(r.used, true)
+	r.mutex.Unlock()
+	response.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+	return response, nil
+}
+
+// find returns the first unused interaction matching key, or else the last used one,
+// so that repeated identical requests replay the recorded sequence.
+func (r *Recorder) find(key string) (Interaction, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	last := -1
+	for i, interaction := range r.cassette.Interactions {
+		if matchKey(interaction.Request) != key {
+			continue
+		}
+		if !r.used[i] {
+			r.used[i] = true
+			return interaction, true
+		}
+		last = i
+	}
+	if last < 0 {
+		return Interaction{}, false
+	}
+	return r.cassette.Interactions[last], true
+}
+
+// redactRequest returns the request as stored in the cassette.
+func (r *Recorder) redactRequest(request *http.Request, body []byte) RecordedRequest {
+	u := *request.URL
+	query := u.Query()
+	if query.Get("api_key") != "" {
+		query.Set("api_key", Redacted)
+		u.RawQuery = query.Encode()
+	}
+	recorded := RecordedRequest{Method: request.Method, URL: r.redact(u.String()), ContentType: request.Header.Get("Content-Type")}
+	if len(body) != 0 {
+		if recorded.ContentType == "application/x-www-form-urlencoded" {
+			if form, err := url.ParseQuery(string(body)); err == nil && form.Get("api_key") != "" {
+				form.Set("api_key", Redacted)
+				body = []byte(form.Encode())
+			}
+		}
+		recorded.Body, recorded.BodyEncoding = r.encodeBody(body)
+	}
+	return recorded
+}
+
+// encodeBody returns a body as stored in a cassette: redacted if it is UTF-8, in base64 otherwise.
+func (r *Recorder) encodeBody(body []byte) (string, string) {
+	if !utf8.Valid(body) {
+		return base64.StdEncoding.EncodeToString(body), "base64"
+	}
+	return r.redact(string(body)), ""
+}
+
+// decodeBody returns a body stored in a cassette.
+func decodeBody(body, encoding string) []byte {
+	if encoding == "base64" {
+		if data, err := base64.StdEncoding.DecodeString(body); err == nil {
+			return data
+		}
+	}
+	return []byte(body)
+}
+
+func (r *Recorder) redactHeader(header http.Header) http.Header {
+	redacted := http.Header{}
+	for key, values := range header {
+		if key == "Set-Cookie" {
+			continue
+		}
+		for _, value := range values {
+			redacted.Add(key, r.redact(value))
+		}
+	}
+	return redacted
+}
+
+// redact replaces the secrets in s.
+func (r *Recorder) redact(s string) string {
+	for _, secret := range r.Secrets {
+		if secret != "" {
+			s = strings.Replace(s, secret, Redacted, -1)
+			s = strings.Replace(s, url.QueryEscape(secret), Redacted, -1)
+		}
+	}
+	return s
+}
+
+// matchKey returns the key requests are matched by: the method, the URL path and
+// the sorted parameters of the query and of the url encoded or multipart body, without the OAuth
+// parameters and the api_key. The files of a multipart body are keyed by the SHA-256 of their content.
+func matchKey(request RecordedRequest) string {
+	u, err := url.Parse(request.URL)
+	if err != nil {
+		return request.Method + " " + request.URL
+	}
+	var params []string
+	add := func(values url.Values) {
+		for key, list := range values {
+			if strings.HasPrefix(key, "oauth_") || key == "api_key" {
+				continue
+			}
+			for _, value := range list {
+				params = append(params, url.QueryEscape(key)+"="+url.QueryEscape(value))
+			}
+		}
+	}
+	add(u.Query())
+	body := decodeBody(request.Body, request.BodyEncoding)
+	mediaType, mediaParams, _ := mime.ParseMediaType(request.ContentType)
+	switch {
+	case mediaType == "multipart/form-data":
+		form, err := multipart.NewReader(bytes.NewReader(body), mediaParams["boundary"]).ReadForm(32 << 20)
+		if err != nil {
+			return request.Method + " " + request.URL + " " + request.Body
+		}
+		defer form.RemoveAll()
+		add(form.Value)
+		for key, headers := range form.File {
+			for _, header := range headers {
+				params = append(params, url.QueryEscape(key)+"=@"+url.QueryEscape(header.Filename)+":"+fileHash(header))
+			}
+		}
+	case len(body) != 0:
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			add(form)
+		}
+	}
+	sort.Strings(params)
+	return request.Method + " " + u.Path + "?" + strings.Join(params, "&")
+}
+
+// fileHash returns the SHA-256 of the content of an uploaded file, in hexadecimal.
+func fileHash(header *multipart.FileHeader) string {
+	file, err := header.Open()
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	hash := sha256.New()
+	io.Copy(hash, file)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (response RecordedResponse) toHTTP(request *http.Request) *http.Response {
+	body := decodeBody(response.Body, response.BodyEncoding)
+	header := http.Header{}
+	for key, values := range response.Header {
+		header[key] = append([]string(nil), values...)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", response.StatusCode, http.StatusText(response.StatusCode)),
+		StatusCode:    response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       request,
+	}
+}
@@ -0,0 +1,105 @@
+package tumblrtest_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spbr/gotumblr"
+	"github.com/spbr/gotumblr/tumblrtest"
+)
+
+// session makes requests covering the GET and POST endpoints, an upload and a binary download,
+// and returns what they returned.
+func session(t *testing.T, client *gotumblr.TumblrRestClient, httpClient *http.Client, image []byte) (string, []byte) {
+	info, err := client.BlogInfo(tester)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := client.CreatePost(tester, map[string]string{"type": "photo", "caption": "recorded"},
+		gotumblr.Upload{Param: "data", Filename: "image.png", Content: bytes.NewReader(image)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	posts, err := client.Posts(tester, "photo", map[string]string{"id": id.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	post, err := client.DecodePost(posts.Posts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := httpClient.Get(post.(*gotumblr.PhotoPost).Photos[0].Original().URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	downloaded, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Blog.Title + " " + id.String(), downloaded
+}
+
+func TestRecorder(t *testing.T) {
+	server := tumblrtest.NewServer()
+	image := []byte("\x89PNG\r\n\x1a\n\xff\xfe binary")
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	recorder, err := tumblrtest.NewRecorder(path, tumblrtest.Record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder.Secrets = []string{server.ConsumerSecret, server.Token, server.TokenSecret}
+	client := server.Client()
+	client.SetHTTPClient(recorder.Client())
+	recordedResult, recordedImage := session(t, client, recorder.Client(), image)
+	if !bytes.Equal(recordedImage, image) {
+		t.Fatalf("downloaded %q while recording, want %q", recordedImage, image)
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cassette := string(data)
+	for _, secret := range append(recorder.Secrets, server.ConsumerKey) {
+		if strings.Contains(cassette, secret) {
+			t.Errorf("the cassette holds the secret %q", secret)
+		}
+	}
+	if strings.Contains(cassette, "Authorization") || strings.Contains(cassette, "oauth_signature") {
+		t.Error("the cassette holds the OAuth credentials")
+	}
+	if strings.Count(cassette, `"body_encoding": "base64"`) != 2 {
+		t.Error("the binary upload and download are not stored in base64")
+	}
+
+	// the server is closed: the requests are replayed with new nonces, timestamps and multipart boundaries
+	replayer, err := tumblrtest.NewRecorder(path, tumblrtest.Replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = server.Client()
+	client.SetHTTPClient(replayer.Client())
+	replayedResult, replayedImage := session(t, client, replayer.Client(), image)
+	if replayedResult != recordedResult || !bytes.Equal(replayedImage, image) {
+		t.Errorf("replayed %q and %q, want %q and %q", replayedResult, replayedImage, recordedResult, image)
+	}
+
+	// an upload with another content does not match
+	other := append([]byte(nil), image...)
+	other[len(other)-1] = 'X'
+	_, err = client.CreatePost(tester, map[string]string{"type": "photo", "caption": "recorded"},
+		gotumblr.Upload{Param: "data", Filename: "image.png", Content: bytes.NewReader(other)})
+	if err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+		t.Errorf("upload of another file: err = %v, want no recorded interaction", err)
+	}
+}
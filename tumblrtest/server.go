@@ -0,0 +1,342 @@
+// Package tumblrtest provides an in-process fake of the Tumblr API for testing code that uses gotumblr.
+//
+// The fake keeps its blogs, posts, likes and follows in memory, verifies the OAuth signatures
+// of the requests, answers conditional GET requests with their ETag, and can be told to fail
+// requests or to enforce a rate limit:
+//
+//	server := tumblrtest.NewServer()
+//	defer server.Close()
+//	client := server.Client()
+//	id := server.AddPost("tester", map[string]interface{}{"type": "text", "body": "Hello"})
+//	ok, err := client.DeletePost(gotumblr.MustParseBlogIdentifier("tester"), id)
+package tumblrtest
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spbr/gotumblr"
+)
+
+// Default credentials accepted by a Server.
+const (
+	ConsumerKey    = "consumer-key"
+	ConsumerSecret = "consumer-secret"
+	Token          = "oauth-token"
+	TokenSecret    = "oauth-token-secret"
+)
+
+// Fault describes an error response the server sends instead of handling matching requests.
+type Fault struct {
+	Method string        // matches any method if empty
+	Path   string        // matches the request paths starting with Path, any path if empty
+	Status int           // HTTP and meta status of the response, 503 by default
+	Msg    string        // meta message of the response, the status text by default
+	Body   string        // raw body sent instead of the JSON error, e.g. to simulate a malformed response
+	Delay  time.Duration // wait before responding
+	Times  int           // number of requests the fault applies to, 0 for all
+}
+
+// Server is a fake Tumblr API server. Its exported fields must not be changed once it handles requests.
+type Server struct {
+	*httptest.Server
+
+	ConsumerKey, ConsumerSecret string
+	Token, TokenSecret          string
+	// Now returns the current time of the server, time.Now by default.
+	Now func() time.Time
+
+	mutex     sync.Mutex
+	user      string
+	blogs     map[string]*blog
+	posts     []*storedPost
+	nextID    int64
+	following []string
+	likes     []gotumblr.PostID
+	faults    []*Fault
+	nonces    map[string]bool
+	requests  []string
+	uploads   map[string][]byte
+
+	rateLimit   int
+	rateWindow  time.Duration
+	windowStart time.Time
+	windowCount int
+}
+
+// NewServer starts a fake Tumblr API server with the default credentials
+// and a user named "tester" who owns the blog "tester".
+func NewServer() *Server {
+	s := &Server{
+		ConsumerKey:    ConsumerKey,
+		ConsumerSecret: ConsumerSecret,
+		Token:          Token,
+		TokenSecret:    TokenSecret,
+		Now:            time.Now,
+		user:           "tester",
+		blogs:          map[string]*blog{},
+		nextID:         100000000000000000, // larger than 2^53, like real post ids
+		nonces:         map[string]bool{},
+		uploads:        map[string][]byte{},
+	}
+	s.blogs["tester"] = newBlog("tester", "Tester", "")
+	s.blogs["tester"].owned = true
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Client returns a client of the server using its credentials.
+func (s *Server) Client() *gotumblr.TumblrRestClient {
+	return gotumblr.NewTumblrRestClient(s.ConsumerKey, s.ConsumerSecret, s.Token, s.TokenSecret, "", s.URL)
+}
+
+// InjectFault makes the server answer the requests matching f with an error.
+// Faults are matched in the order they were injected.
+func (s *Server) InjectFault(f Fault) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if f.Status == 0 {
+		f.Status = http.StatusServiceUnavailable
+	}
+	if f.Msg == "" {
+		f.Msg = http.StatusText(f.Status)
+	}
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all injected faults.
+func (s *Server) ClearFaults() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.faults = nil
+}
+
+// SetRateLimit makes the server accept at most limit requests per window and answer
+// the others with a 429 status. A limit of 0 removes the rate limit.
+func (s *Server) SetRateLimit(limit int, window time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.rateLimit, s.rateWindow = limit, window
+	s.windowStart, s.windowCount = time.Time{}, 0
+}
+
+// Requests returns the method and path of the requests handled so far, e.g. "GET /v2/user/info".
+func (s *Server) Requests() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.mutex.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	fault := s.matchFault(r)
+	limited, remaining, reset := s.countRequest()
+	rateLimit := s.rateLimit
+	s.mutex.Unlock()
+
+	if rateLimit > 0 {
+		w.Header().Set("X-Ratelimit-Perhour-Limit", strconv.Itoa(rateLimit))
+		w.Header().Set("X-Ratelimit-Perhour-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("X-Ratelimit-Perhour-Reset", strconv.Itoa(reset))
+	}
+	if fault != nil {
+		time.Sleep(fault.Delay)
+		if fault.Body != "" {
+			w.WriteHeader(fault.Status)
+			fmt.Fprint(w, fault.Body)
+		} else {
+			writeError(w, fault.Status, fault.Msg)
+		}
+		return
+	}
+	if limited {
+		w.Header().Set("Retry-After", strconv.Itoa(reset))
+		writeError(w, http.StatusTooManyRequests, "Limit Exceeded")
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, "/media/") {
+		s.mutex.Lock()
+		content, uploaded := s.uploads[r.URL.Path]
+		s.mutex.Unlock()
+		if !uploaded {
+			content = mediaContent(r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(content)
+		return
+	}
+	if !s.authenticate(r) {
+		writeError(w, http.StatusUnauthorized, "Not Authorized")
+		return
+	}
+	// the multipart parameters are not signed, so they are only parsed once the request is authenticated
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	s.mutex.Lock()
+	status, response, location := s.route(r)
+	s.mutex.Unlock()
+	if location != "" {
+		http.Redirect(w, r, s.URL+location, http.StatusMovedPermanently)
+		return
+	}
+	if status/100 != 2 {
+		msg, _ := response.(string)
+		writeError(w, status, msg)
+		return
+	}
+	if r.Method == "GET" {
+		writeCacheable(w, r, status, response)
+		return
+	}
+	writeResponse(w, status, http.StatusText(status), response)
+}
+
+// matchFault returns the fault applying to the request, if any. The mutex must be held.
+func (s *Server) matchFault(r *http.Request) *Fault {
+	for i, fault := range s.faults {
+		if fault.Method != "" && fault.Method != r.Method || !strings.HasPrefix(r.URL.Path, fault.Path) {
+			continue
+		}
+		if fault.Times > 0 {
+			if fault.Times--; fault.Times == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		matched := *fault
+		return &matched
+	}
+	return nil
+}
+
+// countRequest counts a request against the rate limit and reports whether it exceeds it,
+// how many requests remain and in how many seconds the window resets. The mutex must be held.
+func (s *Server) countRequest() (bool, int, int) {
+	if s.rateLimit <= 0 {
+		return false, 0, 0
+	}
+	now := s.Now()
+	if s.windowStart.IsZero() || now.Sub(s.windowStart) >= s.rateWindow {
+		s.windowStart, s.windowCount = now, 0
+	}
+	s.windowCount++
+	reset := int(s.windowStart.Add(s.rateWindow).Sub(now).Seconds() + 0.5)
+	remaining := s.rateLimit - s.windowCount
+	if remaining < 0 {
+		remaining = 0
+	}
+	return s.windowCount > s.rateLimit, remaining, reset
+}
+
+// authenticate accepts requests with a valid OAuth signature,
+// and requests to the endpoints that only need the consumer key with a valid api_key.
+func (s *Server) authenticate(r *http.Request) bool {
+	if r.Header.Get("Authorization") != "" {
+		return s.verifySignature(r)
+	}
+	return apiKeyEndpoint(r) && r.Form.Get("api_key") == s.ConsumerKey
+}
+
+// apiKeyEndpoint reports whether the endpoint of the request can be called with only an api_key.
+func apiKeyEndpoint(r *http.Request) bool {
+	if r.Method != "GET" {
+		return false
+	}
+	if r.URL.Path == "/v2/tagged" {
+		return true
+	}
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(segments) < 4 || segments[0] != "v2" || segments[1] != "blog" {
+		return false
+	}
+	switch segments[3] {
+	case "info", "likes", "avatar":
+		return true
+	case "posts":
+		return len(segments) == 4 || !isPrivateListing(segments[4])
+	}
+	return false
+}
+
+func isPrivateListing(name string) bool {
+	return name == "queue" || name == "draft" || name == "submission"
+}
+
+// responseBody returns the JSON body of a response of the API.
+func responseBody(status int, msg string, response interface{}) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"meta":     map[string]interface{}{"status": status, "msg": msg},
+		"response": response,
+	})
+}
+
+func writeResponse(w http.ResponseWriter, status int, msg string, response interface{}) {
+	body, err := responseBody(status, msg, response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// writeCacheable writes the response of a GET request with an ETag, or a 304 response
+// if the request is conditional on the same ETag.
+func writeCacheable(w http.ResponseWriter, r *http.Request, status int, response interface{}) {
+	body, err := responseBody(status, http.StatusText(status), response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sum := sha1.Sum(body)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	if msg == "" {
+		msg = http.StatusText(status)
+	}
+	writeResponse(w, status, msg, []interface{}{})
+}
+
+// mediaContent returns the content the server serves for a media path:
+// the path itself, so that tests can tell the files apart.
+func mediaContent(path string) []byte {
+	return []byte("media:" + path)
+}
+
+// formInt returns the integer value of a request parameter, or def if it is missing or invalid.
+func formInt(form url.Values, key string, def int) int {
+	value, err := strconv.Atoi(form.Get(key))
+	if err != nil {
+		return def
+	}
+	return value
+}
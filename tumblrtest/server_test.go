@@ -0,0 +1,345 @@
+package tumblrtest_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/spbr/gotumblr"
+	"github.com/spbr/gotumblr/tumblrtest"
+)
+
+var tester = gotumblr.MustParseBlogIdentifier("tester")
+
+// statusOf returns the HTTP status of an *gotumblr.APIError, or 0.
+func statusOf(err error) int {
+	var apiError *gotumblr.APIError
+	if errors.As(err, &apiError) {
+		return apiError.StatusCode
+	}
+	return 0
+}
+
+// replayTransport sends every request twice, and records the status of the replay.
+type replayTransport struct {
+	replayStatus int
+}
+
+func (t *replayTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	response, err := http.DefaultTransport.RoundTrip(r)
+	if err != nil || r.Method != "GET" {
+		return response, err
+	}
+	replay, err := http.DefaultTransport.RoundTrip(r.Clone(r.Context()))
+	if err != nil {
+		return nil, err
+	}
+	replay.Body.Close()
+	t.replayStatus = replay.StatusCode
+	return response, nil
+}
+
+func TestSignature(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+
+	if _, err := server.Client().Info(); err != nil {
+		t.Fatalf("Info with the server credentials: %v", err)
+	}
+
+	// the query and form parameters, with characters to percent-encode, are part of the signature
+	client := server.Client()
+	id, err := client.CreatePost(tester, map[string]string{
+		"type":  "text",
+		"title": "Ünïcode & friends",
+		"body":  "<p>100% \"quoted\" text + spaces</p>",
+		"tags":  "tag one,tag~two",
+	})
+	if err != nil {
+		t.Fatalf("CreatePost: %v", err)
+	}
+	posts, err := client.Posts(tester, "text", map[string]string{"tag": "tag one", "filter": "raw"})
+	if err != nil {
+		t.Fatalf("Posts with a tag: %v", err)
+	}
+	if len(posts.Posts) != 1 {
+		t.Fatalf("Posts with a tag returned %d posts, want 1", len(posts.Posts))
+	}
+	post, err := gotumblr.DecodePost(posts.Posts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := post.(*gotumblr.TextPost); text.ID != id || text.Title != "Ünïcode & friends" {
+		t.Errorf("Posts returned %+v, want the post %s", text, id)
+	}
+
+	for name, wrong := range map[string]*gotumblr.TumblrRestClient{
+		"consumer key":    gotumblr.NewTumblrRestClient("other", tumblrtest.ConsumerSecret, tumblrtest.Token, tumblrtest.TokenSecret, "", server.URL),
+		"consumer secret": gotumblr.NewTumblrRestClient(tumblrtest.ConsumerKey, "other", tumblrtest.Token, tumblrtest.TokenSecret, "", server.URL),
+		"token":           gotumblr.NewTumblrRestClient(tumblrtest.ConsumerKey, tumblrtest.ConsumerSecret, "other", tumblrtest.TokenSecret, "", server.URL),
+		"token secret":    gotumblr.NewTumblrRestClient(tumblrtest.ConsumerKey, tumblrtest.ConsumerSecret, tumblrtest.Token, "other", "", server.URL),
+	} {
+		if _, err := wrong.Info(); statusOf(err) != http.StatusUnauthorized {
+			t.Errorf("Info with another %s: err = %v, want a 401 *APIError", name, err)
+		}
+		if _, err := wrong.CreateText(tester, map[string]string{"body": "x"}); statusOf(err) != http.StatusUnauthorized {
+			t.Errorf("CreateText with another %s: err = %v, want a 401 *APIError", name, err)
+		}
+	}
+
+	response, err := http.Get(server.URL + "/v2/user/info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unsigned request: status %d, want 401", response.StatusCode)
+	}
+	response, err = http.Get(server.URL + "/v2/blog/tester/info?api_key=" + tumblrtest.ConsumerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("request of an api_key endpoint with the api_key: status %d, want 200", response.StatusCode)
+	}
+
+	transport := new(replayTransport)
+	client = server.Client()
+	client.SetHTTPClient(&http.Client{Transport: transport})
+	if _, err := client.Info(); err != nil {
+		t.Fatalf("Info: %v", err)
+	}
+	if transport.replayStatus != http.StatusUnauthorized {
+		t.Errorf("replayed request: status %d, want 401", transport.replayStatus)
+	}
+}
+
+func TestUserAndBlogs(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	server.AddBlog("staff", "Staff", "News from the staff")
+	server.AddFollower("tester", "fan")
+	server.AddFollower("tester", "other-fan")
+	id := server.AddPost("staff", map[string]interface{}{"type": "text", "body": "Hello", "tags": []string{"news"}})
+	server.AddPost("tester", map[string]interface{}{"type": "text", "body": "Mine"})
+
+	info, err := client.Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user := info.User; user.Name != "tester" || user.DefaultPostFormat != "html" || len(user.Blogs) != 1 || user.Blogs[0].Name != "tester" || !user.Blogs[0].Primary {
+		t.Errorf("Info = %+v", user)
+	}
+
+	blogInfo, err := client.BlogInfo(tester)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blog := blogInfo.Blog; blog.Name != "tester" || blog.Posts != 1 || !blog.Ask || !blog.AskAnon {
+		t.Errorf("BlogInfo = %+v", blog)
+	}
+
+	followers, err := client.Followers(tester, map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if followers.TotalUsers != 2 || len(followers.Users) != 2 {
+		t.Errorf("Followers = %+v, want 2 users", followers)
+	}
+
+	staff := gotumblr.MustParseBlogIdentifier("staff")
+	if _, err := client.Follow(staff); err != nil {
+		t.Fatal(err)
+	}
+	following, err := client.Following(map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if following.TotalBlogs != 1 || len(following.Blogs) != 1 || following.Blogs[0].Name != "staff" {
+		t.Errorf("Following = %+v, want staff", following)
+	}
+
+	post, _ := server.Post(id)
+	if _, err := client.Like(id, post["reblog_key"].(string)); err != nil {
+		t.Fatal(err)
+	}
+	if !server.Liked(id) {
+		t.Error("the post is not liked")
+	}
+	likes, err := client.Likes(map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if likes.LikedCount != 1 || len(likes.LikedPosts) != 1 {
+		t.Errorf("Likes = %d posts, count %d, want 1", len(likes.LikedPosts), likes.LikedCount)
+	}
+}
+
+func TestBlogIdentifiers(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	server.AddBlog("staff", "Staff", "")
+	server.SetCustomDomain("staff", "News.Example.com")
+	server.AddPost("staff", map[string]interface{}{"type": "text", "body": "Hello"})
+
+	for _, identifier := range []string{"staff", "staff.tumblr.com", "news.example.com", "https://NEWS.example.com/", server.BlogUUID("staff")} {
+		blog, err := gotumblr.ParseBlogIdentifier(identifier)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, err := client.BlogInfo(blog)
+		if err != nil {
+			t.Errorf("BlogInfo(%q): %v", identifier, err)
+			continue
+		}
+		if info.Blog.Name != "staff" || info.Blog.UUID != server.BlogUUID("staff") || info.Blog.URL != "https://news.example.com/" {
+			t.Errorf("BlogInfo(%q) = %+v, want staff", identifier, info.Blog)
+		}
+		if blog.URL() == "" {
+			continue // the follow endpoint takes URLs
+		}
+		if _, err := client.Follow(blog); err != nil {
+			t.Errorf("Follow(%q): %v", identifier, err)
+		}
+		if following := server.Following(); len(following) != 1 || following[0] != "staff" {
+			t.Errorf("after Follow(%q), following %v, want staff", identifier, following)
+		}
+		if _, err := client.Unfollow(blog); err != nil {
+			t.Errorf("Unfollow(%q): %v", identifier, err)
+		}
+	}
+
+	for _, identifier := range []string{"other.example.com", "t:KwxGbqn0_Ti2cs4ZDxIERA"} {
+		_, err := client.BlogInfo(gotumblr.MustParseBlogIdentifier(identifier))
+		if statusOf(err) != http.StatusNotFound {
+			t.Errorf("BlogInfo(%q): err = %v, want a 404", identifier, err)
+		}
+	}
+	if uuid := server.BlogUUID("tester"); uuid == "" || uuid == server.BlogUUID("staff") {
+		t.Errorf("the UUIDs of tester and staff are %q and %q, want distinct UUIDs", uuid, server.BlogUUID("staff"))
+	}
+}
+
+func TestPosts(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	client := server.Client()
+
+	id, err := client.CreatePost(tester, map[string]string{"type": "text", "body": "Draft", "state": "draft", "tags": "Golang,misc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	drafts, err := client.Drafts(tester, map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drafts.Posts) != 1 {
+		t.Fatalf("Drafts returned %d posts, want 1", len(drafts.Posts))
+	}
+
+	if _, err := client.EditPost(tester, map[string]string{"id": id.String(), "state": "published", "tags": "golang,misc"}); err != nil {
+		t.Fatal(err)
+	}
+	// the tag filter ignores case, as the API does
+	posts, err := client.Posts(tester, "", map[string]string{"tag": "GoLang"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if posts.TotalPosts != 1 || len(posts.Posts) != 1 {
+		t.Fatalf("Posts with a tag = %d posts, total %d, want 1", len(posts.Posts), posts.TotalPosts)
+	}
+	post, err := gotumblr.DecodePost(posts.Posts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base := post.Base(); base.ID != id || base.IDString != id.String() || base.State != "published" || len(base.Tags) != 2 || base.Tags[0] != "golang" {
+		t.Errorf("edited post = %+v", base)
+	}
+
+	if _, err := client.DeletePost(tester, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := server.Post(id); ok {
+		t.Error("the deleted post is still stored")
+	}
+	if _, err := client.DeletePost(tester, id); statusOf(err) != http.StatusNotFound {
+		t.Errorf("DeletePost of a deleted post: err = %v, want a 404 *APIError", err)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	client := server.Client()
+
+	server.InjectFault(tumblrtest.Fault{Path: "/v2/user/info", Status: http.StatusServiceUnavailable, Times: 1})
+	if _, err := client.Info(); statusOf(err) != http.StatusServiceUnavailable {
+		t.Errorf("Info with a fault: err = %v, want a 503 *APIError", err)
+	}
+	if _, err := client.Info(); err != nil {
+		t.Errorf("Info once the fault is used up: %v", err)
+	}
+
+	server.InjectFault(tumblrtest.Fault{Path: "/v2/user/info", Body: "<html>oops</html>", Status: http.StatusOK, Times: 1})
+	if _, err := client.Info(); !errors.As(err, new(*gotumblr.DecodeError)) {
+		t.Errorf("Info with a malformed response: err = %v, want a *DecodeError", err)
+	}
+
+	server.SetRateLimit(2, time.Hour)
+	for i := 0; i < 2; i++ {
+		if _, err := client.Info(); err != nil {
+			t.Fatalf("request %d within the rate limit: %v", i, err)
+		}
+	}
+	_, err := client.Info()
+	if !gotumblr.IsRateLimited(err) {
+		t.Errorf("request over the rate limit: err = %v, want a rate limit error", err)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 6 || requests[0] != "GET /v2/user/info" {
+		t.Errorf("Requests = %q", requests)
+	}
+}
+
+func TestMedia(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	client := server.Client()
+
+	content := []byte("\x89PNG fake image")
+	id, err := client.CreatePost(tester, map[string]string{"type": "photo"},
+		gotumblr.Upload{Param: "data[0]", Filename: "image.png", Content: bytes.NewReader(content)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := server.Post(id)
+	data, err := json.Marshal(stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	post, err := gotumblr.DecodePost(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	photos := post.(*gotumblr.PhotoPost).Photos
+	if len(photos) != 1 {
+		t.Fatalf("the photo post has %d photos, want 1", len(photos))
+	}
+	response, err := http.Get(photos[0].Original().URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	body, _ := ioutil.ReadAll(response.Body)
+	if string(body) != string(content) {
+		t.Errorf("the uploaded photo is served as %q, want %q", body, content)
+	}
+}
@@ -0,0 +1,12 @@
+package gotumblr
+
+import "io"
+
+// Upload is a file sent along the parameters of a request, as multipart form data.
+type Upload struct {
+	// Param is the name of the parameter: data for the file of an audio or video post,
+	// data[0], data[1]... for the photos of a photo post.
+	Param    string
+	Filename string
+	Content  io.Reader
+}
@@ -1,5 +1,7 @@
 package gotumblr
 
+import "time"
+
 //UserInfo holds the tumbler user data
 type User struct {
 	Name      string
@@ -7,3 +9,8 @@ type User struct {
 	URL       string
 	Updated   int64
 }
+
+// UpdatedTime returns the time the user's blog was last updated in UTC.
+func (u *User) UpdatedTime() time.Time {
+	return unixTime(u.Updated)
+}
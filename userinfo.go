@@ -3,7 +3,7 @@ package gotumblr
 // UserInfo holds the Tumbler userinfo data
 type UserInfo struct {
 	Following         int64
-	DefaultPostFormat string
+	DefaultPostFormat string `json:"default_post_format"`
 	Name              string
 	Likes             int64
 	Blogs             []OwnedBlog
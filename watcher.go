@@ -0,0 +1,429 @@
+package gotumblr
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Checkpoint records how far a Watcher got, so that a restarted watcher does not emit posts again.
+// It can be persisted as JSON.
+type Checkpoint struct {
+	Dashboard PostID            `json:"dashboard,omitempty"`  // id of the newest dashboard post seen
+	Blogs     map[string]PostID `json:"blogs,omitempty"`      // id of the newest post seen, by blog hostname
+	Tagged    int64             `json:"tagged,omitempty"`     // timestamp of the newest tagged post seen
+	TaggedIDs []PostID          `json:"tagged_ids,omitempty"` // ids of the tagged posts seen with that timestamp
+}
+
+// Watcher polls the dashboard, and optionally the posts of some blogs or a tag,
+// and emits every new post once. Every poll pages back to the checkpoint of its source,
+// so that no post is missed however many were published since the previous poll;
+// the first poll of a source fetches a single page. The polling interval starts at MinInterval,
+// doubles up to MaxInterval while there are no new posts and returns to MinInterval when there are.
+// The fields must be set before the watcher is run.
+type Watcher struct {
+	Dashboard    bool             // watch the user's dashboard (set by NewWatcher)
+	Blogs        []BlogIdentifier // watch the posts of these blogs
+	Tag          string           // watch the posts tagged with this tag, if not empty
+	Limit        int              // posts requested per page, 20 by default
+	MinInterval  time.Duration    // 30 seconds by default
+	MaxInterval  time.Duration    // 5 minutes by default
+	SkipExisting bool             // do not emit the posts found by the first poll of a source without checkpoint
+
+	// OnError is called when a poll fails. The watcher keeps polling.
+	OnError func(error)
+	// OnCheckpoint is called with the updated checkpoint after every poll that found new posts,
+	// after they have been emitted, so it can be persisted.
+	OnCheckpoint func(Checkpoint)
+
+	client     TumblrReader
+	mutex      sync.Mutex
+	checkpoint Checkpoint
+	seen       map[PostID]bool
+	seenOrder  []PostID
+}
+
+// watcherSeenSize is the number of recent post ids a Watcher remembers
+// to avoid emitting a post found by several sources twice.
+const watcherSeenSize = 1000
+
+// NewWatcher creates a Watcher of the user's dashboard.
+func NewWatcher(client TumblrReader) *Watcher {
+	return &Watcher{Dashboard: true, client: client}
+}
+
+// Checkpoint returns the current checkpoint of the watcher.
+func (w *Watcher) Checkpoint() Checkpoint {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.copyCheckpoint()
+}
+
+// Restore sets the checkpoint the watcher starts from, e.g. one saved by OnCheckpoint.
+func (w *Watcher) Restore(checkpoint Checkpoint) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.checkpoint = checkpoint
+	w.checkpoint.Blogs = map[string]PostID{}
+	for blog, id := range checkpoint.Blogs {
+		w.checkpoint.Blogs[blog] = id
+	}
+	w.checkpoint.TaggedIDs = append([]PostID(nil), checkpoint.TaggedIDs...)
+}
+
+// Watch runs the watcher in a new goroutine and returns a channel of the new posts,
+// which is closed once ctx is cancelled.
+func (w *Watcher) Watch(ctx context.Context) <-chan Post {
+	posts := make(chan Post)
+	go func() {
+		defer close(posts)
+		w.Run(ctx, func(post Post) {
+			select {
+			case posts <- post:
+			case <-ctx.Done():
+			}
+		})
+	}()
+	return posts
+}
+
+// Run polls until ctx is cancelled, calling handle with every new post,
+// oldest first. It returns the error of the context.
+func (w *Watcher) Run(ctx context.Context, handle func(Post)) error {
+	minInterval, maxInterval := w.MinInterval, w.MaxInterval
+	if minInterval <= 0 {
+		minInterval = 30 * time.Second
+	}
+	if maxInterval < minInterval {
+		maxInterval = 5 * time.Minute
+		if maxInterval < minInterval {
+			maxInterval = minInterval
+		}
+	}
+	interval := minInterval
+	for {
+		if w.poll(ctx, handle) {
+			interval = minInterval
+		} else if interval *= 2; interval > maxInterval {
+			interval = maxInterval
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// poll polls every source once and reports whether new posts were found.
+func (w *Watcher) poll(ctx context.Context, handle func(Post)) bool {
+	found := false
+	if w.Dashboard {
+		found = w.pollSource(ctx, handle, w.pollDashboard) || found
+	}
+	for _, blog := range w.Blogs {
+		blog := blog
+		found = w.pollSource(ctx, handle, func(ctx context.Context) (watchResult, error) {
+			return w.pollBlog(ctx, blog)
+		}) || found
+	}
+	if w.Tag != "" {
+		found = w.pollSource(ctx, handle, w.pollTagged) || found
+	}
+	if found && w.OnCheckpoint != nil {
+		w.OnCheckpoint(w.Checkpoint())
+	}
+	return found
+}
+
+// watchResult is the result of polling one source of a Watcher.
+type watchResult struct {
+	posts  []Post
+	isNew  func(Post) bool // tells whether a post is newer than the checkpoint
+	commit func()          // advances the checkpoint past the posts
+	first  bool            // the source had no checkpoint yet
+}
+
+// pollSource polls a source and emits its new posts.
+// The checkpoint is not advanced if ctx is cancelled while the posts are emitted.
+func (w *Watcher) pollSource(ctx context.Context, handle func(Post), source func(context.Context) (watchResult, error)) bool {
+	result, err := source(ctx)
+	if err != nil {
+		w.fail(err)
+		return false
+	}
+	if result.first && w.SkipExisting {
+		w.markSeen(result.posts)
+		result.commit()
+		return false
+	}
+	found := w.emit(ctx, result.posts, result.isNew, handle)
+	if ctx.Err() == nil {
+		result.commit()
+	}
+	return found
+}
+
+func (w *Watcher) pollDashboard(ctx context.Context) (watchResult, error) {
+	w.mutex.Lock()
+	since := w.checkpoint.Dashboard
+	w.mutex.Unlock()
+	client := w.reader(ctx)
+	posts, err := w.pageBack(ctx, since, func(offset int) ([]json.RawMessage, error) {
+		options := w.options()
+		options["offset"] = strconv.Itoa(offset)
+		if since != 0 {
+			options["since_id"] = since.String()
+		}
+		response, err := client.Dashboard(options)
+		if err != nil {
+			return nil, err
+		}
+		return response.Posts, nil
+	})
+	if err != nil {
+		return watchResult{}, err
+	}
+	newest := newestID(posts, since)
+	commit := func() {
+		w.mutex.Lock()
+		w.checkpoint.Dashboard = newest
+		w.mutex.Unlock()
+	}
+	return watchResult{posts, func(post Post) bool { return post.Base().ID > since }, commit, since == 0}, nil
+}
+
+func (w *Watcher) pollBlog(ctx context.Context, blog BlogIdentifier) (watchResult, error) {
+	key := blog.Hostname()
+	w.mutex.Lock()
+	since := w.checkpoint.Blogs[key]
+	w.mutex.Unlock()
+	client := w.reader(ctx)
+	posts, err := w.pageBack(ctx, since, func(offset int) ([]json.RawMessage, error) {
+		options := w.options()
+		options["offset"] = strconv.Itoa(offset)
+		response, err := client.Posts(blog, "", options)
+		if err != nil {
+			return nil, err
+		}
+		return response.Posts, nil
+	})
+	if err != nil {
+		return watchResult{}, err
+	}
+	newest := newestID(posts, since)
+	commit := func() {
+		w.mutex.Lock()
+		if w.checkpoint.Blogs == nil {
+			w.checkpoint.Blogs = map[string]PostID{}
+		}
+		w.checkpoint.Blogs[key] = newest
+		w.mutex.Unlock()
+	}
+	return watchResult{posts, func(post Post) bool { return post.Base().ID > since }, commit, since == 0}, nil
+}
+
+// pageBack fetches the pages of a source listing the newest posts first, given a function
+// fetching the page at an offset, until it reaches the post since or the end of the listing,
+// so that no post newer than since is missed however many were published since the last poll.
+// The first poll of a source, without since, fetches a single page.
+func (w *Watcher) pageBack(ctx context.Context, since PostID, page func(offset int) ([]json.RawMessage, error)) ([]Post, error) {
+	limit := w.limit()
+	var posts []Post
+	found := map[PostID]bool{}
+	for offset := 0; ; {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		data, err := page(offset)
+		if err != nil {
+			return nil, err
+		}
+		offset += len(data)
+		reached, fresh := false, false
+		for _, post := range w.decode(data) {
+			id := post.Base().ID
+			if id <= since {
+				reached = true
+			}
+			if !found[id] {
+				// the posts published while paging shift the pages, showing some posts twice
+				found[id], fresh = true, true
+				posts = append(posts, post)
+			}
+		}
+		if since == 0 || reached || !fresh || len(data) < limit {
+			return posts, nil
+		}
+	}
+}
+func (w *Watcher) pollTagged(ctx context.Context) (watchResult, error) {
+	w.mutex.Lock()
+	since := w.checkpoint.Tagged
+	sinceIDs := map[PostID]bool{}
+	for _, id := range w.checkpoint.TaggedIDs {
+		sinceIDs[id] = true
+	}
+	w.mutex.Unlock()
+	// the posts are paged back to the checkpoint like those of the other sources
+	it := NewTaggedIterator(w.reader(ctx), w.Tag)
+	it.Limit = w.Limit
+	if since != 0 {
+		it.Horizon = time.Unix(since, 0)
+	}
+	var posts []Post
+	for (since != 0 || len(posts) < w.limit()) && ctx.Err() == nil && it.Next() {
+		posts = append(posts, it.Post())
+	}
+	if err := it.Err(); err != nil {
+		return watchResult{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return watchResult{}, err
+	}
+	isNew := func(post Post) bool {
+		base := post.Base()
+		return base.Timestamp > since || base.Timestamp == since && !sinceIDs[base.ID]
+	}
+	newest, newestIDs := since, map[PostID]bool{}
+	for id := range sinceIDs {
+		newestIDs[id] = true
+	}
+	for _, post := range posts {
+		base := post.Base()
+		if base.Timestamp > newest {
+			newest, newestIDs = base.Timestamp, map[PostID]bool{}
+		}
+		if base.Timestamp == newest {
+			newestIDs[base.ID] = true
+		}
+	}
+	ids := make([]PostID, 0, len(newestIDs))
+	for id := range newestIDs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	commit := func() {
+		w.mutex.Lock()
+		w.checkpoint.Tagged, w.checkpoint.TaggedIDs = newest, ids
+		w.mutex.Unlock()
+	}
+	return watchResult{posts, isNew, commit, since == 0}, nil
+}
+
+// newestID returns the largest id among the posts and since.
+func newestID(posts []Post, since PostID) PostID {
+	newest := since
+	for _, post := range posts {
+		if post.Base().ID > newest {
+			newest = post.Base().ID
+		}
+	}
+	return newest
+}
+
+// emit calls handle with the new posts not seen from another source, oldest first,
+// and reports whether there were any.
+func (w *Watcher) emit(ctx context.Context, posts []Post, isNew func(Post) bool, handle func(Post)) bool {
+	var fresh []Post
+	for _, post := range posts {
+		if isNew(post) {
+			fresh = append(fresh, post)
+		}
+	}
+	sort.SliceStable(fresh, func(i, j int) bool {
+		a, b := fresh[i].Base(), fresh[j].Base()
+		return a.Timestamp < b.Timestamp || a.Timestamp == b.Timestamp && a.ID < b.ID
+	})
+	for _, post := range fresh {
+		if ctx.Err() != nil {
+			break
+		}
+		if w.markSeen([]Post{post}) {
+			handle(post)
+		}
+	}
+	return len(fresh) != 0
+}
+
+// markSeen remembers the ids of the posts and reports whether any of them was not seen before.
+func (w *Watcher) markSeen(posts []Post) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.seen == nil {
+		w.seen = map[PostID]bool{}
+	}
+	unseen := false
+	for _, post := range posts {
+		id := post.Base().ID
+		if w.seen[id] {
+			continue
+		}
+		unseen = true
+		w.seen[id] = true
+		w.seenOrder = append(w.seenOrder, id)
+		if len(w.seenOrder) > watcherSeenSize {
+			delete(w.seen, w.seenOrder[0])
+			w.seenOrder = w.seenOrder[1:]
+		}
+	}
+	return unseen
+}
+
+// decode decodes the posts, reporting those that cannot be decoded to OnError.
+func (w *Watcher) decode(data []json.RawMessage) []Post {
+	posts := make([]Post, 0, len(data))
+	for _, raw := range data {
+		post, err := DecodePost(raw)
+		if err != nil {
+			w.fail(err)
+			continue
+		}
+		posts = append(posts, post)
+	}
+	return posts
+}
+
+func (w *Watcher) options() map[string]string {
+	return map[string]string{"limit": strconv.Itoa(w.limit())}
+}
+
+func (w *Watcher) limit() int {
+	if w.Limit <= 0 {
+		return 20
+	}
+	return w.Limit
+}
+
+// reader returns the client bound to ctx if it is a *TumblrRestClient.
+func (w *Watcher) reader(ctx context.Context) TumblrReader {
+	if client, ok := w.client.(*TumblrRestClient); ok {
+		return client.WithContext(ctx)
+	}
+	return w.client
+}
+
+func (w *Watcher) fail(err error) {
+	if w.OnError != nil {
+		w.OnError(err)
+	}
+}
+
+// copyCheckpoint returns a copy of the checkpoint that does not share its maps and slices.
+// The mutex must be held.
+func (w *Watcher) copyCheckpoint() Checkpoint {
+	checkpoint := w.checkpoint
+	if w.checkpoint.Blogs != nil {
+		checkpoint.Blogs = map[string]PostID{}
+		for blog, id := range w.checkpoint.Blogs {
+			checkpoint.Blogs[blog] = id
+		}
+	}
+	checkpoint.TaggedIDs = append([]PostID(nil), w.checkpoint.TaggedIDs...)
+	return checkpoint
+}
@@ -0,0 +1,138 @@
+package gotumblr_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spbr/gotumblr"
+	"github.com/spbr/gotumblr/tumblrtest"
+)
+
+// addTimedPosts adds n text posts to a blog, a second apart after start, and returns their ids.
+func addTimedPosts(server *tumblrtest.Server, blog string, start time.Time, n int, tags ...string) []gotumblr.PostID {
+	var ids []gotumblr.PostID
+	for i := 0; i < n; i++ {
+		ids = append(ids, server.AddPost(blog, map[string]interface{}{
+			"type": "text", "body": "x", "tags": tags,
+			"timestamp": start.Add(time.Duration(i) * time.Second).Unix(),
+		}))
+	}
+	return ids
+}
+
+// watchPolls runs the watcher until it has polled polls times, between which next is called,
+// and returns the ids of the posts emitted.
+func watchPolls(t *testing.T, w *gotumblr.Watcher, polls int, next func(poll int)) []gotumblr.PostID {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	w.MinInterval, w.MaxInterval = time.Millisecond, time.Millisecond
+	w.OnError = func(err error) { t.Error(err) }
+	var ids []gotumblr.PostID
+	poll := 0
+	// the watcher only reports polls that found posts, so every poll is expected to find some
+	w.OnCheckpoint = func(gotumblr.Checkpoint) {
+		if poll++; poll == polls {
+			cancel()
+		} else {
+			next(poll)
+		}
+	}
+	w.Run(ctx, func(post gotumblr.Post) { ids = append(ids, post.Base().ID) })
+	if poll != polls {
+		t.Fatalf("%d polls found posts, want %d", poll, polls)
+	}
+	return ids
+}
+
+func equalIDs(a, b []gotumblr.PostID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestWatcherGap(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	server.AddBlog("staff", "Staff", "")
+	client := server.Client()
+	if _, err := client.Follow(gotumblr.MustParseBlogIdentifier("staff")); err != nil {
+		t.Fatal(err)
+	}
+	clock := time.Now().Add(-time.Hour)
+	add := func(n int) []gotumblr.PostID {
+		ids := addTimedPosts(server, "staff", clock, n, "news")
+		clock = clock.Add(time.Duration(n) * time.Second)
+		return ids
+	}
+	existing := add(8)
+
+	for _, source := range []string{"dashboard", "blog", "tag"} {
+		t.Run(source, func(t *testing.T) {
+			w := gotumblr.NewWatcher(client)
+			w.Dashboard = source == "dashboard"
+			if source == "blog" {
+				w.Blogs = []gotumblr.BlogIdentifier{gotumblr.MustParseBlogIdentifier("staff")}
+			}
+			if source == "tag" {
+				w.Tag = "news"
+			}
+			w.Limit = 5
+			var added []gotumblr.PostID
+			ids := watchPolls(t, w, 2, func(int) {
+				// many more posts than a page between two polls
+				added = add(12)
+			})
+			// the first poll emits the newest page only, oldest first
+			want := append(append([]gotumblr.PostID(nil), existing[len(existing)-5:]...), added...)
+			if !equalIDs(ids, want) {
+				t.Errorf("emitted %v, want %v", ids, want)
+			}
+			existing = append(existing, added...)
+		})
+	}
+}
+
+func TestWatcherRestore(t *testing.T) {
+	server := tumblrtest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	blog := gotumblr.MustParseBlogIdentifier("tester")
+	start := time.Now().Add(-time.Hour)
+	existing := addTimedPosts(server, "tester", start, 3, "news")
+	newWatcher := func() *gotumblr.Watcher {
+		w := gotumblr.NewWatcher(client)
+		w.Dashboard = false
+		w.Blogs = []gotumblr.BlogIdentifier{blog}
+		w.Tag = "news"
+		w.Limit = 5
+		return w
+	}
+
+	first := newWatcher()
+	if ids := watchPolls(t, first, 1, nil); !equalIDs(ids, existing) {
+		t.Fatalf("first watcher emitted %v, want %v", ids, existing)
+	}
+	checkpoint := first.Checkpoint()
+	if checkpoint.Blogs["tester.tumblr.com"] != existing[2] || checkpoint.Tagged != start.Add(2*time.Second).Unix() {
+		t.Errorf("checkpoint %+v, want the last of %v", checkpoint, existing)
+	}
+
+	// a restarted watcher emits the posts published since the checkpoint, and only them
+	added := addTimedPosts(server, "tester", start.Add(time.Minute), 13, "news")
+	second := newWatcher()
+	second.Restore(checkpoint)
+	if ids := watchPolls(t, second, 1, nil); !equalIDs(ids, added) {
+		t.Errorf("restored watcher emitted %v, want %v", ids, added)
+	}
+	checkpoint = second.Checkpoint()
+	if checkpoint.Blogs["tester.tumblr.com"] != added[12] || len(checkpoint.TaggedIDs) != 1 || checkpoint.TaggedIDs[0] != added[12] {
+		t.Errorf("checkpoint %+v, want the last of %v", checkpoint, added)
+	}
+}